@@ -1,9 +1,14 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"code.cloudfoundry.org/lager"
 
@@ -12,6 +17,11 @@ import (
 	"github.com/cloudfoundry-incubator/galera-healthcheck/api/middleware"
 	"github.com/cloudfoundry-incubator/galera-healthcheck/config"
 	"github.com/cloudfoundry-incubator/galera-healthcheck/domain"
+	"github.com/cloudfoundry-incubator/galera-healthcheck/healthcheck"
+	"github.com/cloudfoundry-incubator/galera-healthcheck/metrics"
+	"github.com/cloudfoundry-incubator/galera-healthcheck/monit_client"
+	"github.com/cloudfoundry-incubator/galera-healthcheck/mysqld_cmd"
+	"github.com/cloudfoundry-incubator/galera-healthcheck/requestid"
 )
 
 //go:generate go run github.com/maxbrunsfeld/counterfeiter/v6 . ReqHealthChecker
@@ -22,6 +32,16 @@ type ReqHealthChecker interface {
 //go:generate go run github.com/maxbrunsfeld/counterfeiter/v6 . HealthChecker
 type HealthChecker interface {
 	Check() (string, error)
+	CheckStatus() (healthcheck.CheckStatus, error)
+	WsrepStatus() (map[string]string, error)
+	WsrepVariable(name string) (string, error)
+	StatusLike(pattern string) (map[string]string, error)
+	Ping(ctx context.Context) error
+	PingDB() error
+	ClusterSize() (int, error)
+	ClientConnections() (int, error)
+	WsrepMetrics() (map[string]float64, error)
+	ResetCache()
 }
 
 //go:generate go run github.com/maxbrunsfeld/counterfeiter/v6 . StateSnapshotter
@@ -36,15 +56,29 @@ type MonitClient interface {
 	StartServiceSingleNode(req *http.Request) (string, error)
 	StopService(req *http.Request) (string, error)
 	GetStatus(req *http.Request) (string, error)
+	GetProcessStats(req *http.Request) (monit_client.ProcessStats, error)
+	GetServices(req *http.Request) ([]monit_client.ServiceSummary, error)
+	ReloadMonit(req *http.Request) (string, error)
+	UnmonitorService(req *http.Request) (string, error)
+	MonitorService(req *http.Request) (string, error)
+	GetState(req *http.Request) (*string, error)
 }
 
 //go:generate go run github.com/maxbrunsfeld/counterfeiter/v6 . SequenceNumberChecker
 type SequenceNumberChecker interface {
 	Check(req *http.Request) (string, error)
+	RecoverPosition(req *http.Request) (mysqld_cmd.RecoveredPosition, error)
 }
 
 type RunFunc func(req *http.Request) (string, error)
 
+// BuildInfo identifies the build of the running sidecar.
+type BuildInfo struct {
+	SHA     string `json:"sha"`
+	Version string `json:"version"`
+	BuiltAt string `json:"built_at"`
+}
+
 type router struct {
 	logger                lager.Logger
 	rootConfig            *config.Config
@@ -53,6 +87,10 @@ type router struct {
 	reqHealthChecker      ReqHealthChecker
 	healthchecker         HealthChecker
 	stateSnapshotter      StateSnapshotter
+	buildInfo             BuildInfo
+	metrics               *metrics.Registry
+	drain                 *drainFlag
+	role                  *nodeRole
 }
 
 func NewRouter(
@@ -63,8 +101,55 @@ func NewRouter(
 	reqHealthChecker ReqHealthChecker,
 	healthchecker HealthChecker,
 	stateSnapshotter StateSnapshotter,
+	buildInfo BuildInfo,
+	metricsRegistry *metrics.Registry,
 ) (http.Handler, error) {
-	r := router{
+	return newRouter(logger, rootConfig, monitClient, sequenceNumberChecker, reqHealthChecker, healthchecker, stateSnapshotter, buildInfo, metricsRegistry).handler(func(string) bool { return true })
+}
+
+// NewSplitRouters is NewRouter for a sidecar that serves its API on two ports.
+// The public router serves only the unauthenticated health endpoints that
+// load balancers probe, and the admin router serves everything else. Both
+// share the same state, so draining the node through the admin router takes
+// it out of rotation on the public one.
+func NewSplitRouters(
+	logger lager.Logger,
+	rootConfig *config.Config,
+	monitClient MonitClient,
+	sequenceNumberChecker SequenceNumberChecker,
+	reqHealthChecker ReqHealthChecker,
+	healthchecker HealthChecker,
+	stateSnapshotter StateSnapshotter,
+	buildInfo BuildInfo,
+	metricsRegistry *metrics.Registry,
+) (public http.Handler, admin http.Handler, err error) {
+	r := newRouter(logger, rootConfig, monitClient, sequenceNumberChecker, reqHealthChecker, healthchecker, stateSnapshotter, buildInfo, metricsRegistry)
+
+	public, err = r.handler(isPublicRoute)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	admin, err = r.handler(func(name string) bool { return !isPublicRoute(name) })
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return public, admin, nil
+}
+
+func newRouter(
+	logger lager.Logger,
+	rootConfig *config.Config,
+	monitClient MonitClient,
+	sequenceNumberChecker SequenceNumberChecker,
+	reqHealthChecker ReqHealthChecker,
+	healthchecker HealthChecker,
+	stateSnapshotter StateSnapshotter,
+	buildInfo BuildInfo,
+	metricsRegistry *metrics.Registry,
+) router {
+	return router{
 		logger:                logger,
 		rootConfig:            rootConfig,
 		monitClient:           monitClient,
@@ -72,75 +157,351 @@ func NewRouter(
 		reqHealthChecker:      reqHealthChecker,
 		healthchecker:         healthchecker,
 		stateSnapshotter:      stateSnapshotter,
+		buildInfo:             buildInfo,
+		metrics:               metricsRegistry,
+		drain:                 &drainFlag{},
+		role:                  newNodeRole(rootConfig.Role),
 	}
+}
+
+// publicRoutes are the routes served by the public router of NewSplitRouters.
+var publicRoutes = map[string]bool{
+	"v1_status":          true,
+	"version":            true,
+	"live":               true,
+	"ready":              true,
+	"db_ping":            true,
+	"galera_status":      true,
+	"galera_status_head": true,
+	"root":               true,
+	"root_head":          true,
+	"metrics":            true,
+}
+
+// healthRoutes are the routes load balancers and proxies probe. Their
+// responses carry the configured ResponseHeaders so that a cached 200 cannot
+// hide a node that has since become unhealthy.
+var healthRoutes = map[string]bool{
+	"v1_status":          true,
+	"live":               true,
+	"ready":              true,
+	"db_ping":            true,
+	"galera_status":      true,
+	"galera_status_head": true,
+	"root":               true,
+	"root_head":          true,
+}
 
+func isPublicRoute(name string) bool {
+	return publicRoutes[strings.TrimSuffix(name, "_preflight")]
+}
+
+// handler routes requests to the API's endpoints, serving only the routes
+// include accepts.
+func (r router) handler(include func(name string) bool) (http.Handler, error) {
 	routes := rata.Routes{
 		{Name: "v1_status", Method: "GET", Path: "/api/v1/status"},
+		{Name: "version", Method: "GET", Path: "/version"},
+		{Name: "live", Method: "GET", Path: "/live"},
+		{Name: "ready", Method: "GET", Path: "/ready"},
+		{Name: "db_ping", Method: "GET", Path: "/db_ping"},
 
 		{Name: "mysql_status", Method: "GET", Path: "/mysql_status"},
+		{Name: "mysql_stats", Method: "GET", Path: "/mysql_stats"},
 		{Name: "stop_mysql", Method: "POST", Path: "/stop_mysql"},
+		{Name: "stop_mysql_graceful", Method: "POST", Path: "/stop_mysql_graceful"},
 		{Name: "start_mysql_bootstrap", Method: "POST", Path: "/start_mysql_bootstrap"},
 		{Name: "start_mysql_join", Method: "POST", Path: "/start_mysql_join"},
 		{Name: "start_mysql_single_node", Method: "POST", Path: "/start_mysql_single_node"},
+		{Name: "monit_reload", Method: "POST", Path: "/monit/reload"},
+		{Name: "monit_services", Method: "GET", Path: "/monit/services"},
+		{Name: "monit_unmonitor", Method: "POST", Path: "/monit/unmonitor"},
+		{Name: "monit_monitor", Method: "POST", Path: "/monit/monitor"},
+		{Name: "state", Method: "GET", Path: "/state"},
+		{Name: "config", Method: "GET", Path: "/config"},
 		{Name: "sequence_number", Method: "GET", Path: "/sequence_number"},
+		{Name: "sequence_number_compare", Method: "POST", Path: "/sequence_number/compare"},
+		{Name: "wsrep_recover", Method: "POST", Path: "/wsrep_recover"},
 		{Name: "galera_status", Method: "GET", Path: "/galera_status"},
+		{Name: "galera_status_head", Method: "HEAD", Path: "/galera_status"},
+		{Name: "wsrep_status", Method: "GET", Path: "/wsrep_status"},
+		{Name: "metrics_wsrep", Method: "GET", Path: "/metrics/wsrep"},
+		{Name: "wsrep_variable", Method: "GET", Path: "/wsrep"},
+		{Name: "status_like", Method: "GET", Path: "/status"},
+		{Name: "cluster_size", Method: "GET", Path: "/cluster_size"},
+		{Name: "health", Method: "GET", Path: "/health"},
+		{Name: "drain", Method: "POST", Path: "/drain"},
+		{Name: "role", Method: "POST", Path: "/role"},
+		{Name: "undrain", Method: "POST", Path: "/undrain"},
+		{Name: "reset", Method: "POST", Path: "/reset"},
 		{Name: "root", Method: "GET", Path: "/"},
+		{Name: "root_head", Method: "HEAD", Path: "/"},
 	}
 
 	handlers := rata.Handlers{
 		"v1_status": r.v1Status(),
+		"version":   r.version(),
+		"live":      r.live(),
+		"ready":     r.ready(),
+		"db_ping":   r.dbPing(),
+
+		"mysql_status":            r.authenticated(r.mysqlStatus()),
+		"mysql_stats":             r.authenticated(r.mysqlStats()),
+		"stop_mysql":              r.getMutatingHandler(ErrorCodeServiceStopFailed, r.guardClusterSize(r.monitClient.StopService)),
+		"stop_mysql_graceful":     r.getMutatingHandler(ErrorCodeServiceStopFailed, r.guardClusterSize(r.stopGracefully)),
+		"start_mysql_bootstrap":   r.getStartHandler(StartActionBootstrap, r.monitClient.StartServiceBootstrap),
+		"start_mysql_join":        r.getStartHandler(StartActionJoin, r.monitClient.StartServiceJoin),
+		"start_mysql_single_node": r.getStartHandler(StartActionSingleNode, r.monitClient.StartServiceSingleNode),
+		"monit_reload":            r.getMutatingHandler(ErrorCodeMonitReloadFailed, r.monitClient.ReloadMonit),
+		"monit_services":          r.authenticated(r.monitServices()),
+		"monit_unmonitor":         r.getMutatingHandler(ErrorCodeMonitActionFailed, r.monitClient.UnmonitorService),
+		"monit_monitor":           r.getMutatingHandler(ErrorCodeMonitActionFailed, r.monitClient.MonitorService),
+		"state":                   r.authenticated(r.state()),
+		"config":                  r.authenticated(r.effectiveConfig()),
+		"sequence_number":         r.authenticated(r.sequenceNumber()),
+		"sequence_number_compare": r.authenticated(r.compareSequenceNumbers()),
+		"wsrep_recover":           r.mutating(r.wsrepRecover()),
+		"galera_status":           r.getInsecureHandler(ErrorCodeUnhealthy, r.unlessDraining(r.legacyHealthStatus(r.timedCheck(r.reqHealthChecker.CheckReq)))),
+		"wsrep_status":            r.authenticated(r.wsrepStatus()),
+		"metrics_wsrep":           r.authenticated(r.wsrepMetricsHandler()),
+		"wsrep_variable":          r.authenticated(r.wsrepVariable()),
+		"status_like":             r.authenticated(r.statusLike()),
+		"cluster_size":            r.getSecureHandler(ErrorCodeWsrepStatusFailed, r.clusterSize),
+		"health":                  r.authenticated(r.health()),
+		"role":                    r.getSecureHandler(ErrorCodeInvalidRole, r.setRole),
+		"drain":                   r.getSecureHandler(ErrorCodeInternal, r.setDraining(true)),
+		"undrain":                 r.getSecureHandler(ErrorCodeInternal, r.setDraining(false)),
+		"reset":                   r.getSecureHandler(ErrorCodeInternal, r.reset),
+		"root":                    r.getInsecureHandler(ErrorCodeUnhealthy, r.unlessDraining(r.legacyHealthStatus(r.timedCheck(r.reqHealthChecker.CheckReq)))),
+	}
 
-		"mysql_status":            r.getSecureHandler(r.monitClient.GetStatus),
-		"stop_mysql":              r.getSecureHandler(r.monitClient.StopService),
-		"start_mysql_bootstrap":   r.getSecureHandler(r.monitClient.StartServiceBootstrap),
-		"start_mysql_join":        r.getSecureHandler(r.monitClient.StartServiceJoin),
-		"start_mysql_single_node": r.getSecureHandler(r.monitClient.StartServiceSingleNode),
-		"sequence_number":         r.getSecureHandler(r.sequenceNumberChecker.Check),
-		"galera_status":           r.getInsecureHandler(r.reqHealthChecker.CheckReq),
-		"root":                    r.getInsecureHandler(r.reqHealthChecker.CheckReq),
+	// Load balancers may probe with HEAD; the server omits the body.
+	handlers["galera_status_head"] = handlers["galera_status"]
+	handlers["root_head"] = handlers["root"]
+
+	for _, route := range routes {
+		handlers[route.Name] = r.withTimeout(route, handlers[route.Name])
+	}
+
+	if len(r.rootConfig.ResponseHeaders) > 0 {
+		responseHeaders := middleware.NewResponseHeaders(r.rootConfig.ResponseHeaders)
+		for name := range healthRoutes {
+			handlers[name] = responseHeaders.Wrap(handlers[name])
+		}
+	}
+
+	if r.rootConfig.CORS.Enabled() {
+		routes = r.withCORS(routes, handlers)
+	}
+
+	for name, handler := range handlers {
+		handlers[name] = middleware.NewRequestID().Wrap(
+			middleware.NewAudit(r.logger.Session("audit"), name).Wrap(handler),
+		)
+	}
+
+	if r.metrics != nil {
+		for name, handler := range handlers {
+			handlers[name] = r.countRequests(name, handler)
+		}
+
+		routes = append(routes, rata.Route{Name: "metrics", Method: "GET", Path: "/metrics"})
+		handlers["metrics"] = r.metricsHandler()
+	}
+
+	if r.rootConfig.EnableProfiling {
+		routes = r.withProfiling(routes, handlers)
+	}
+
+	included := rata.Routes{}
+	for _, route := range routes {
+		if include(route.Name) {
+			included = append(included, route)
+		}
+	}
+	routes = included
+
+	if basePath := r.rootConfig.BasePath; basePath != "" {
+		for i, route := range routes {
+			if route.Path == "/" {
+				routes[i].Path = basePath
+			} else {
+				routes[i].Path = basePath + route.Path
+			}
+		}
 	}
 
 	handler, err := rata.NewRouter(routes, handlers)
 	if err != nil {
-		logger.Error("Error initializing router", err)
+		r.logger.Error("Error initializing router", err)
 		return nil, err
 	}
 
 	return handler, nil
 }
 
-func (r router) getSecureHandler(run RunFunc) http.Handler {
-	basicAuth := middleware.NewBasicAuth(
-		r.rootConfig.SidecarEndpoint.Username,
-		r.rootConfig.SidecarEndpoint.Password,
-	)
+// withCORS enables CORS on the GET endpoints and adds routes answering their
+// preflight OPTIONS requests. Mutating endpoints are never CORS-enabled.
+func (r router) withCORS(routes rata.Routes, handlers rata.Handlers) rata.Routes {
+	cors := middleware.NewCORS(r.rootConfig.CORS.AllowedOrigins, r.rootConfig.CORS.AllowedMethods)
+
+	preflighted := map[string]bool{}
+	for _, route := range routes {
+		if route.Method != "GET" && route.Method != "HEAD" {
+			continue
+		}
+
+		handlers[route.Name] = cors.Wrap(handlers[route.Name])
+
+		if !preflighted[route.Path] {
+			preflighted[route.Path] = true
+			name := route.Name + "_preflight"
+			routes = append(routes, rata.Route{Name: name, Method: "OPTIONS", Path: route.Path})
+			handlers[name] = cors.Wrap(http.NotFoundHandler())
+		}
+	}
+	return routes
+}
+
+// recoveryRoutes are the GET routes that may run mysqld --wsrep-recover,
+// which can take minutes on a large datadir.
+var recoveryRoutes = map[string]bool{
+	"sequence_number": true,
+	"health":          true,
+}
+
+// withTimeout bounds the time spent serving route. Read endpoints answer
+// quickly; POST endpoints may wait on galera-init, and recovery routes on
+// wsrep recovery, so they get the longer timeout.
+func (r router) withTimeout(route rata.Route, handler http.Handler) http.Handler {
+	// /ready enforces the deadline the client asks for.
+	if route.Name == "ready" {
+		return handler
+	}
+
+	timeout := r.rootConfig.EndpointTimeouts.Read
+	if route.Method == "POST" || recoveryRoutes[route.Name] {
+		timeout = r.rootConfig.EndpointTimeouts.Mutating
+	}
+
+	if timeout <= 0 {
+		return handler
+	}
+	return http.TimeoutHandler(handler, timeout, fmt.Sprintf("request timed out after %s", timeout))
+}
+
+func (r router) getSecureHandler(code ErrorCode, run RunFunc) http.Handler {
+	return r.authenticated(r.getInsecureHandler(code, run))
+}
+
+// getMutatingHandler is getSecureHandler for endpoints that stop or start the
+// database, which additionally require confirmation when RequireConfirmation
+// is set.
+func (r router) getMutatingHandler(code ErrorCode, run RunFunc) http.Handler {
+	return r.mutating(r.getInsecureHandler(code, run))
+}
+
+const (
+	StartActionBootstrap  = "bootstrap"
+	StartActionJoin       = "join"
+	StartActionSingleNode = "single_node"
+)
+
+// getStartHandler is getMutatingHandler for the endpoints that start mysqld.
+// Clients that want JSON get a StartResponse so that automation can branch
+// on its fields rather than on the message.
+func (r router) getStartHandler(action string, run RunFunc) http.Handler {
+	return r.mutating(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		message, err := run(req)
+		if err != nil {
+			r.writeError(w, req, ErrorCodeServiceStartFailed, err)
+			return
+		}
+
+		r.requestLogger(req).Debug(fmt.Sprintf("Response body: %s", message))
+
+		if !r.wantsJSON(req) {
+			w.Write([]byte(message))
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(StartResponse{
+			Action:  action,
+			Result:  "success",
+			Message: message,
+		})
+	}))
+}
+
+// mutating guards endpoints that change the state of mysqld. They accept only
+// small bodies from clients on the MutatingAllowlist, and are disabled
+// entirely when the sidecar is configured ReadOnly.
+func (r router) mutating(handler http.Handler) http.Handler {
+	if r.rootConfig.ReadOnly {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			w.Header().Set("Allow", "")
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			w.Write([]byte("sidecar is read-only"))
+		})
+	}
+
+	if r.rootConfig.RequireConfirmation {
+		handler = middleware.NewConfirmation().Wrap(handler)
+	}
+	handler = middleware.NewLimitBody(r.rootConfig.MaxRequestBodyBytes).Wrap(handler)
+	handler = r.authenticated(handler)
+
+	if allowlist := r.rootConfig.MutatingAllowlist; allowlist.Enabled() {
+		handler = middleware.NewAllowlist(allowlist.AllowedNetworks(), allowlist.TrustedProxyNetworks()).Wrap(handler)
+	}
+	return handler
+}
+
+func (r router) authenticated(handler http.Handler) http.Handler {
+	var credentials []middleware.Credential
+	for _, credential := range r.rootConfig.SidecarEndpoint.AllCredentials() {
+		credentials = append(credentials, middleware.Credential{
+			Username: credential.Username,
+			Password: credential.Password,
+		})
+	}
+
+	basicAuth := middleware.NewBasicAuth(r.rootConfig.SidecarEndpoint.Realm, credentials...)
 
-	handler := r.getInsecureHandler(run)
 	return basicAuth.Wrap(handler)
 }
 
-func (r router) getInsecureHandler(run RunFunc) http.Handler {
+// getInsecureHandler serves the result of run, reporting any error with the
+// given code.
+func (r router) getInsecureHandler(code ErrorCode, run RunFunc) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
 		body, err := run(req)
 		if err != nil {
-			w.WriteHeader(http.StatusInternalServerError)
-			r.logger.Error("Failed to process request", err)
-			w.Write([]byte(err.Error()))
+			r.writeError(w, req, code, err)
 			return
 		}
 
-		r.logger.Debug(fmt.Sprintf("Response body: %s", body))
+		r.requestLogger(req).Debug(fmt.Sprintf("Response body: %s", body))
 		w.Write([]byte(body))
 	})
 }
 
+// errorStatusCode returns the HTTP status for a failed request. Errors may
+// choose their status by implementing StatusCode() int.
+func errorStatusCode(err error) int {
+	var coded interface{ StatusCode() int }
+	if errors.As(err, &coded) {
+		return coded.StatusCode()
+	}
+	return http.StatusInternalServerError
+}
+
 func (r router) v1Status() http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
 		s, err := r.stateSnapshotter.State()
 		if err != nil {
-			w.WriteHeader(http.StatusInternalServerError)
-			r.logger.Error("Failed to process request", err)
-			w.Write([]byte(err.Error()))
+			r.writeError(w, req, ErrorCodeWsrepStatusFailed, err)
 			return
 		}
 
@@ -151,13 +512,380 @@ func (r router) v1Status() http.Handler {
 			WsrepLocalStateComment: string(s.WsrepLocalState.Comment()),
 			WsrepLocalIndex:        s.WsrepLocalIndex,
 			Healthy:                r.rootConfig.IsHealthy(s),
+			Role:                   r.role.Get(),
+		})
+	})
+}
+
+func (r router) countRequests(endpoint string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		r.metrics.IncRequests(endpoint)
+		next.ServeHTTP(w, req)
+	})
+}
+
+func (r router) timedCheck(run RunFunc) RunFunc {
+	return func(req *http.Request) (string, error) {
+		start := time.Now()
+		defer func() { r.metrics.ObserveCheckDuration(time.Since(start)) }()
+		return run(req)
+	}
+}
+
+// unlessDraining reports the node as unhealthy while it is draining, whatever
+// its wsrep state, so that load balancers stop routing to it.
+func (r router) unlessDraining(run RunFunc) RunFunc {
+	return func(req *http.Request) (string, error) {
+		if r.drain.IsSet() {
+			return "", DrainingError{}
+		}
+		return run(req)
+	}
+}
+
+// legacyHealthStatus reports unhealthy nodes with 200 and the node's state in
+// the body when LegacyHealthStatus is set, for clients that parse the body
+// rather than the status code.
+func (r router) legacyHealthStatus(run RunFunc) RunFunc {
+	if !r.rootConfig.LegacyHealthStatus {
+		return run
+	}
+
+	return func(req *http.Request) (string, error) {
+		body, err := run(req)
+
+		var unhealthy healthcheck.UnhealthyError
+		if errors.As(err, &unhealthy) {
+			return unhealthy.Error(), nil
+		}
+		return body, err
+	}
+}
+
+func (r router) setDraining(draining bool) RunFunc {
+	return func(req *http.Request) (string, error) {
+		r.drain.Set(draining)
+		r.requestLogger(req).Info("drain", lager.Data{"draining": draining})
+		if draining {
+			return "draining", nil
+		}
+		return "not draining", nil
+	}
+}
+
+// reset clears the sidecar's transient in-memory state: it undrains the node
+// and discards the cached health check result. It leaves mysqld and the state
+// file alone.
+func (r router) reset(req *http.Request) (string, error) {
+	r.drain.Set(false)
+	r.healthchecker.ResetCache()
+	r.requestLogger(req).Info("reset")
+	return "reset", nil
+}
+
+func (r router) metricsHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		r.metrics.WriteTo(w)
+	})
+}
+
+func (r router) wsrepMetricsHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		values, err := r.healthchecker.WsrepMetrics()
+		if err != nil {
+			r.writeError(w, req, ErrorCodeWsrepStatusFailed, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		metrics.WriteWsrepMetrics(w, values)
+	})
+}
+
+func (r router) wsrepStatus() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		status, err := r.healthchecker.WsrepStatus()
+		if err != nil {
+			r.writeError(w, req, ErrorCodeWsrepStatusFailed, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(status)
+	})
+}
+
+func (r router) wsrepVariable() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		name := req.URL.Query().Get("name")
+		value, err := r.healthchecker.WsrepVariable(name)
+		if err != nil {
+			r.writeError(w, req, ErrorCodeWsrepStatusFailed, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(WsrepVariableResponse{
+			Name:  name,
+			Value: value,
+		})
+	})
+}
+
+// statusLike serves the status variables matching the like query parameter,
+// for ad-hoc diagnostics such as ?like=wsrep_cert%.
+func (r router) statusLike() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		status, err := r.healthchecker.StatusLike(req.URL.Query().Get("like"))
+		if err != nil {
+			r.writeError(w, req, ErrorCodeWsrepStatusFailed, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(status)
+	})
+}
+
+func (r router) clusterSize(_ *http.Request) (string, error) {
+	size, err := r.healthchecker.ClusterSize()
+	if err != nil {
+		return "", err
+	}
+	return strconv.Itoa(size), nil
+}
+
+// dbPing reports whether the database accepts connections, separately from
+// the full wsrep health check.
+func (r router) dbPing() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if err := r.healthchecker.PingDB(); err != nil {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			r.requestLogger(req).Error("Database ping failed", err)
+			w.Write([]byte(err.Error()))
+			return
+		}
+
+		w.Write([]byte("ok"))
+	})
+}
+
+// live reports whether the sidecar and its database connection are up,
+// regardless of wsrep state. The root and galera_status endpoints remain the
+// readiness signal.
+func (r router) live() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		ctx := req.Context()
+		if r.rootConfig.LivenessTimeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, r.rootConfig.LivenessTimeout)
+			defer cancel()
+		}
+
+		if err := r.healthchecker.Ping(ctx); err != nil {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			r.requestLogger(req).Error("Liveness check failed", err)
+			w.Write([]byte(err.Error()))
+			return
+		}
+
+		w.Write([]byte("alive"))
+	})
+}
+
+func (r router) version() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(r.buildInfo)
+	})
+}
+
+func (r router) mysqlStatus() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		status, err := r.monitClient.GetStatus(req)
+		if err != nil {
+			r.writeError(w, req, ErrorCodeServiceStatusFailed, err)
+			return
+		}
+
+		if !r.wantsJSON(req) {
+			r.requestLogger(req).Debug(fmt.Sprintf("Response body: %s", status))
+			w.Write([]byte(status))
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+
+		service := req.URL.Query().Get("service")
+		if service == "" {
+			service = r.rootConfig.Monit.ServiceName
+		}
+
+		json.NewEncoder(w).Encode(MysqlStatusResponse{
+			Status:  status,
+			State:   monit_client.NormalizeStatus(status),
+			Service: service,
 		})
 	})
 }
 
+func (r router) sequenceNumber() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		seqno, err := r.sequenceNumberChecker.Check(req)
+		if err != nil {
+			r.writeError(w, req, ErrorCodeSequenceNumberFailed, err)
+			return
+		}
+
+		response := SequenceNumberResponse{
+			SequenceNumber: -1,
+			IsArbitrator:   r.rootConfig.IsArbitrator(),
+		}
+
+		if !r.wantsJSON(req) {
+			if response.IsArbitrator {
+				switch r.rootConfig.ArbitratorSeqnoFormat {
+				case config.ArbitratorSeqnoSentinel:
+					seqno = strconv.Itoa(response.SequenceNumber)
+				case config.ArbitratorSeqnoStructured:
+					w.Header().Set("Content-Type", "application/json")
+					json.NewEncoder(w).Encode(response)
+					return
+				}
+			}
+
+			r.requestLogger(req).Debug(fmt.Sprintf("Response body: %s", seqno))
+			w.Write([]byte(seqno))
+			return
+		}
+
+		if !response.IsArbitrator {
+			response.SequenceNumber, err = strconv.Atoi(seqno)
+			if err != nil {
+				r.writeError(w, req, ErrorCodeSequenceNumberFailed, err)
+				return
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(response)
+	})
+}
+
+func (r router) mysqlStats() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		stats, err := r.monitClient.GetProcessStats(req)
+		if err != nil {
+			r.writeError(w, req, ErrorCodeServiceStatusFailed, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(stats)
+	})
+}
+
+func (r router) monitServices() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		services, err := r.monitClient.GetServices(req)
+		if err != nil {
+			r.writeError(w, req, ErrorCodeServiceStatusFailed, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(services)
+	})
+}
+
+// effectiveConfig reports the configuration the sidecar is running with,
+// without credentials.
+func (r router) effectiveConfig() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(r.rootConfig.Redacted())
+	})
+}
+
+func (r router) state() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		state, err := r.monitClient.GetState(req)
+		if err != nil {
+			r.writeError(w, req, ErrorCodeStateFailed, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(StateResponse{State: state})
+	})
+}
+
+func (r router) wsrepRecover() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		position, err := r.sequenceNumberChecker.RecoverPosition(req)
+		if err != nil {
+			r.writeError(w, req, ErrorCodeRecoveryFailed, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(position)
+	})
+}
+
+// requestLogger returns the router's logger tagged with the correlation ID of
+// req.
+func (r router) requestLogger(req *http.Request) lager.Logger {
+	return requestid.Logger(r.logger, req.Context())
+}
+
+// wantsJSON honors an explicit Accept header from the client and otherwise
+// falls back to the configured ResponseFormat.
+func (r router) wantsJSON(req *http.Request) bool {
+	accept := req.Header.Get("Accept")
+	switch {
+	case strings.Contains(accept, "application/json"):
+		return true
+	case strings.Contains(accept, "text/plain"):
+		return false
+	default:
+		return r.rootConfig.ResponseFormat == config.ResponseFormatJSON
+	}
+}
+
+type WsrepVariableResponse struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type MysqlStatusResponse struct {
+	Status  string                    `json:"status"`
+	State   monit_client.ServiceState `json:"state"`
+	Service string                    `json:"service"`
+}
+
+// StateResponse reports the contents of the state file. State is null when
+// there is no state file.
+type StateResponse struct {
+	State *string `json:"state"`
+}
+
+type StartResponse struct {
+	Action  string `json:"action"`
+	Result  string `json:"result"`
+	Message string `json:"message"`
+}
+
+type SequenceNumberResponse struct {
+	SequenceNumber int  `json:"sequence_number"`
+	IsArbitrator   bool `json:"is_arbitrator"`
+}
+
 type V1StatusResponse struct {
 	WsrepLocalState        uint   `json:"wsrep_local_state"`
 	WsrepLocalStateComment string `json:"wsrep_local_state_comment"`
 	WsrepLocalIndex        uint   `json:"wsrep_local_index"`
 	Healthy                bool   `json:"healthy"`
+	Role                   string `json:"role"`
 }
@@ -1,20 +1,36 @@
 package api_test
 
 import (
+	"context"
 	"fmt"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"strings"
+	"time"
 
+	"database/sql"
+	"database/sql/driver"
 	"encoding/json"
 	"errors"
 
+	"code.cloudfoundry.org/lager"
 	"code.cloudfoundry.org/lager/lagertest"
 	"github.com/cloudfoundry-incubator/galera-healthcheck/api"
 	"github.com/cloudfoundry-incubator/galera-healthcheck/api/apifakes"
 	"github.com/cloudfoundry-incubator/galera-healthcheck/config"
 	"github.com/cloudfoundry-incubator/galera-healthcheck/domain"
+	"github.com/cloudfoundry-incubator/galera-healthcheck/healthcheck"
+	"github.com/cloudfoundry-incubator/galera-healthcheck/metrics"
+	"github.com/cloudfoundry-incubator/galera-healthcheck/monit_client"
+	"github.com/cloudfoundry-incubator/galera-healthcheck/mysqld_cmd"
+	"github.com/cloudfoundry-incubator/galera-healthcheck/node_manager"
+	"github.com/cloudfoundry-incubator/galera-healthcheck/requestid"
+	"github.com/cloudfoundry-incubator/galera-healthcheck/sequence_number"
+	"github.com/cloudfoundry-incubator/galera-healthcheck/sequence_number/sequence_numberfakes"
+	testdb "github.com/erikstmartin/go-testdb"
 	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
 	. "github.com/onsi/gomega"
 )
 
@@ -26,6 +42,27 @@ const (
 	ApiPassword               = "fake-password"
 )
 
+// slowRunner stands in for a mysqld --wsrep-recover that takes delay to
+// report the recovered position in the file named by --log-error.
+type slowRunner struct {
+	delay    time.Duration
+	errorLog string
+}
+
+func (s *slowRunner) Run(name string, args ...string) ([]byte, error) {
+	time.Sleep(s.delay)
+
+	for _, arg := range args {
+		if strings.HasPrefix(arg, "--log-error=") {
+			path := strings.TrimPrefix(arg, "--log-error=")
+			if err := ioutil.WriteFile(path, []byte(s.errorLog), 0644); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return nil, nil
+}
+
 var _ = Describe("Sidecar API", func() {
 	var (
 		monitClient      *apifakes.FakeMonitClient
@@ -33,6 +70,10 @@ var _ = Describe("Sidecar API", func() {
 		reqhealthchecker *apifakes.FakeReqHealthChecker
 		healthchecker    *apifakes.FakeHealthChecker
 		stateSnapshotter *apifakes.FakeStateSnapshotter
+		testConfig       *config.Config
+		buildInfo        api.BuildInfo
+		metricsRegistry  *metrics.Registry
+		testLogger       *lagertest.TestLogger
 		ts               *httptest.Server
 
 		ExpectedStateSnapshot domain.DBState
@@ -54,13 +95,25 @@ var _ = Describe("Sidecar API", func() {
 
 		healthchecker = &apifakes.FakeHealthChecker{}
 		healthchecker.CheckReturns(ExpectedHealthCheckStatus, nil)
+		healthchecker.CheckStatusReturns(healthcheck.CheckStatus{
+			State:      ExpectedHealthCheckStatus,
+			Ready:      true,
+			LocalState: healthcheck.STATE_SYNCED,
+		}, nil)
 
 		stateSnapshotter = new(apifakes.FakeStateSnapshotter)
 		stateSnapshotter.StateReturns(ExpectedStateSnapshot, nil)
 
-		testLogger := lagertest.NewTestLogger("mysql_cmd")
+		buildInfo = api.BuildInfo{
+			SHA:     "fake-sha",
+			Version: "1.2.3",
+			BuiltAt: "2020-01-01T00:00:00Z",
+		}
+
+		metricsRegistry = nil
+		testLogger = lagertest.NewTestLogger("mysql_cmd")
 
-		testConfig := &config.Config{
+		testConfig = &config.Config{
 			SidecarEndpoint: config.SidecarEndpointConfig{
 				Username: ApiUsername,
 				Password: ApiPassword,
@@ -71,7 +124,9 @@ var _ = Describe("Sidecar API", func() {
 		monitClient.StartServiceBootstrapReturns("Successfully sent bootstrap request", nil)
 		monitClient.StartServiceJoinReturns("Successfully sent join request", nil)
 		monitClient.GetStatusReturns("running", nil)
+	})
 
+	JustBeforeEach(func() {
 		handler, err := api.NewRouter(
 			testLogger,
 			testConfig,
@@ -80,6 +135,8 @@ var _ = Describe("Sidecar API", func() {
 			reqhealthchecker,
 			healthchecker,
 			stateSnapshotter,
+			buildInfo,
+			metricsRegistry,
 		)
 		Expect(err).ToNot(HaveOccurred())
 		ts = httptest.NewServer(handler)
@@ -149,6 +206,88 @@ var _ = Describe("Sidecar API", func() {
 			Expect(monitClient.GetStatusCallCount()).To(Equal(1))
 		})
 
+		Describe("/mysql_status response format", func() {
+			BeforeEach(func() {
+				testConfig.Monit.ServiceName = "mysql"
+			})
+
+			var getStatus = func(accept string) (*http.Response, string) {
+				req := createReq("mysql_status", "GET")
+				if accept != "" {
+					req.Header.Set("Accept", accept)
+				}
+				resp, err := http.DefaultClient.Do(req)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(resp.StatusCode).To(Equal(http.StatusOK))
+
+				body, err := ioutil.ReadAll(resp.Body)
+				Expect(err).ToNot(HaveOccurred())
+				return resp, string(body)
+			}
+
+			It("returns the raw monit status by default", func() {
+				_, body := getStatus("")
+				Expect(body).To(Equal("running"))
+			})
+
+			It("returns JSON when the client accepts application/json", func() {
+				resp, body := getStatus("application/json")
+				Expect(resp.Header.Get("Content-Type")).To(Equal("application/json"))
+				Expect(body).To(MatchJSON(`{"status":"running","state":"running","service":"mysql"}`))
+			})
+
+			It("reports the normalized state of a stopped service", func() {
+				monitClient.GetStatusReturns("stopped", nil)
+
+				_, body := getStatus("application/json")
+				Expect(body).To(MatchJSON(`{"status":"stopped","state":"stopped","service":"mysql"}`))
+			})
+
+			It("reports the normalized state of an unmonitored service", func() {
+				monitClient.GetStatusReturns("not monitored", nil)
+
+				_, body := getStatus("application/json")
+				Expect(body).To(MatchJSON(`{"status":"not monitored","state":"not_monitored","service":"mysql"}`))
+			})
+
+			It("reports the service named in the request", func() {
+				req := createReq("mysql_status?service=garbd", "GET")
+				req.Header.Set("Accept", "application/json")
+				resp, err := http.DefaultClient.Do(req)
+				Expect(err).ToNot(HaveOccurred())
+
+				body, err := ioutil.ReadAll(resp.Body)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(string(body)).To(MatchJSON(`{"status":"running","state":"running","service":"garbd"}`))
+				Expect(monitClient.GetStatusArgsForCall(0).URL.Query().Get("service")).To(Equal("garbd"))
+			})
+
+			It("returns 400 for a service that is not managed", func() {
+				monitClient.GetStatusReturns("", node_manager.UnknownServiceError{Service: "sshd"})
+
+				resp, err := http.DefaultClient.Do(createReq("mysql_status?service=sshd", "GET"))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(resp.StatusCode).To(Equal(http.StatusBadRequest))
+			})
+
+			Context("when the ResponseFormat is json", func() {
+				BeforeEach(func() {
+					testConfig.ResponseFormat = config.ResponseFormatJSON
+				})
+
+				It("returns JSON", func() {
+					resp, body := getStatus("")
+					Expect(resp.Header.Get("Content-Type")).To(Equal("application/json"))
+					Expect(body).To(MatchJSON(`{"status":"running","state":"running","service":"mysql"}`))
+				})
+
+				It("returns the raw monit status when the client accepts text/plain", func() {
+					_, body := getStatus("text/plain")
+					Expect(body).To(Equal("running"))
+				})
+			})
+		})
+
 		It("Calls Checker on the SequenceNumberchecker when a new sequence_number is created", func() {
 			req := createReq("sequence_number", "GET")
 			resp, err := http.DefaultClient.Do(req)
@@ -161,152 +300,2304 @@ var _ = Describe("Sidecar API", func() {
 			Expect(sequenceNumber.CheckCallCount()).To(Equal(1))
 		})
 
-		It("returns 404 when a request is made to an unsupplied endpoint", func() {
-			req := createReq("nonexistent_endpoint", "GET")
-			resp, err := http.DefaultClient.Do(req)
-			Expect(err).ToNot(HaveOccurred())
-			Expect(resp.StatusCode).To(Equal(http.StatusNotFound))
-		})
-	})
+		Describe("/wsrep", func() {
+			var getVariable = func(name string) (*http.Response, string) {
+				resp, err := http.DefaultClient.Do(createReq("wsrep?name="+name, "GET"))
+				Expect(err).ToNot(HaveOccurred())
 
-	Context("when request does not have basic auth", func() {
-		var createReq = func(endpoint string, method string) *http.Request {
-			url := fmt.Sprintf("%s/%s", ts.URL, endpoint)
-			req, err := http.NewRequest(method, url, nil)
-			Expect(err).ToNot(HaveOccurred())
-			return req
-		}
+				body, err := ioutil.ReadAll(resp.Body)
+				Expect(err).ToNot(HaveOccurred())
+				return resp, string(body)
+			}
 
-		It("requires authentication for /stop_mysql", func() {
-			req := createReq("stop_mysql", "POST")
-			resp, err := http.DefaultClient.Do(req)
-			Expect(err).ToNot(HaveOccurred())
+			It("returns the named variable as JSON", func() {
+				healthchecker.WsrepVariableReturns("Primary", nil)
 
-			Expect(resp.StatusCode).To(Equal(http.StatusUnauthorized))
-			Expect(monitClient.StopServiceCallCount()).To(Equal(0))
-		})
+				resp, body := getVariable("wsrep_cluster_status")
+				Expect(resp.StatusCode).To(Equal(http.StatusOK))
+				Expect(resp.Header.Get("Content-Type")).To(Equal("application/json"))
+				Expect(body).To(MatchJSON(`{"name":"wsrep_cluster_status","value":"Primary"}`))
+				Expect(healthchecker.WsrepVariableArgsForCall(0)).To(Equal("wsrep_cluster_status"))
+			})
 
-		It("requires authentication for /start_mysql_bootstrap", func() {
-			req := createReq("start_mysql_bootstrap", "POST")
-			resp, err := http.DefaultClient.Do(req)
-			Expect(err).ToNot(HaveOccurred())
+			It("returns 400 for a name that is not allowed", func() {
+				healthchecker.WsrepVariableReturns("", healthcheck.InvalidVariableError{Name: "version"})
 
-			Expect(resp.StatusCode).To(Equal(http.StatusUnauthorized))
-			Expect(monitClient.StartServiceBootstrapCallCount()).To(Equal(0))
-		})
+				resp, body := getVariable("version")
+				Expect(resp.StatusCode).To(Equal(http.StatusBadRequest))
+				Expect(body).To(ContainSubstring(`"version" is not a queryable wsrep variable`))
+			})
 
-		It("requires authentication for /start_mysql_join", func() {
-			req := createReq("start_mysql_join", "POST")
-			resp, err := http.DefaultClient.Do(req)
-			Expect(err).ToNot(HaveOccurred())
+			It("returns 404 for a variable mysqld does not report", func() {
+				healthchecker.WsrepVariableReturns("", healthcheck.VariableNotFoundError{Name: "wsrep_gcomm_uuid"})
 
-			Expect(resp.StatusCode).To(Equal(http.StatusUnauthorized))
-			Expect(monitClient.StartServiceJoinCallCount()).To(Equal(0))
+				resp, _ := getVariable("wsrep_gcomm_uuid")
+				Expect(resp.StatusCode).To(Equal(http.StatusNotFound))
+			})
 		})
 
-		It("requires authentication for /mysql_status", func() {
-			req := createReq("mysql_status", "GET")
-			resp, err := http.DefaultClient.Do(req)
-			Expect(err).ToNot(HaveOccurred())
+		Describe("/status", func() {
+			It("returns the matching status variables as JSON", func() {
+				healthchecker.StatusLikeReturns(map[string]string{"wsrep_cert_index_size": "42"}, nil)
 
-			Expect(resp.StatusCode).To(Equal(http.StatusUnauthorized))
-			Expect(monitClient.GetStatusCallCount()).To(Equal(0))
-		})
+				resp, err := http.DefaultClient.Do(createReq("status?like=wsrep_cert%25", "GET"))
+				Expect(err).ToNot(HaveOccurred())
+				body, err := ioutil.ReadAll(resp.Body)
+				Expect(err).ToNot(HaveOccurred())
 
-		It("requires authentication for /sequence_number", func() {
-			req := createReq("sequence_number", "GET")
-			resp, err := http.DefaultClient.Do(req)
-			Expect(err).ToNot(HaveOccurred())
+				Expect(resp.StatusCode).To(Equal(http.StatusOK))
+				Expect(resp.Header.Get("Content-Type")).To(Equal("application/json"))
+				Expect(body).To(MatchJSON(`{"wsrep_cert_index_size":"42"}`))
+				Expect(healthchecker.StatusLikeArgsForCall(0)).To(Equal("wsrep_cert%"))
+			})
 
-			Expect(resp.StatusCode).To(Equal(http.StatusUnauthorized))
-			responseBody, err := ioutil.ReadAll(resp.Body)
-			Expect(err).ToNot(HaveOccurred())
-			Expect(responseBody).ToNot(ContainSubstring(ExpectedSeqno))
-			Expect(sequenceNumber.CheckCallCount()).To(Equal(0))
+			It("returns an empty object when nothing matches", func() {
+				healthchecker.StatusLikeReturns(map[string]string{}, nil)
+
+				resp, err := http.DefaultClient.Do(createReq("status?like=wsrep_nonexistent%25", "GET"))
+				Expect(err).ToNot(HaveOccurred())
+				body, err := ioutil.ReadAll(resp.Body)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(resp.StatusCode).To(Equal(http.StatusOK))
+				Expect(body).To(MatchJSON(`{}`))
+			})
+
+			It("returns 400 for an unsafe pattern", func() {
+				healthchecker.StatusLikeReturns(nil, healthcheck.InvalidPatternError{Pattern: "x' OR '1"})
+
+				resp, err := http.DefaultClient.Do(createReq("status?like=x%27%20OR%20%271", "GET"))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(resp.StatusCode).To(Equal(http.StatusBadRequest))
+			})
 		})
 
-		It("Calls Check on the reqHealthchecker at the root endpoint", func() {
-			req := createReq("", "GET")
-			resp, err := http.DefaultClient.Do(req)
-			Expect(err).ToNot(HaveOccurred())
+		Describe("/wsrep_status", func() {
+			It("returns the wsrep status snapshot as JSON", func() {
+				healthchecker.WsrepStatusReturns(map[string]string{
+					"cluster_status":      "Primary",
+					"connected":           "ON",
+					"ready":               "ON",
+					"local_state_comment": "Synced",
+					"cluster_size":        "3",
+				}, nil)
+
+				req := createReq("wsrep_status", "GET")
+				resp, err := http.DefaultClient.Do(req)
+				Expect(err).ToNot(HaveOccurred())
 
-			Expect(resp.StatusCode).To(Equal(http.StatusOK))
-			responseBody, err := ioutil.ReadAll(resp.Body)
-			Expect(err).ToNot(HaveOccurred())
-			Expect(responseBody).To(ContainSubstring(ExpectedHealthCheckStatus))
-			Expect(reqhealthchecker.CheckReqCallCount()).To(Equal(1))
+				Expect(resp.StatusCode).To(Equal(http.StatusOK))
+				Expect(resp.Header.Get("Content-Type")).To(Equal("application/json"))
+				responseBody, err := ioutil.ReadAll(resp.Body)
+				Expect(err).ToNot(HaveOccurred())
+
+				var status map[string]string
+				Expect(json.Unmarshal(responseBody, &status)).To(Succeed())
+				Expect(status).To(HaveKey("cluster_status"))
+				Expect(status).To(HaveKey("connected"))
+				Expect(status).To(HaveKey("ready"))
+				Expect(status).To(HaveKey("local_state_comment"))
+				Expect(status).To(HaveKeyWithValue("cluster_size", "3"))
+				Expect(healthchecker.WsrepStatusCallCount()).To(Equal(1))
+			})
+
+			It("returns 500 when the status cannot be queried", func() {
+				healthchecker.WsrepStatusReturns(nil, errors.New("db is down"))
+
+				req := createReq("wsrep_status", "GET")
+				resp, err := http.DefaultClient.Do(req)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(resp.StatusCode).To(Equal(http.StatusInternalServerError))
+				responseBody, err := ioutil.ReadAll(resp.Body)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(responseBody).To(ContainSubstring("db is down"))
+			})
 		})
 
-		It("Calls Check on the reqHealthchecker at /galera_status", func() {
-			req := createReq("galera_status", "GET")
-			resp, err := http.DefaultClient.Do(req)
-			Expect(err).ToNot(HaveOccurred())
+		Describe("start endpoint response format", func() {
+			BeforeEach(func() {
+				monitClient.StartServiceBootstrapReturns("cluster bootstrap successful", nil)
+				monitClient.StartServiceJoinReturns("join cluster successful", nil)
+				monitClient.StartServiceSingleNodeReturns("single node start successful", nil)
+			})
 
-			Expect(resp.StatusCode).To(Equal(http.StatusOK))
-			responseBody, err := ioutil.ReadAll(resp.Body)
-			Expect(err).ToNot(HaveOccurred())
-			Expect(responseBody).To(ContainSubstring(ExpectedHealthCheckStatus))
-			Expect(reqhealthchecker.CheckReqCallCount()).To(Equal(1))
+			var start = func(endpoint, accept string) (*http.Response, string) {
+				req := createReq(endpoint, "POST")
+				if accept != "" {
+					req.Header.Set("Accept", accept)
+				}
+				resp, err := http.DefaultClient.Do(req)
+				Expect(err).ToNot(HaveOccurred())
+
+				body, err := ioutil.ReadAll(resp.Body)
+				Expect(err).ToNot(HaveOccurred())
+				return resp, string(body)
+			}
+
+			It("returns the plain message by default", func() {
+				resp, body := start("start_mysql_bootstrap", "")
+				Expect(resp.StatusCode).To(Equal(http.StatusOK))
+				Expect(body).To(Equal("cluster bootstrap successful"))
+			})
+
+			DescribeTable("returns JSON when the client accepts application/json",
+				func(endpoint, expected string) {
+					resp, body := start(endpoint, "application/json")
+					Expect(resp.StatusCode).To(Equal(http.StatusOK))
+					Expect(resp.Header.Get("Content-Type")).To(Equal("application/json"))
+					Expect(body).To(MatchJSON(expected))
+				},
+				Entry("bootstrap", "start_mysql_bootstrap",
+					`{"action":"bootstrap","result":"success","message":"cluster bootstrap successful"}`),
+				Entry("join", "start_mysql_join",
+					`{"action":"join","result":"success","message":"join cluster successful"}`),
+				Entry("single_node", "start_mysql_single_node",
+					`{"action":"single_node","result":"success","message":"single node start successful"}`),
+			)
+
+			It("returns the error envelope when the start fails", func() {
+				monitClient.StartServiceJoinReturns("", errors.New("galera-init failed"))
+
+				resp, body := start("start_mysql_join", "application/json")
+				Expect(resp.StatusCode).To(Equal(http.StatusInternalServerError))
+				Expect(body).To(MatchJSON(`{"error":"galera-init failed","code":"SERVICE_START_FAILED"}`))
+			})
 		})
 
-		Describe("/api/v1/status", func() {
-			It("Calls State on the stateSnapshotter", func() {
-				req := createReq("api/v1/status", "GET")
+		Describe("/sequence_number response format", func() {
+			var getSequenceNumber = func(accept string) (*http.Response, string) {
+				req := createReq("sequence_number", "GET")
+				if accept != "" {
+					req.Header.Set("Accept", accept)
+				}
 				resp, err := http.DefaultClient.Do(req)
 				Expect(err).ToNot(HaveOccurred())
 
+				body, err := ioutil.ReadAll(resp.Body)
+				Expect(err).ToNot(HaveOccurred())
+				return resp, string(body)
+			}
+
+			It("returns the plain sequence number by default", func() {
+				resp, body := getSequenceNumber("")
 				Expect(resp.StatusCode).To(Equal(http.StatusOK))
-				Expect(stateSnapshotter.StateCallCount()).To(Equal(1))
+				Expect(body).To(Equal(ExpectedSeqno))
 			})
 
-			Context("when getting the state succeeds", func() {
-				var (
-					returnedState domain.DBState
-				)
+			It("returns JSON when the client accepts application/json", func() {
+				resp, body := getSequenceNumber("application/json")
+				Expect(resp.StatusCode).To(Equal(http.StatusOK))
+				Expect(resp.Header.Get("Content-Type")).To(Equal("application/json"))
+				Expect(body).To(MatchJSON(`{"sequence_number":4,"is_arbitrator":false}`))
+			})
 
+			Context("when running on an arbitrator node", func() {
 				BeforeEach(func() {
-					returnedState = domain.DBState{
-						WsrepLocalIndex: 1,
-						WsrepLocalState: domain.Synced,
-					}
-
-					stateSnapshotter.StateReturns(returnedState, nil)
+					testConfig.Monit.ServiceName = "garbd"
+					sequenceNumber.CheckReturns(ArbitratorSeqnoResponse, nil)
 				})
 
-				It("has the required fields", func() {
-					req := createReq("api/v1/status", "GET")
-					resp, err := http.DefaultClient.Do(req)
-					Expect(err).ToNot(HaveOccurred())
+				It("returns the arbitrator message by default", func() {
+					_, body := getSequenceNumber("")
+					Expect(body).To(Equal(ArbitratorSeqnoResponse))
+				})
 
+				It("returns JSON flagging the arbitrator", func() {
+					resp, body := getSequenceNumber("application/json")
 					Expect(resp.StatusCode).To(Equal(http.StatusOK))
+					Expect(body).To(MatchJSON(`{"sequence_number":-1,"is_arbitrator":true}`))
+				})
 
-					var state struct {
-						WsrepLocalIndex        uint   `json:"wsrep_local_index"`
-						WsrepLocalState        uint   `json:"wsrep_local_state"`
-						WsrepLocalStateComment string `json:"wsrep_local_state_comment"`
-					}
+				Context("when the arbitrator answers with a sentinel", func() {
+					BeforeEach(func() {
+						testConfig.ArbitratorSeqnoFormat = config.ArbitratorSeqnoSentinel
+					})
 
-					json.NewDecoder(resp.Body).Decode(&state)
+					It("returns -1 by default", func() {
+						_, body := getSequenceNumber("")
+						Expect(body).To(Equal("-1"))
+					})
 
-					Expect(state.WsrepLocalIndex).To(Equal(returnedState.WsrepLocalIndex))
-					Expect(state.WsrepLocalState).To(Equal(uint(returnedState.WsrepLocalState)))
-					Expect(state.WsrepLocalStateComment).To(Equal(string(returnedState.WsrepLocalState.Comment())))
+					It("still returns JSON when the client accepts application/json", func() {
+						_, body := getSequenceNumber("application/json")
+						Expect(body).To(MatchJSON(`{"sequence_number":-1,"is_arbitrator":true}`))
+					})
+				})
+
+				Context("when the arbitrator answers with a structured flag", func() {
+					BeforeEach(func() {
+						testConfig.ArbitratorSeqnoFormat = config.ArbitratorSeqnoStructured
+					})
+
+					It("returns JSON flagging the arbitrator by default", func() {
+						resp, body := getSequenceNumber("")
+						Expect(resp.Header.Get("Content-Type")).To(Equal("application/json"))
+						Expect(body).To(MatchJSON(`{"sequence_number":-1,"is_arbitrator":true}`))
+					})
 				})
 			})
 
-			Context("when getting the state fails", func() {
+			It("returns 500 when the sequence number cannot be determined", func() {
+				sequenceNumber.CheckReturns("", errors.New("database is running"))
+
+				resp, body := getSequenceNumber("application/json")
+				Expect(resp.StatusCode).To(Equal(http.StatusInternalServerError))
+				Expect(body).To(ContainSubstring("database is running"))
+			})
+		})
+
+		Describe("audit logging", func() {
+			It("logs the endpoint, username and result of mutating requests", func() {
+				req := createReq("stop_mysql", "POST")
+				resp, err := http.DefaultClient.Do(req)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(resp.StatusCode).To(Equal(http.StatusOK))
+
+				requestID := resp.Header.Get("X-Request-Id")
+				Expect(requestID).NotTo(BeEmpty())
+
+				logs := testLogger.LogMessages()
+				Expect(logs).To(ContainElement("mysql_cmd.audit.audit"))
+
+				var auditLog lager.LogFormat
+				for _, log := range testLogger.Logs() {
+					if log.Message == "mysql_cmd.audit.audit" {
+						auditLog = log
+					}
+				}
+				Expect(auditLog.LogLevel).To(Equal(lager.INFO))
+				Expect(auditLog.Data).To(HaveKeyWithValue("endpoint", "stop_mysql"))
+				Expect(auditLog.Data).To(HaveKeyWithValue("username", ApiUsername))
+				Expect(auditLog.Data).To(HaveKeyWithValue("source_ip", "127.0.0.1"))
+				Expect(auditLog.Data).To(HaveKeyWithValue("status", BeNumerically("==", http.StatusOK)))
+				Expect(auditLog.Data).To(HaveKeyWithValue("request_id", requestID))
+			})
+
+			It("echoes a request ID supplied by the client", func() {
+				req := createReq("stop_mysql", "POST")
+				req.Header.Set("X-Request-Id", "fake-request-id")
+				resp, err := http.DefaultClient.Do(req)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(resp.Header.Get("X-Request-Id")).To(Equal("fake-request-id"))
+			})
+
+			It("passes the request ID to the node manager in the request context", func() {
+				req := createReq("stop_mysql", "POST")
+				req.Header.Set("X-Request-Id", "fake-request-id")
+				_, err := http.DefaultClient.Do(req)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(monitClient.StopServiceCallCount()).To(Equal(1))
+				id, ok := requestid.FromContext(monitClient.StopServiceArgsForCall(0).Context())
+				Expect(ok).To(BeTrue())
+				Expect(id).To(Equal("fake-request-id"))
+			})
+
+			It("tags request-scoped logs with the request ID", func() {
+				monitClient.StopServiceReturns("", errors.New("stop failed"))
+
+				req := createReq("stop_mysql", "POST")
+				req.Header.Set("X-Request-Id", "fake-request-id")
+				_, err := http.DefaultClient.Do(req)
+				Expect(err).ToNot(HaveOccurred())
+
+				var errorLog lager.LogFormat
+				for _, log := range testLogger.Logs() {
+					if log.Message == "mysql_cmd.Failed to process request" {
+						errorLog = log
+					}
+				}
+				Expect(errorLog.Data).To(HaveKeyWithValue("request_id", "fake-request-id"))
+			})
+
+			It("logs read-only requests at debug level", func() {
+				req := createReq("mysql_status", "GET")
+				_, err := http.DefaultClient.Do(req)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(testLogger.LogMessages()).To(ContainElement("mysql_cmd.audit.audit"))
+				for _, log := range testLogger.Logs() {
+					if log.Message == "mysql_cmd.audit.audit" {
+						Expect(log.LogLevel).To(Equal(lager.DEBUG))
+						Expect(log.Data).To(HaveKeyWithValue("endpoint", "mysql_status"))
+					}
+				}
+			})
+		})
+
+		Describe("/cluster_size", func() {
+			It("returns the cluster size", func() {
+				healthchecker.ClusterSizeReturns(3, nil)
+
+				resp, err := http.DefaultClient.Do(createReq("cluster_size", "GET"))
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(resp.StatusCode).To(Equal(http.StatusOK))
+				responseBody, err := ioutil.ReadAll(resp.Body)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(string(responseBody)).To(Equal("3"))
+			})
+
+			It("returns the unhealthy status when the node is not in the primary component", func() {
+				healthchecker.ClusterSizeReturns(0, healthcheck.UnhealthyError{Err: errors.New("node is not part of the primary component")})
+
+				resp, err := http.DefaultClient.Do(createReq("cluster_size", "GET"))
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(resp.StatusCode).To(Equal(http.StatusServiceUnavailable))
+				responseBody, err := ioutil.ReadAll(resp.Body)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(responseBody).To(ContainSubstring("primary component"))
+			})
+		})
+
+		Describe("restrictions on mutating requests", func() {
+			DescribeTable("rejects methods other than POST without calling monit",
+				func(endpoint string) {
+					for _, method := range []string{"GET", "PUT", "DELETE"} {
+						resp, err := http.DefaultClient.Do(createReq(endpoint, method))
+						Expect(err).ToNot(HaveOccurred())
+						Expect(resp.StatusCode).To(Equal(http.StatusMethodNotAllowed))
+					}
+
+					Expect(monitClient.StopServiceCallCount()).To(Equal(0))
+					Expect(monitClient.StartServiceBootstrapCallCount()).To(Equal(0))
+					Expect(monitClient.StartServiceJoinCallCount()).To(Equal(0))
+					Expect(monitClient.StartServiceSingleNodeCallCount()).To(Equal(0))
+				},
+				Entry("stop", "stop_mysql"),
+				Entry("graceful stop", "stop_mysql_graceful"),
+				Entry("bootstrap", "start_mysql_bootstrap"),
+				Entry("join", "start_mysql_join"),
+				Entry("single node", "start_mysql_single_node"),
+			)
+
+			Context("when MaxRequestBodyBytes is set", func() {
 				BeforeEach(func() {
-					stateSnapshotter.StateReturns(domain.DBState{}, errors.New("possibly not a galera cluster"))
+					testConfig.MaxRequestBodyBytes = 16
 				})
 
-				It("500s", func() {
-					req := createReq("api/v1/status", "GET")
+				It("accepts small bodies", func() {
+					req := createReq("stop_mysql", "POST")
+					req.Body = ioutil.NopCloser(strings.NewReader("confirm=true"))
+					req.ContentLength = int64(len("confirm=true"))
 					resp, err := http.DefaultClient.Do(req)
 					Expect(err).ToNot(HaveOccurred())
 
-					Expect(resp.StatusCode).To(Equal(http.StatusInternalServerError))
+					Expect(resp.StatusCode).To(Equal(http.StatusOK))
+					Expect(monitClient.StopServiceCallCount()).To(Equal(1))
 				})
-			})
+
+				It("rejects larger bodies without calling monit", func() {
+					body := strings.Repeat("x", 17)
+					req := createReq("stop_mysql", "POST")
+					req.Body = ioutil.NopCloser(strings.NewReader(body))
+					req.ContentLength = int64(len(body))
+					resp, err := http.DefaultClient.Do(req)
+					Expect(err).ToNot(HaveOccurred())
+
+					Expect(resp.StatusCode).To(Equal(http.StatusRequestEntityTooLarge))
+					Expect(monitClient.StopServiceCallCount()).To(Equal(0))
+				})
+
+				It("rejects larger chunked bodies that carry no Content-Length", func() {
+					body := strings.Repeat("x", 1024)
+					req := createReq("stop_mysql", "POST")
+					req.Body = ioutil.NopCloser(strings.NewReader(body))
+					req.ContentLength = -1
+					resp, err := http.DefaultClient.Do(req)
+					Expect(err).ToNot(HaveOccurred())
+
+					Expect(resp.StatusCode).To(Equal(http.StatusRequestEntityTooLarge))
+					Expect(monitClient.StopServiceCallCount()).To(Equal(0))
+				})
+
+				It("accepts small chunked bodies", func() {
+					req := createReq("stop_mysql", "POST")
+					req.Body = ioutil.NopCloser(strings.NewReader("confirm=true"))
+					req.ContentLength = -1
+					resp, err := http.DefaultClient.Do(req)
+					Expect(err).ToNot(HaveOccurred())
+
+					Expect(resp.StatusCode).To(Equal(http.StatusOK))
+					Expect(monitClient.StopServiceCallCount()).To(Equal(1))
+				})
+			})
+		})
+
+		Describe("confirmation of mutating requests", func() {
+			It("does not require confirmation by default", func() {
+				resp, err := http.DefaultClient.Do(createReq("stop_mysql", "POST"))
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(resp.StatusCode).To(Equal(http.StatusOK))
+				Expect(monitClient.StopServiceCallCount()).To(Equal(1))
+			})
+
+			Context("when RequireConfirmation is set", func() {
+				BeforeEach(func() {
+					testConfig.RequireConfirmation = true
+				})
+
+				It("rejects unconfirmed requests without calling monit", func() {
+					resp, err := http.DefaultClient.Do(createReq("stop_mysql", "POST"))
+					Expect(err).ToNot(HaveOccurred())
+
+					Expect(resp.StatusCode).To(Equal(http.StatusPreconditionFailed))
+					Expect(monitClient.StopServiceCallCount()).To(Equal(0))
+				})
+
+				It("accepts requests confirmed with the X-Confirm header", func() {
+					req := createReq("start_mysql_join", "POST")
+					req.Header.Set("X-Confirm", "true")
+					resp, err := http.DefaultClient.Do(req)
+					Expect(err).ToNot(HaveOccurred())
+
+					Expect(resp.StatusCode).To(Equal(http.StatusOK))
+					Expect(monitClient.StartServiceJoinCallCount()).To(Equal(1))
+				})
+
+				It("accepts requests confirmed with the confirm query parameter", func() {
+					resp, err := http.DefaultClient.Do(createReq("start_mysql_bootstrap?confirm=true", "POST"))
+					Expect(err).ToNot(HaveOccurred())
+
+					Expect(resp.StatusCode).To(Equal(http.StatusOK))
+					Expect(monitClient.StartServiceBootstrapCallCount()).To(Equal(1))
+				})
+
+				It("does not require confirmation for read-only requests", func() {
+					resp, err := http.DefaultClient.Do(createReq("mysql_status", "GET"))
+					Expect(err).ToNot(HaveOccurred())
+
+					Expect(resp.StatusCode).To(Equal(http.StatusOK))
+				})
+			})
+		})
+
+		Describe("mutating allowlist", func() {
+			var stop = func(forwardedFor string) *http.Response {
+				req := createReq("stop_mysql", "POST")
+				if forwardedFor != "" {
+					req.Header.Set("X-Forwarded-For", forwardedFor)
+				}
+				resp, err := http.DefaultClient.Do(req)
+				Expect(err).ToNot(HaveOccurred())
+				return resp
+			}
+
+			Context("when the client's address is allowed", func() {
+				BeforeEach(func() {
+					testConfig.MutatingAllowlist.AllowedCIDRs = []string{"10.0.0.0/8", "127.0.0.0/8"}
+				})
+
+				It("serves mutating requests", func() {
+					Expect(stop("").StatusCode).To(Equal(http.StatusOK))
+					Expect(monitClient.StopServiceCallCount()).To(Equal(1))
+				})
+			})
+
+			Context("when the client's address is not allowed", func() {
+				BeforeEach(func() {
+					testConfig.MutatingAllowlist.AllowedCIDRs = []string{"10.0.0.0/8"}
+				})
+
+				It("rejects mutating requests without calling monit", func() {
+					Expect(stop("").StatusCode).To(Equal(http.StatusForbidden))
+					Expect(monitClient.StopServiceCallCount()).To(Equal(0))
+				})
+
+				It("ignores X-Forwarded-For from untrusted clients", func() {
+					Expect(stop("10.1.2.3").StatusCode).To(Equal(http.StatusForbidden))
+					Expect(monitClient.StopServiceCallCount()).To(Equal(0))
+				})
+
+				It("still serves read requests", func() {
+					resp, err := http.DefaultClient.Do(createReq("mysql_status", "GET"))
+					Expect(err).ToNot(HaveOccurred())
+					Expect(resp.StatusCode).To(Equal(http.StatusOK))
+				})
+			})
+
+			Context("when the request comes through a trusted proxy", func() {
+				BeforeEach(func() {
+					testConfig.MutatingAllowlist.AllowedCIDRs = []string{"10.0.0.0/8"}
+					testConfig.MutatingAllowlist.TrustedProxies = []string{"127.0.0.1/32"}
+				})
+
+				It("allows clients forwarded from an allowed address", func() {
+					Expect(stop("10.1.2.3").StatusCode).To(Equal(http.StatusOK))
+					Expect(monitClient.StopServiceCallCount()).To(Equal(1))
+				})
+
+				It("rejects clients forwarded from other addresses", func() {
+					Expect(stop("192.168.0.9").StatusCode).To(Equal(http.StatusForbidden))
+				})
+
+				It("does not trust addresses the client prepended", func() {
+					Expect(stop("10.1.2.3, 192.168.0.9").StatusCode).To(Equal(http.StatusForbidden))
+				})
+			})
+		})
+
+		Describe("minimum cluster size for stops", func() {
+			var stop = func(endpoint string) (*http.Response, string) {
+				resp, err := http.DefaultClient.Do(createReq(endpoint, "POST"))
+				Expect(err).ToNot(HaveOccurred())
+
+				body, err := ioutil.ReadAll(resp.Body)
+				Expect(err).ToNot(HaveOccurred())
+				return resp, string(body)
+			}
+
+			It("does not check the cluster size when MinClusterSize is unset", func() {
+				resp, _ := stop("stop_mysql")
+				Expect(resp.StatusCode).To(Equal(http.StatusOK))
+				Expect(healthchecker.ClusterSizeCallCount()).To(Equal(0))
+				Expect(monitClient.StopServiceCallCount()).To(Equal(1))
+			})
+
+			Context("when MinClusterSize is configured", func() {
+				BeforeEach(func() {
+					testConfig.MinClusterSize = 2
+				})
+
+				It("allows the stop when the cluster stays at or above the minimum", func() {
+					healthchecker.ClusterSizeReturns(3, nil)
+
+					resp, _ := stop("stop_mysql")
+					Expect(resp.StatusCode).To(Equal(http.StatusOK))
+					Expect(monitClient.StopServiceCallCount()).To(Equal(1))
+				})
+
+				It("refuses the stop when the cluster would drop below the minimum", func() {
+					healthchecker.ClusterSizeReturns(2, nil)
+
+					resp, body := stop("stop_mysql")
+					Expect(resp.StatusCode).To(Equal(http.StatusConflict))
+					Expect(body).To(ContainSubstring("cluster size is 2"))
+					Expect(body).To(ContainSubstring("minimum of 2"))
+					Expect(monitClient.StopServiceCallCount()).To(Equal(0))
+				})
+
+				It("refuses the stop when the cluster size cannot be determined", func() {
+					healthchecker.ClusterSizeReturns(0, errors.New("connection refused"))
+
+					resp, body := stop("stop_mysql")
+					Expect(resp.StatusCode).To(Equal(http.StatusInternalServerError))
+					Expect(body).To(ContainSubstring("could not determine cluster size"))
+					Expect(monitClient.StopServiceCallCount()).To(Equal(0))
+				})
+
+				It("allows a forced stop without checking the cluster size", func() {
+					healthchecker.ClusterSizeReturns(2, nil)
+
+					resp, _ := stop("stop_mysql?force=true")
+					Expect(resp.StatusCode).To(Equal(http.StatusOK))
+					Expect(healthchecker.ClusterSizeCallCount()).To(Equal(0))
+					Expect(monitClient.StopServiceCallCount()).To(Equal(1))
+				})
+			})
+		})
+
+		Describe("/mysql_stats", func() {
+			It("returns the mysql process stats as JSON", func() {
+				monitClient.GetProcessStatsReturns(monit_client.ProcessStats{
+					MemoryKB:      195060,
+					CPUPercent:    0.2,
+					UptimeSeconds: 247559,
+				}, nil)
+
+				resp, err := http.DefaultClient.Do(createReq("mysql_stats", "GET"))
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(resp.StatusCode).To(Equal(http.StatusOK))
+				Expect(resp.Header.Get("Content-Type")).To(Equal("application/json"))
+				responseBody, err := ioutil.ReadAll(resp.Body)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(responseBody).To(MatchJSON(`{"memory_kb":195060,"cpu_percent":0.2,"uptime_seconds":247559}`))
+			})
+
+			It("returns 500 when monit cannot be queried", func() {
+				monitClient.GetProcessStatsReturns(monit_client.ProcessStats{}, errors.New("service not found"))
+
+				resp, err := http.DefaultClient.Do(createReq("mysql_stats", "GET"))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(resp.StatusCode).To(Equal(http.StatusInternalServerError))
+			})
+		})
+
+		It("returns 409 when bootstrapping is unsafe", func() {
+			monitClient.StartServiceBootstrapReturns("", node_manager.UnsafeBootstrapError{GrastatePath: "/path/to/grastate.dat"})
+
+			resp, err := http.DefaultClient.Do(createReq("start_mysql_bootstrap", "POST"))
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(resp.StatusCode).To(Equal(http.StatusConflict))
+			responseBody, err := ioutil.ReadAll(resp.Body)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(responseBody).To(ContainSubstring("safe_to_bootstrap"))
+		})
+
+		Describe("/metrics/wsrep", func() {
+			It("exposes wsrep status variables whether or not a metrics registry is provided", func() {
+				healthchecker.WsrepMetricsReturns(map[string]float64{
+					"wsrep_cluster_size":         3,
+					"wsrep_local_recv_queue_avg": 0.5,
+				}, nil)
+
+				resp, err := http.DefaultClient.Do(createReq("metrics/wsrep", "GET"))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(resp.StatusCode).To(Equal(http.StatusOK))
+				Expect(resp.Header.Get("Content-Type")).To(HavePrefix("text/plain"))
+
+				responseBody, err := ioutil.ReadAll(resp.Body)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(string(responseBody)).To(ContainSubstring("galera_wsrep_cluster_size 3\n"))
+				Expect(string(responseBody)).To(ContainSubstring("galera_wsrep_local_recv_queue_avg 0.5\n"))
+			})
+
+			It("reports a failure to read the wsrep status variables", func() {
+				healthchecker.WsrepMetricsReturns(nil, errors.New("database is down"))
+
+				resp, err := http.DefaultClient.Do(createReq("metrics/wsrep", "GET"))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(resp.StatusCode).To(Equal(http.StatusInternalServerError))
+			})
+
+			It("audits the request", func() {
+				resp, err := http.DefaultClient.Do(createReq("metrics/wsrep", "GET"))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(resp.StatusCode).To(Equal(http.StatusOK))
+
+				var auditLog lager.LogFormat
+				for _, log := range testLogger.Logs() {
+					if log.Message == "mysql_cmd.audit.audit" {
+						auditLog = log
+					}
+				}
+				Expect(auditLog.Data).To(HaveKeyWithValue("endpoint", "metrics_wsrep"))
+				Expect(auditLog.Data).To(HaveKeyWithValue("username", ApiUsername))
+			})
+
+			Context("when the read timeout is short", func() {
+				var release chan struct{}
+
+				BeforeEach(func() {
+					release = make(chan struct{})
+					testConfig.EndpointTimeouts.Read = 50 * time.Millisecond
+				})
+
+				AfterEach(func() {
+					close(release)
+				})
+
+				It("returns 503 when reading the variables takes too long", func() {
+					healthchecker.WsrepMetricsStub = func() (map[string]float64, error) {
+						<-release
+						return nil, nil
+					}
+
+					resp, err := http.DefaultClient.Do(createReq("metrics/wsrep", "GET"))
+					Expect(err).ToNot(HaveOccurred())
+					Expect(resp.StatusCode).To(Equal(http.StatusServiceUnavailable))
+				})
+			})
+		})
+
+		Describe("/health", func() {
+			var getHealth = func() (int, api.HealthResponse) {
+				resp, err := http.DefaultClient.Do(createReq("health", "GET"))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(resp.Header.Get("Content-Type")).To(Equal("application/json"))
+
+				var body api.HealthResponse
+				Expect(json.NewDecoder(resp.Body).Decode(&body)).To(Succeed())
+				return resp.StatusCode, body
+			}
+
+			It("reports a healthy node when every subsystem is healthy", func() {
+				status, body := getHealth()
+
+				Expect(status).To(Equal(http.StatusOK))
+				Expect(body).To(Equal(api.HealthResponse{
+					Healthy:        true,
+					Draining:       false,
+					TransferRole:   "member",
+					Wsrep:          api.SubsystemHealth{Status: ExpectedHealthCheckStatus},
+					Monit:          api.SubsystemHealth{Status: "running"},
+					SequenceNumber: api.SubsystemHealth{Status: ExpectedSeqno},
+				}))
+			})
+
+			Context("when the node is an SST donor", func() {
+				It("reports the donor transfer role and is unhealthy when donors do not receive traffic", func() {
+					healthchecker.CheckStatusReturns(healthcheck.CheckStatus{
+						Reason:     "not synced",
+						LocalState: healthcheck.STATE_DONOR_DESYNCED,
+					}, healthcheck.UnhealthyError{Err: errors.New("not synced")})
+
+					resp, err := http.DefaultClient.Do(createReq("health", "GET"))
+					Expect(err).ToNot(HaveOccurred())
+					Expect(resp.StatusCode).To(Equal(http.StatusServiceUnavailable))
+
+					body, err := ioutil.ReadAll(resp.Body)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(string(body)).To(ContainSubstring(`"transfer_role":"donor"`))
+					Expect(string(body)).To(ContainSubstring(`"healthy":false`))
+				})
+
+				It("reports the donor transfer role but stays healthy when donors receive traffic", func() {
+					healthchecker.CheckStatusReturns(healthcheck.CheckStatus{
+						State:      "synced",
+						Ready:      true,
+						LocalState: healthcheck.STATE_DONOR_DESYNCED,
+					}, nil)
+
+					status, body := getHealth()
+
+					Expect(status).To(Equal(http.StatusOK))
+					Expect(body.TransferRole).To(Equal(healthcheck.RoleDonor))
+					Expect(body.Healthy).To(BeTrue())
+				})
+			})
+
+			It("omits the transfer role when the wsrep state cannot be read", func() {
+				healthchecker.CheckStatusReturns(healthcheck.CheckStatus{Reason: "database is down"}, errors.New("database is down"))
+
+				_, body := getHealth()
+				Expect(body.TransferRole).To(BeEmpty())
+			})
+
+			It("attributes a failure to the subsystem that failed", func() {
+				healthchecker.CheckStatusReturns(healthcheck.CheckStatus{
+					Reason:     "joining",
+					LocalState: healthcheck.STATE_JOINING,
+				}, errors.New("joining"))
+
+				status, body := getHealth()
+
+				Expect(status).To(Equal(http.StatusServiceUnavailable))
+				Expect(body.Healthy).To(BeFalse())
+				Expect(body.Wsrep).To(Equal(api.SubsystemHealth{Error: "joining"}))
+				Expect(body.Monit).To(Equal(api.SubsystemHealth{Status: "running"}))
+				Expect(body.SequenceNumber).To(Equal(api.SubsystemHealth{Status: ExpectedSeqno}))
+			})
+
+			It("is unhealthy when mysqld is not running", func() {
+				monitClient.GetStatusReturns("stopped", nil)
+				sequenceNumber.CheckReturns("", errors.New("mysqld is not running"))
+
+				status, body := getHealth()
+
+				Expect(status).To(Equal(http.StatusServiceUnavailable))
+				Expect(body.Healthy).To(BeFalse())
+				Expect(body.Monit).To(Equal(api.SubsystemHealth{Status: "stopped"}))
+				Expect(body.SequenceNumber).To(Equal(api.SubsystemHealth{Error: "mysqld is not running"}))
+			})
+
+			It("is unhealthy while the node is draining", func() {
+				resp, err := http.DefaultClient.Do(createReq("drain", "POST"))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(resp.StatusCode).To(Equal(http.StatusOK))
+
+				status, body := getHealth()
+
+				Expect(status).To(Equal(http.StatusServiceUnavailable))
+				Expect(body.Healthy).To(BeFalse())
+				Expect(body.Draining).To(BeTrue())
+				Expect(body.Wsrep).To(Equal(api.SubsystemHealth{Status: ExpectedHealthCheckStatus}))
+			})
+		})
+
+		Describe("CORS", func() {
+			BeforeEach(func() {
+				testConfig.CORS = config.CORSConfig{
+					AllowedOrigins: []string{"https://dashboard.example.com"},
+					AllowedMethods: []string{"GET", "HEAD"},
+				}
+			})
+
+			var getFrom = func(endpoint, origin string) *http.Response {
+				req := createReq(endpoint, "GET")
+				req.Header.Set("Origin", origin)
+				resp, err := http.DefaultClient.Do(req)
+				Expect(err).ToNot(HaveOccurred())
+				return resp
+			}
+
+			It("allows an allowed origin to call the read endpoints", func() {
+				for _, endpoint := range []string{"galera_status", "mysql_status"} {
+					resp := getFrom(endpoint, "https://dashboard.example.com")
+					Expect(resp.StatusCode).To(Equal(http.StatusOK))
+					Expect(resp.Header.Get("Access-Control-Allow-Origin")).To(Equal("https://dashboard.example.com"), endpoint)
+				}
+			})
+
+			It("does not allow other origins", func() {
+				resp := getFrom("galera_status", "https://evil.example.com")
+				Expect(resp.Header.Get("Access-Control-Allow-Origin")).To(BeEmpty())
+			})
+
+			It("allows credentials from an explicitly allowed origin", func() {
+				resp := getFrom("mysql_status", "https://dashboard.example.com")
+				Expect(resp.Header.Get("Access-Control-Allow-Credentials")).To(Equal("true"))
+			})
+
+			Context("when any origin is allowed", func() {
+				BeforeEach(func() {
+					testConfig.CORS.AllowedOrigins = []string{"*"}
+				})
+
+				It("allows any origin without credentials", func() {
+					for _, endpoint := range []string{"galera_status", "mysql_status", "config"} {
+						resp := getFrom(endpoint, "https://evil.example.com")
+						Expect(resp.Header.Get("Access-Control-Allow-Origin")).To(Equal("*"), endpoint)
+						Expect(resp.Header.Get("Access-Control-Allow-Credentials")).To(BeEmpty(), endpoint)
+					}
+				})
+			})
+
+			It("answers preflight requests for the read endpoints", func() {
+				req := createReq("mysql_status", "OPTIONS")
+				req.Header.Set("Origin", "https://dashboard.example.com")
+				req.Header.Set("Access-Control-Request-Method", "GET")
+				resp, err := http.DefaultClient.Do(req)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(resp.StatusCode).To(Equal(http.StatusNoContent))
+				Expect(resp.Header.Get("Access-Control-Allow-Origin")).To(Equal("https://dashboard.example.com"))
+				Expect(resp.Header.Get("Access-Control-Allow-Methods")).To(Equal("GET, HEAD"))
+				Expect(monitClient.GetStatusCallCount()).To(Equal(0))
+			})
+
+			It("does not enable CORS on mutating endpoints", func() {
+				req := createReq("stop_mysql", "POST")
+				req.Header.Set("Origin", "https://dashboard.example.com")
+				resp, err := http.DefaultClient.Do(req)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(resp.Header.Get("Access-Control-Allow-Origin")).To(BeEmpty())
+
+				req = createReq("stop_mysql", "OPTIONS")
+				req.Header.Set("Origin", "https://dashboard.example.com")
+				resp, err = http.DefaultClient.Do(req)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(resp.Header.Get("Access-Control-Allow-Origin")).To(BeEmpty())
+			})
+		})
+
+		Describe("HEAD probes", func() {
+			It("reports a synced node as healthy without a body", func() {
+				resp, err := http.DefaultClient.Do(createReq("", "HEAD"))
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(resp.StatusCode).To(Equal(http.StatusOK))
+				body, err := ioutil.ReadAll(resp.Body)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(body).To(BeEmpty())
+				Expect(reqhealthchecker.CheckReqCallCount()).To(Equal(1))
+			})
+
+			It("reports an unreachable database as 503", func() {
+				reqhealthchecker.CheckReqReturns("", healthcheck.UnreachableError{Err: errors.New("Cannot get status from galera")})
+
+				resp, err := http.DefaultClient.Do(createReq("", "HEAD"))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(resp.StatusCode).To(Equal(http.StatusServiceUnavailable))
+			})
+
+			It("is supported at /galera_status", func() {
+				resp, err := http.DefaultClient.Do(createReq("galera_status", "HEAD"))
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(resp.StatusCode).To(Equal(http.StatusOK))
+				Expect(reqhealthchecker.CheckReqCallCount()).To(Equal(1))
+			})
+		})
+
+		Describe("response headers", func() {
+			BeforeEach(func() {
+				testConfig.ResponseHeaders = map[string]string{
+					"Cache-Control": "no-store, no-cache",
+					"X-Node":        "mysql/0",
+				}
+			})
+
+			It("sets them on the root response", func() {
+				resp, err := http.DefaultClient.Do(createReq("", "GET"))
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(resp.StatusCode).To(Equal(http.StatusOK))
+				Expect(resp.Header.Get("Cache-Control")).To(ContainSubstring("no-store"))
+				Expect(resp.Header.Get("X-Node")).To(Equal("mysql/0"))
+			})
+
+			It("sets them when the node is unhealthy", func() {
+				reqhealthchecker.CheckReqReturns("", healthcheck.UnreachableError{Err: errors.New("Cannot get status from galera")})
+
+				resp, err := http.DefaultClient.Do(createReq("galera_status", "GET"))
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(resp.StatusCode).To(Equal(http.StatusServiceUnavailable))
+				Expect(resp.Header.Get("Cache-Control")).To(Equal("no-store, no-cache"))
+			})
+
+			It("does not set them on other endpoints", func() {
+				resp, err := http.DefaultClient.Do(createReq("mysql_status", "GET"))
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(resp.Header.Get("X-Node")).To(BeEmpty())
+			})
+
+			Context("when a header is configured with an empty value", func() {
+				BeforeEach(func() {
+					testConfig.ResponseHeaders["Cache-Control"] = ""
+				})
+
+				It("omits it", func() {
+					resp, err := http.DefaultClient.Do(createReq("", "GET"))
+					Expect(err).ToNot(HaveOccurred())
+
+					Expect(resp.Header).ToNot(HaveKey("Cache-Control"))
+				})
+			})
+		})
+
+		Describe("/stop_mysql_graceful", func() {
+			var rootStatusAtStop int
+
+			BeforeEach(func() {
+				testConfig.GracefulStopTimeout = 200 * time.Millisecond
+				testConfig.GracefulStopPollInterval = 10 * time.Millisecond
+
+				rootStatusAtStop = 0
+				monitClient.StopServiceStub = func(*http.Request) (string, error) {
+					resp, err := http.Get(ts.URL + "/")
+					if err == nil {
+						rootStatusAtStop = resp.StatusCode
+						resp.Body.Close()
+					}
+					return "stop successful", nil
+				}
+			})
+
+			It("drains the node before stopping once clients have disconnected", func() {
+				healthchecker.ClientConnectionsReturnsOnCall(0, 2, nil)
+				healthchecker.ClientConnectionsReturnsOnCall(1, 0, nil)
+
+				resp, err := http.DefaultClient.Do(createReq("stop_mysql_graceful", "POST"))
+				Expect(err).ToNot(HaveOccurred())
+				body, err := ioutil.ReadAll(resp.Body)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(resp.StatusCode).To(Equal(http.StatusOK))
+				Expect(string(body)).To(Equal("client connections drained; stop successful"))
+				Expect(healthchecker.ClientConnectionsCallCount()).To(Equal(2))
+				Expect(monitClient.StopServiceCallCount()).To(Equal(1))
+				Expect(rootStatusAtStop).To(Equal(http.StatusServiceUnavailable))
+			})
+
+			It("stops anyway once the grace period expires", func() {
+				healthchecker.ClientConnectionsReturns(3, nil)
+
+				start := time.Now()
+				resp, err := http.DefaultClient.Do(createReq("stop_mysql_graceful", "POST"))
+				Expect(err).ToNot(HaveOccurred())
+				body, err := ioutil.ReadAll(resp.Body)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(time.Since(start)).To(BeNumerically(">=", 200*time.Millisecond))
+				Expect(resp.StatusCode).To(Equal(http.StatusOK))
+				Expect(string(body)).To(Equal("grace period of 200ms expired with 3 client connections open; stop successful"))
+				Expect(monitClient.StopServiceCallCount()).To(Equal(1))
+				Expect(rootStatusAtStop).To(Equal(http.StatusServiceUnavailable))
+			})
+
+			It("reports a failure to stop", func() {
+				healthchecker.ClientConnectionsReturns(0, nil)
+				monitClient.StopServiceStub = nil
+				monitClient.StopServiceReturns("", errors.New("monit is unavailable"))
+
+				resp, err := http.DefaultClient.Do(createReq("stop_mysql_graceful", "POST"))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(resp.StatusCode).To(Equal(http.StatusInternalServerError))
+			})
+		})
+
+		Describe("draining", func() {
+			var getRoot = func() (int, string) {
+				resp, err := http.DefaultClient.Do(createReq("", "GET"))
+				Expect(err).ToNot(HaveOccurred())
+				body, err := ioutil.ReadAll(resp.Body)
+				Expect(err).ToNot(HaveOccurred())
+				return resp.StatusCode, string(body)
+			}
+
+			It("reports the node unhealthy between /drain and /undrain", func() {
+				status, _ := getRoot()
+				Expect(status).To(Equal(http.StatusOK))
+
+				resp, err := http.DefaultClient.Do(createReq("drain", "POST"))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(resp.StatusCode).To(Equal(http.StatusOK))
+
+				status, body := getRoot()
+				Expect(status).To(Equal(http.StatusServiceUnavailable))
+				Expect(body).To(Equal("draining"))
+
+				resp, err = http.DefaultClient.Do(createReq("galera_status", "GET"))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(resp.StatusCode).To(Equal(http.StatusServiceUnavailable))
+
+				resp, err = http.DefaultClient.Do(createReq("undrain", "POST"))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(resp.StatusCode).To(Equal(http.StatusOK))
+
+				status, body = getRoot()
+				Expect(status).To(Equal(http.StatusOK))
+				Expect(body).To(Equal(ExpectedHealthCheckStatus))
+			})
+
+			It("requires authentication", func() {
+				req := createReq("drain", "POST")
+				req.SetBasicAuth("bad-username", "bad-password")
+				resp, err := http.DefaultClient.Do(req)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(resp.StatusCode).To(Equal(http.StatusUnauthorized))
+
+				status, _ := getRoot()
+				Expect(status).To(Equal(http.StatusOK))
+			})
+		})
+
+		Describe("/reset", func() {
+			It("undrains the node and invalidates the health cache", func() {
+				resp, err := http.DefaultClient.Do(createReq("drain", "POST"))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(resp.StatusCode).To(Equal(http.StatusOK))
+
+				resp, err = http.DefaultClient.Do(createReq("reset", "POST"))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(resp.StatusCode).To(Equal(http.StatusOK))
+				Expect(healthchecker.ResetCacheCallCount()).To(Equal(1))
+
+				resp, err = http.DefaultClient.Do(createReq("", "GET"))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(resp.StatusCode).To(Equal(http.StatusOK))
+			})
+
+			It("does not touch mysqld", func() {
+				resp, err := http.DefaultClient.Do(createReq("reset", "POST"))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(resp.StatusCode).To(Equal(http.StatusOK))
+
+				Expect(monitClient.StopServiceCallCount()).To(Equal(0))
+				Expect(monitClient.StartServiceJoinCallCount()).To(Equal(0))
+				Expect(monitClient.StartServiceBootstrapCallCount()).To(Equal(0))
+			})
+
+			It("requires authentication", func() {
+				resp, err := http.DefaultClient.Do(createReq("drain", "POST"))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(resp.StatusCode).To(Equal(http.StatusOK))
+
+				req := createReq("reset", "POST")
+				req.SetBasicAuth("bad-username", "bad-password")
+				resp, err = http.DefaultClient.Do(req)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(resp.StatusCode).To(Equal(http.StatusUnauthorized))
+				Expect(healthchecker.ResetCacheCallCount()).To(Equal(0))
+
+				resp, err = http.DefaultClient.Do(createReq("", "GET"))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(resp.StatusCode).To(Equal(http.StatusServiceUnavailable))
+			})
+		})
+
+		Describe("error responses", func() {
+			BeforeEach(func() {
+				monitClient.StopServiceReturns("", errors.New("monit is unavailable"))
+			})
+
+			It("reports the error and a stable code in a JSON envelope", func() {
+				req := createReq("stop_mysql", "POST")
+				req.Header.Set("Accept", "application/json")
+				resp, err := http.DefaultClient.Do(req)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(resp.StatusCode).To(Equal(http.StatusInternalServerError))
+				Expect(resp.Header.Get("Content-Type")).To(Equal("application/json"))
+
+				var body api.ErrorResponse
+				Expect(json.NewDecoder(resp.Body).Decode(&body)).To(Succeed())
+				Expect(body).To(Equal(api.ErrorResponse{
+					Error: "monit is unavailable",
+					Code:  api.ErrorCodeServiceStopFailed,
+				}))
+			})
+
+			It("uses the code for the failing operation", func() {
+				monitClient.GetStatusReturns("", errors.New("monit is unavailable"))
+
+				req := createReq("mysql_status", "GET")
+				req.Header.Set("Accept", "application/json")
+				resp, err := http.DefaultClient.Do(req)
+				Expect(err).ToNot(HaveOccurred())
+
+				var body api.ErrorResponse
+				Expect(json.NewDecoder(resp.Body).Decode(&body)).To(Succeed())
+				Expect(body.Code).To(Equal(api.ErrorCodeServiceStatusFailed))
+			})
+
+			It("falls back to plain text for clients that accept text/plain", func() {
+				testConfig.ResponseFormat = config.ResponseFormatJSON
+
+				req := createReq("stop_mysql", "POST")
+				req.Header.Set("Accept", "text/plain")
+				resp, err := http.DefaultClient.Do(req)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(resp.StatusCode).To(Equal(http.StatusInternalServerError))
+				body, err := ioutil.ReadAll(resp.Body)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(string(body)).To(Equal("monit is unavailable"))
+			})
+		})
+
+		Context("when the sidecar is read-only", func() {
+			BeforeEach(func() {
+				testConfig.ReadOnly = true
+			})
+
+			It("refuses to stop mysql without calling monit", func() {
+				resp, err := http.DefaultClient.Do(createReq("stop_mysql", "POST"))
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(resp.StatusCode).To(Equal(http.StatusMethodNotAllowed))
+				Expect(monitClient.StopServiceCallCount()).To(Equal(0))
+			})
+
+			It("refuses to start mysql or run wsrep recovery", func() {
+				for _, endpoint := range []string{"start_mysql_bootstrap", "start_mysql_join", "start_mysql_single_node", "wsrep_recover"} {
+					resp, err := http.DefaultClient.Do(createReq(endpoint, "POST"))
+					Expect(err).ToNot(HaveOccurred())
+					Expect(resp.StatusCode).To(Equal(http.StatusMethodNotAllowed), endpoint)
+				}
+
+				Expect(monitClient.StartServiceBootstrapCallCount()).To(Equal(0))
+				Expect(monitClient.StartServiceJoinCallCount()).To(Equal(0))
+				Expect(monitClient.StartServiceSingleNodeCallCount()).To(Equal(0))
+				Expect(sequenceNumber.RecoverPositionCallCount()).To(Equal(0))
+			})
+
+			It("still serves the read endpoints", func() {
+				for _, endpoint := range []string{"", "galera_status", "mysql_status", "sequence_number"} {
+					resp, err := http.DefaultClient.Do(createReq(endpoint, "GET"))
+					Expect(err).ToNot(HaveOccurred())
+					Expect(resp.StatusCode).To(Equal(http.StatusOK), endpoint)
+				}
+			})
+		})
+
+		Describe("endpoint timeouts", func() {
+			var release chan struct{}
+
+			BeforeEach(func() {
+				release = make(chan struct{})
+				testConfig.EndpointTimeouts = config.EndpointTimeoutsConfig{
+					Read:     50 * time.Millisecond,
+					Mutating: 5 * time.Second,
+				}
+			})
+
+			AfterEach(func() {
+				close(release)
+			})
+
+			It("returns 503 when a read endpoint takes too long", func() {
+				reqhealthchecker.CheckReqStub = func(*http.Request) (string, error) {
+					<-release
+					return ExpectedHealthCheckStatus, nil
+				}
+
+				resp, err := http.DefaultClient.Do(createReq("", "GET"))
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(resp.StatusCode).To(Equal(http.StatusServiceUnavailable))
+				body, err := ioutil.ReadAll(resp.Body)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(string(body)).To(Equal("request timed out after 50ms"))
+			})
+
+			It("gives mutating endpoints the longer timeout", func() {
+				monitClient.StopServiceStub = func(*http.Request) (string, error) {
+					time.Sleep(100 * time.Millisecond)
+					return "Successfully sent stop request", nil
+				}
+
+				resp, err := http.DefaultClient.Do(createReq("stop_mysql", "POST"))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(resp.StatusCode).To(Equal(http.StatusOK))
+			})
+
+			It("gives endpoints that run wsrep recovery the longer timeout", func() {
+				testdb.SetExecFunc(func(query string) (driver.Result, error) {
+					return nil, errors.New("connection refused")
+				})
+				defer testdb.Reset()
+				db, err := sql.Open("testdb", "")
+				Expect(err).ToNot(HaveOccurred())
+
+				checkerConfig := config.Config{MysqldPath: "/var/vcap/packages/pxc/bin/mysqld"}
+				runner := &slowRunner{delay: 100 * time.Millisecond, errorLog: "WSREP: Recovered position: 6d8c3c23-0c8b-11eb-a2b7-6f0b4e5a5f2b:57"}
+				monit := &sequence_numberfakes.FakeMonitClient{}
+				monit.StatusReturns("stopped", nil)
+				checker := sequence_number.New(db, mysqld_cmd.NewMysqldCmd(testLogger, checkerConfig, runner), monit, checkerConfig, testLogger)
+				sequenceNumber.CheckStub = checker.Check
+
+				resp, err := http.DefaultClient.Do(createReq("sequence_number", "GET"))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(resp.StatusCode).To(Equal(http.StatusOK))
+
+				body, err := ioutil.ReadAll(resp.Body)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(string(body)).To(Equal("57"))
+			})
+		})
+
+		Describe("/monit/reload", func() {
+			It("reloads monit and returns its response", func() {
+				monitClient.ReloadMonitReturns("Monit daemon reinitialized", nil)
+
+				resp, err := http.DefaultClient.Do(createReq("monit/reload", "POST"))
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(resp.StatusCode).To(Equal(http.StatusOK))
+				body, err := ioutil.ReadAll(resp.Body)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(string(body)).To(Equal("Monit daemon reinitialized"))
+				Expect(monitClient.ReloadMonitCallCount()).To(Equal(1))
+			})
+
+			It("reports a failed reload", func() {
+				monitClient.ReloadMonitReturns("", errors.New("failed to reload monit: status code: 403"))
+
+				resp, err := http.DefaultClient.Do(createReq("monit/reload", "POST"))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(resp.StatusCode).To(Equal(http.StatusInternalServerError))
+			})
+		})
+
+		Describe("/monit/unmonitor", func() {
+			It("unmonitors the service without stopping it", func() {
+				monitClient.UnmonitorServiceReturns("unmonitor successful", nil)
+
+				resp, err := http.DefaultClient.Do(createReq("monit/unmonitor", "POST"))
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(resp.StatusCode).To(Equal(http.StatusOK))
+				body, err := ioutil.ReadAll(resp.Body)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(string(body)).To(Equal("unmonitor successful"))
+				Expect(monitClient.UnmonitorServiceCallCount()).To(Equal(1))
+				Expect(monitClient.StopServiceCallCount()).To(Equal(0))
+			})
+
+			It("reports a failed monit action", func() {
+				monitClient.UnmonitorServiceReturns("", errors.New("failed to make unmonitor request for mysql: status code: 403"))
+
+				req := createReq("monit/unmonitor", "POST")
+				req.Header.Set("Accept", "application/json")
+				resp, err := http.DefaultClient.Do(req)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(resp.StatusCode).To(Equal(http.StatusInternalServerError))
+
+				body, err := ioutil.ReadAll(resp.Body)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(string(body)).To(ContainSubstring("MONIT_ACTION_FAILED"))
+			})
+		})
+
+		Describe("/monit/monitor", func() {
+			It("resumes monitoring the service", func() {
+				monitClient.MonitorServiceReturns("monitor successful", nil)
+
+				resp, err := http.DefaultClient.Do(createReq("monit/monitor", "POST"))
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(resp.StatusCode).To(Equal(http.StatusOK))
+				body, err := ioutil.ReadAll(resp.Body)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(string(body)).To(Equal("monitor successful"))
+				Expect(monitClient.MonitorServiceCallCount()).To(Equal(1))
+			})
+		})
+
+		Describe("/monit/services", func() {
+			It("returns every monit service and its status", func() {
+				monitClient.GetServicesReturns([]monit_client.ServiceSummary{
+					{Name: "galera-init", Status: monit_client.StateRunning},
+					{Name: "garbd", Status: monit_client.StateNotMonitored},
+				}, nil)
+
+				resp, err := http.DefaultClient.Do(createReq("monit/services", "GET"))
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(resp.StatusCode).To(Equal(http.StatusOK))
+				Expect(resp.Header.Get("Content-Type")).To(Equal("application/json"))
+				body, err := ioutil.ReadAll(resp.Body)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(body).To(MatchJSON(`[
+					{"name":"galera-init","status":"running"},
+					{"name":"garbd","status":"not_monitored"}
+				]`))
+			})
+
+			It("reports a failure to query monit", func() {
+				monitClient.GetServicesReturns(nil, errors.New("monit unavailable"))
+
+				resp, err := http.DefaultClient.Do(createReq("monit/services", "GET"))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(resp.StatusCode).To(Equal(http.StatusInternalServerError))
+			})
+		})
+
+		Describe("/config", func() {
+			BeforeEach(func() {
+				testConfig.DB = config.DBConfig{User: "db-user", Password: "db-password"}
+				testConfig.Monit = config.MonitConfig{
+					User:                          "monit-user",
+					Password:                      "monit-password",
+					ServiceName:                   "galera-init",
+					MysqlStateFilePath:            "/var/vcap/store/mysql/state.txt",
+					GaleraInitStatusServerAddress: "127.0.0.1:8114",
+				}
+				testConfig.TLS = config.TLSConfig{
+					CertificatePath: "/certs/server.crt",
+					PrivateKeyPath:  "/certs/server.key",
+				}
+			})
+
+			var getConfig = func() string {
+				resp, err := http.DefaultClient.Do(createReq("config", "GET"))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(resp.StatusCode).To(Equal(http.StatusOK))
+				Expect(resp.Header.Get("Content-Type")).To(Equal("application/json"))
+
+				body, err := ioutil.ReadAll(resp.Body)
+				Expect(err).ToNot(HaveOccurred())
+				return string(body)
+			}
+
+			It("returns the effective configuration", func() {
+				var effective config.Config
+				Expect(json.Unmarshal([]byte(getConfig()), &effective)).To(Succeed())
+
+				Expect(effective.Monit.ServiceName).To(Equal("galera-init"))
+				Expect(effective.Monit.MysqlStateFilePath).To(Equal("/var/vcap/store/mysql/state.txt"))
+				Expect(effective.Monit.GaleraInitStatusServerAddress).To(Equal("127.0.0.1:8114"))
+				Expect(effective.TLS.CertificatePath).To(Equal("/certs/server.crt"))
+			})
+
+			It("redacts credentials", func() {
+				body := getConfig()
+				for _, secret := range []string{"db-user", "db-password", "monit-user", "monit-password", ApiUsername, ApiPassword} {
+					Expect(body).NotTo(ContainSubstring(secret))
+				}
+				Expect(body).To(ContainSubstring("[REDACTED]"))
+			})
+		})
+
+		Describe("/state", func() {
+			It("returns the state file contents", func() {
+				state := "CLUSTERED"
+				monitClient.GetStateReturns(&state, nil)
+
+				resp, err := http.DefaultClient.Do(createReq("state", "GET"))
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(resp.StatusCode).To(Equal(http.StatusOK))
+				body, err := ioutil.ReadAll(resp.Body)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(body).To(MatchJSON(`{"state":"CLUSTERED"}`))
+			})
+
+			It("returns a null state when there is no state file", func() {
+				monitClient.GetStateReturns(nil, nil)
+
+				resp, err := http.DefaultClient.Do(createReq("state", "GET"))
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(resp.StatusCode).To(Equal(http.StatusOK))
+				body, err := ioutil.ReadAll(resp.Body)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(body).To(MatchJSON(`{"state":null}`))
+			})
+
+			It("requires authentication", func() {
+				req := createReq("state", "GET")
+				req.SetBasicAuth("bad-username", "bad-password")
+				resp, err := http.DefaultClient.Do(req)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(resp.StatusCode).To(Equal(http.StatusUnauthorized))
+			})
+		})
+
+		Context("when a base path is configured", func() {
+			BeforeEach(func() {
+				testConfig.BasePath = "/node-0"
+			})
+
+			It("serves every endpoint under the base path", func() {
+				resp, err := http.DefaultClient.Do(createReq("node-0/mysql_status", "GET"))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(resp.StatusCode).To(Equal(http.StatusOK))
+				Expect(monitClient.GetStatusCallCount()).To(Equal(1))
+
+				resp, err = http.DefaultClient.Do(createReq("mysql_status", "GET"))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(resp.StatusCode).To(Equal(http.StatusNotFound))
+			})
+
+			It("serves the root health check at the base path itself", func() {
+				resp, err := http.DefaultClient.Do(createReq("node-0", "GET"))
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(resp.StatusCode).To(Equal(http.StatusOK))
+				body, err := ioutil.ReadAll(resp.Body)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(string(body)).To(Equal(ExpectedHealthCheckStatus))
+			})
+		})
+
+		Describe("node role", func() {
+			var getRole = func() string {
+				resp, err := http.DefaultClient.Do(createReq("api/v1/status", "GET"))
+				Expect(err).ToNot(HaveOccurred())
+
+				var status api.V1StatusResponse
+				Expect(json.NewDecoder(resp.Body).Decode(&status)).To(Succeed())
+				return status.Role
+			}
+
+			It("reports the replica role by default", func() {
+				Expect(getRole()).To(Equal("replica"))
+			})
+
+			Context("when a role is configured", func() {
+				BeforeEach(func() {
+					testConfig.Role = config.RolePrimary
+				})
+
+				It("reports the configured role", func() {
+					Expect(getRole()).To(Equal("primary"))
+				})
+			})
+
+			It("can be overridden with POST /role", func() {
+				resp, err := http.DefaultClient.Do(createReq("role?role=primary", "POST"))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(resp.StatusCode).To(Equal(http.StatusOK))
+
+				Expect(getRole()).To(Equal("primary"))
+			})
+
+			It("rejects unknown roles", func() {
+				resp, err := http.DefaultClient.Do(createReq("role?role=leader", "POST"))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(resp.StatusCode).To(Equal(http.StatusBadRequest))
+
+				Expect(getRole()).To(Equal("replica"))
+			})
+		})
+
+		Describe("/sequence_number/compare", func() {
+			var compare = func(nodes string) (*http.Response, string) {
+				req := createReq("sequence_number/compare", "POST")
+				req.Body = ioutil.NopCloser(strings.NewReader(nodes))
+				resp, err := http.DefaultClient.Do(req)
+				Expect(err).ToNot(HaveOccurred())
+
+				body, err := ioutil.ReadAll(resp.Body)
+				Expect(err).ToNot(HaveOccurred())
+				return resp, string(body)
+			}
+
+			It("reports this node as the winner when it has the highest seqno", func() {
+				resp, body := compare(`[{"node":"mysql/0","seqno":4},{"node":"mysql/1","seqno":3}]`)
+				Expect(resp.StatusCode).To(Equal(http.StatusOK))
+				Expect(body).To(MatchJSON(`{
+					"winner": "mysql/0",
+					"highest_seqno": 4,
+					"local_sequence_number": 4,
+					"is_winner": true
+				}`))
+			})
+
+			It("reports this node as a winner of a tie", func() {
+				resp, body := compare(`[{"node":"mysql/1","seqno":4},{"node":"mysql/0","seqno":4},{"node":"mysql/2","seqno":2}]`)
+				Expect(resp.StatusCode).To(Equal(http.StatusOK))
+				Expect(body).To(MatchJSON(`{
+					"winner": "mysql/0",
+					"highest_seqno": 4,
+					"tied": ["mysql/0", "mysql/1"],
+					"local_sequence_number": 4,
+					"is_winner": true
+				}`))
+			})
+
+			It("ignores arbitrators in the reported set", func() {
+				resp, body := compare(`[{"node":"arbitrator/0","seqno":null},{"node":"mysql/1","seqno":7},{"node":"mysql/0","seqno":4}]`)
+				Expect(resp.StatusCode).To(Equal(http.StatusOK))
+				Expect(body).To(MatchJSON(`{
+					"winner": "mysql/1",
+					"highest_seqno": 7,
+					"local_sequence_number": 4,
+					"is_winner": false
+				}`))
+			})
+
+			Context("when running on an arbitrator node", func() {
+				BeforeEach(func() {
+					testConfig.Monit.ServiceName = "garbd"
+				})
+
+				It("never reports this node as the winner", func() {
+					resp, body := compare(`[{"node":"arbitrator/0"},{"node":"mysql/0","seqno":4}]`)
+					Expect(resp.StatusCode).To(Equal(http.StatusOK))
+					Expect(body).To(MatchJSON(`{
+						"winner": "mysql/0",
+						"highest_seqno": 4,
+						"local_sequence_number": null,
+						"is_winner": false
+					}`))
+					Expect(sequenceNumber.CheckCallCount()).To(Equal(0))
+				})
+			})
+
+			It("returns 400 when no node reported a seqno", func() {
+				resp, body := compare(`[{"node":"arbitrator/0"}]`)
+				Expect(resp.StatusCode).To(Equal(http.StatusBadRequest))
+				Expect(body).To(ContainSubstring("no node reported a sequence number"))
+			})
+
+			It("returns 400 for a malformed body", func() {
+				resp, _ := compare(`{"node":"mysql/0"}`)
+				Expect(resp.StatusCode).To(Equal(http.StatusBadRequest))
+			})
+		})
+
+		Describe("/debug/pprof", func() {
+			It("is not served by default", func() {
+				resp, err := http.DefaultClient.Do(createReq("debug/pprof/", "GET"))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(resp.StatusCode).To(Equal(http.StatusNotFound))
+			})
+
+			Context("when EnableProfiling is set", func() {
+				BeforeEach(func() {
+					testConfig.EnableProfiling = true
+				})
+
+				It("serves the profile index", func() {
+					resp, err := http.DefaultClient.Do(createReq("debug/pprof/", "GET"))
+					Expect(err).ToNot(HaveOccurred())
+					Expect(resp.StatusCode).To(Equal(http.StatusOK))
+
+					body, err := ioutil.ReadAll(resp.Body)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(string(body)).To(ContainSubstring("goroutine"))
+				})
+
+				It("serves named profiles", func() {
+					resp, err := http.DefaultClient.Do(createReq("debug/pprof/goroutine?debug=1", "GET"))
+					Expect(err).ToNot(HaveOccurred())
+					Expect(resp.StatusCode).To(Equal(http.StatusOK))
+
+					body, err := ioutil.ReadAll(resp.Body)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(string(body)).To(HavePrefix("goroutine profile:"))
+				})
+
+				It("serves the command line", func() {
+					resp, err := http.DefaultClient.Do(createReq("debug/pprof/cmdline", "GET"))
+					Expect(err).ToNot(HaveOccurred())
+					Expect(resp.StatusCode).To(Equal(http.StatusOK))
+				})
+
+				Context("when a BasePath is configured", func() {
+					BeforeEach(func() {
+						testConfig.BasePath = "/sidecar"
+					})
+
+					It("serves named profiles under the BasePath", func() {
+						resp, err := http.DefaultClient.Do(createReq("sidecar/debug/pprof/goroutine?debug=1", "GET"))
+						Expect(err).ToNot(HaveOccurred())
+						Expect(resp.StatusCode).To(Equal(http.StatusOK))
+
+						body, err := ioutil.ReadAll(resp.Body)
+						Expect(err).ToNot(HaveOccurred())
+						Expect(string(body)).To(HavePrefix("goroutine profile:"))
+					})
+				})
+			})
+		})
+
+		Describe("/wsrep_recover", func() {
+			It("returns the recovered position as JSON", func() {
+				sequenceNumber.RecoverPositionReturns(mysqld_cmd.RecoveredPosition{
+					UUID:  "6d8c3c23-0c8b-11eb-a2b7-6f0b4e5a5f2b",
+					Seqno: 32,
+				}, nil)
+
+				resp, err := http.DefaultClient.Do(createReq("wsrep_recover", "POST"))
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(resp.StatusCode).To(Equal(http.StatusOK))
+				responseBody, err := ioutil.ReadAll(resp.Body)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(responseBody).To(MatchJSON(`{"uuid":"6d8c3c23-0c8b-11eb-a2b7-6f0b4e5a5f2b","seqno":32}`))
+			})
+
+			It("returns 409 when mysqld is running", func() {
+				sequenceNumber.RecoverPositionReturns(mysqld_cmd.RecoveredPosition{}, sequence_number.MysqldRunningError{})
+
+				resp, err := http.DefaultClient.Do(createReq("wsrep_recover", "POST"))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(resp.StatusCode).To(Equal(http.StatusConflict))
+			})
+		})
+
+		It("returns 404 when a request is made to an unsupplied endpoint", func() {
+			req := createReq("nonexistent_endpoint", "GET")
+			resp, err := http.DefaultClient.Do(req)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(resp.StatusCode).To(Equal(http.StatusNotFound))
+		})
+	})
+
+	Context("when request does not have basic auth", func() {
+		var createReq = func(endpoint string, method string) *http.Request {
+			url := fmt.Sprintf("%s/%s", ts.URL, endpoint)
+			req, err := http.NewRequest(method, url, nil)
+			Expect(err).ToNot(HaveOccurred())
+			return req
+		}
+
+		It("challenges with the default realm", func() {
+			resp, err := http.DefaultClient.Do(createReq("stop_mysql", "POST"))
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(resp.StatusCode).To(Equal(http.StatusUnauthorized))
+			Expect(resp.Header.Get("WWW-Authenticate")).To(Equal(`Basic realm="Authorization Required"`))
+		})
+
+		Context("when a realm is configured", func() {
+			BeforeEach(func() {
+				testConfig.SidecarEndpoint.Realm = "mysql-sidecar"
+			})
+
+			It("challenges with the configured realm", func() {
+				resp, err := http.DefaultClient.Do(createReq("mysql_status", "GET"))
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(resp.StatusCode).To(Equal(http.StatusUnauthorized))
+				Expect(resp.Header.Get("WWW-Authenticate")).To(Equal(`Basic realm="mysql-sidecar"`))
+			})
+		})
+
+		It("requires authentication for /stop_mysql", func() {
+			req := createReq("stop_mysql", "POST")
+			resp, err := http.DefaultClient.Do(req)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(resp.StatusCode).To(Equal(http.StatusUnauthorized))
+			Expect(monitClient.StopServiceCallCount()).To(Equal(0))
+		})
+
+		It("requires authentication for /stop_mysql_graceful", func() {
+			req := createReq("stop_mysql_graceful", "POST")
+			resp, err := http.DefaultClient.Do(req)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(resp.StatusCode).To(Equal(http.StatusUnauthorized))
+			Expect(healthchecker.ClientConnectionsCallCount()).To(Equal(0))
+			Expect(monitClient.StopServiceCallCount()).To(Equal(0))
+		})
+
+		It("requires authentication for /start_mysql_bootstrap", func() {
+			req := createReq("start_mysql_bootstrap", "POST")
+			resp, err := http.DefaultClient.Do(req)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(resp.StatusCode).To(Equal(http.StatusUnauthorized))
+			Expect(monitClient.StartServiceBootstrapCallCount()).To(Equal(0))
+		})
+
+		It("requires authentication for /start_mysql_join", func() {
+			req := createReq("start_mysql_join", "POST")
+			resp, err := http.DefaultClient.Do(req)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(resp.StatusCode).To(Equal(http.StatusUnauthorized))
+			Expect(monitClient.StartServiceJoinCallCount()).To(Equal(0))
+		})
+
+		It("requires authentication for /mysql_status", func() {
+			req := createReq("mysql_status", "GET")
+			resp, err := http.DefaultClient.Do(req)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(resp.StatusCode).To(Equal(http.StatusUnauthorized))
+			Expect(monitClient.GetStatusCallCount()).To(Equal(0))
+		})
+
+		It("requires authentication for /wsrep_status", func() {
+			req := createReq("wsrep_status", "GET")
+			resp, err := http.DefaultClient.Do(req)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(resp.StatusCode).To(Equal(http.StatusUnauthorized))
+			Expect(healthchecker.WsrepStatusCallCount()).To(Equal(0))
+		})
+
+		It("requires authentication for /cluster_size", func() {
+			resp, err := http.DefaultClient.Do(createReq("cluster_size", "GET"))
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(resp.StatusCode).To(Equal(http.StatusUnauthorized))
+			Expect(healthchecker.ClusterSizeCallCount()).To(Equal(0))
+		})
+
+		It("requires authentication for /wsrep_recover", func() {
+			resp, err := http.DefaultClient.Do(createReq("wsrep_recover", "POST"))
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(resp.StatusCode).To(Equal(http.StatusUnauthorized))
+			Expect(sequenceNumber.RecoverPositionCallCount()).To(Equal(0))
+		})
+
+		Context("when EnableProfiling is set", func() {
+			BeforeEach(func() {
+				testConfig.EnableProfiling = true
+			})
+
+			It("requires authentication for /debug/pprof", func() {
+				req := createReq("debug/pprof/", "GET")
+				resp, err := http.DefaultClient.Do(req)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(resp.StatusCode).To(Equal(http.StatusUnauthorized))
+			})
+		})
+
+		It("requires authentication for /status", func() {
+			req := createReq("status?like=wsrep%25", "GET")
+			resp, err := http.DefaultClient.Do(req)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(resp.StatusCode).To(Equal(http.StatusUnauthorized))
+		})
+
+		It("requires authentication for /config", func() {
+			req := createReq("config", "GET")
+			resp, err := http.DefaultClient.Do(req)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(resp.StatusCode).To(Equal(http.StatusUnauthorized))
+		})
+
+		It("requires authentication for /monit/services", func() {
+			req := createReq("monit/services", "GET")
+			resp, err := http.DefaultClient.Do(req)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(resp.StatusCode).To(Equal(http.StatusUnauthorized))
+		})
+
+		It("requires authentication for /monit/unmonitor", func() {
+			req := createReq("monit/unmonitor", "POST")
+			resp, err := http.DefaultClient.Do(req)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(resp.StatusCode).To(Equal(http.StatusUnauthorized))
+			Expect(monitClient.UnmonitorServiceCallCount()).To(Equal(0))
+		})
+
+		It("requires authentication for /sequence_number", func() {
+			req := createReq("sequence_number", "GET")
+			resp, err := http.DefaultClient.Do(req)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(resp.StatusCode).To(Equal(http.StatusUnauthorized))
+			responseBody, err := ioutil.ReadAll(resp.Body)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(responseBody).ToNot(ContainSubstring(ExpectedSeqno))
+			Expect(sequenceNumber.CheckCallCount()).To(Equal(0))
+		})
+
+		Context("when multiple credentials are configured", func() {
+			BeforeEach(func() {
+				testConfig.SidecarEndpoint.Credentials = []config.CredentialConfig{
+					{Username: "rotated-username", Password: "rotated-password"},
+				}
+			})
+
+			var getStatusAs = func(username, password string) int {
+				req := createReq("mysql_status", "GET")
+				req.SetBasicAuth(username, password)
+				resp, err := http.DefaultClient.Do(req)
+				Expect(err).ToNot(HaveOccurred())
+				return resp.StatusCode
+			}
+
+			It("accepts any configured credential pair", func() {
+				Expect(getStatusAs(ApiUsername, ApiPassword)).To(Equal(http.StatusOK))
+				Expect(getStatusAs("rotated-username", "rotated-password")).To(Equal(http.StatusOK))
+			})
+
+			It("rejects credentials that do not match a configured pair", func() {
+				Expect(getStatusAs("other-username", "other-password")).To(Equal(http.StatusUnauthorized))
+				Expect(getStatusAs(ApiUsername, "rotated-password")).To(Equal(http.StatusUnauthorized))
+			})
+
+			It("rejects credentials that differ only in length", func() {
+				Expect(getStatusAs(ApiUsername, ApiPassword+"x")).To(Equal(http.StatusUnauthorized))
+				Expect(getStatusAs(ApiUsername, ApiPassword[:len(ApiPassword)-1])).To(Equal(http.StatusUnauthorized))
+				Expect(getStatusAs(ApiUsername+"x", ApiPassword)).To(Equal(http.StatusUnauthorized))
+				Expect(getStatusAs(ApiUsername, "")).To(Equal(http.StatusUnauthorized))
+			})
+		})
+
+		It("Calls Check on the reqHealthchecker at the root endpoint", func() {
+			req := createReq("", "GET")
+			resp, err := http.DefaultClient.Do(req)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(resp.StatusCode).To(Equal(http.StatusOK))
+			responseBody, err := ioutil.ReadAll(resp.Body)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(responseBody).To(ContainSubstring(ExpectedHealthCheckStatus))
+			Expect(reqhealthchecker.CheckReqCallCount()).To(Equal(1))
+		})
+
+		It("Calls Check on the reqHealthchecker at /galera_status", func() {
+			req := createReq("galera_status", "GET")
+			resp, err := http.DefaultClient.Do(req)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(resp.StatusCode).To(Equal(http.StatusOK))
+			responseBody, err := ioutil.ReadAll(resp.Body)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(responseBody).To(ContainSubstring(ExpectedHealthCheckStatus))
+			Expect(reqhealthchecker.CheckReqCallCount()).To(Equal(1))
+		})
+
+		It("returns 503 at / when the database is unreachable", func() {
+			reqhealthchecker.CheckReqReturns("", healthcheck.UnreachableError{Err: errors.New("Cannot get status from galera")})
+
+			resp, err := http.DefaultClient.Do(createReq("", "GET"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(resp.StatusCode).To(Equal(http.StatusServiceUnavailable))
+			responseBody, err := ioutil.ReadAll(resp.Body)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(responseBody)).To(Equal("Cannot get status from galera"))
+		})
+
+		Describe("unhealthy nodes", func() {
+			var get = func(endpoint string) (int, string) {
+				resp, err := http.DefaultClient.Do(createReq(endpoint, "GET"))
+				Expect(err).ToNot(HaveOccurred())
+				body, err := ioutil.ReadAll(resp.Body)
+				Expect(err).ToNot(HaveOccurred())
+				return resp.StatusCode, string(body)
+			}
+
+			It("returns 200 for a synced node", func() {
+				status, body := get("")
+				Expect(status).To(Equal(http.StatusOK))
+				Expect(body).To(Equal(ExpectedHealthCheckStatus))
+			})
+
+			It("returns 503 with the node's state for a node that is not synced", func() {
+				reqhealthchecker.CheckReqReturns("", healthcheck.UnhealthyError{Err: errors.New("joining")})
+
+				for _, endpoint := range []string{"", "galera_status"} {
+					status, body := get(endpoint)
+					Expect(status).To(Equal(http.StatusServiceUnavailable), endpoint)
+					Expect(body).To(Equal("joining"), endpoint)
+				}
+			})
+
+			Context("when LegacyHealthStatus is set", func() {
+				BeforeEach(func() {
+					testConfig.LegacyHealthStatus = true
+				})
+
+				It("returns 200 with the node's state for a node that is not synced", func() {
+					reqhealthchecker.CheckReqReturns("", healthcheck.UnhealthyError{Err: errors.New("joining")})
+
+					for _, endpoint := range []string{"", "galera_status"} {
+						status, body := get(endpoint)
+						Expect(status).To(Equal(http.StatusOK), endpoint)
+						Expect(body).To(Equal("joining"), endpoint)
+					}
+				})
+
+				It("still reports an unreachable database as unavailable", func() {
+					reqhealthchecker.CheckReqReturns("", healthcheck.UnreachableError{Err: errors.New("Cannot get status from galera")})
+
+					status, _ := get("")
+					Expect(status).To(Equal(http.StatusServiceUnavailable))
+				})
+			})
+		})
+
+		It("returns 500 at / when the check fails for any other reason", func() {
+			reqhealthchecker.CheckReqReturns("", errors.New("Unrecognized state: 7"))
+
+			resp, err := http.DefaultClient.Do(createReq("", "GET"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(resp.StatusCode).To(Equal(http.StatusInternalServerError))
+		})
+
+		Describe("/ready", func() {
+			BeforeEach(func() {
+				testConfig.ReadyPollInterval = 10 * time.Millisecond
+			})
+
+			var getReady = func(query string) (int, string) {
+				resp, err := http.DefaultClient.Do(createReq("ready"+query, "GET"))
+				Expect(err).ToNot(HaveOccurred())
+				body, err := ioutil.ReadAll(resp.Body)
+				Expect(err).ToNot(HaveOccurred())
+				return resp.StatusCode, string(body)
+			}
+
+			It("returns 200 immediately for a synced node", func() {
+				status, body := getReady("?timeout=30s")
+
+				Expect(status).To(Equal(http.StatusOK))
+				Expect(body).To(Equal(ExpectedHealthCheckStatus))
+				Expect(healthchecker.CheckCallCount()).To(Equal(1))
+			})
+
+			It("waits for the node to become synced", func() {
+				healthchecker.CheckReturnsOnCall(0, "", errors.New("joining"))
+				healthchecker.CheckReturnsOnCall(1, "", errors.New("joined"))
+				healthchecker.CheckReturnsOnCall(2, ExpectedHealthCheckStatus, nil)
+
+				status, body := getReady("?timeout=5s")
+
+				Expect(status).To(Equal(http.StatusOK))
+				Expect(body).To(Equal(ExpectedHealthCheckStatus))
+				Expect(healthchecker.CheckCallCount()).To(Equal(3))
+			})
+
+			It("returns 503 with the last state when the node never becomes synced", func() {
+				healthchecker.CheckReturns("", errors.New("joining"))
+
+				start := time.Now()
+				status, body := getReady("?timeout=100ms")
+
+				Expect(status).To(Equal(http.StatusServiceUnavailable))
+				Expect(body).To(Equal("joining"))
+				Expect(time.Since(start)).To(BeNumerically("<", 1*time.Second))
+				Expect(healthchecker.CheckCallCount()).To(BeNumerically(">", 1))
+			})
+
+			It("rejects an invalid timeout", func() {
+				status, _ := getReady("?timeout=soon")
+				Expect(status).To(Equal(http.StatusBadRequest))
+			})
+
+			Context("when MaxReadyTimeout is set", func() {
+				BeforeEach(func() {
+					testConfig.MaxReadyTimeout = time.Minute
+				})
+
+				It("accepts a timeout up to the maximum", func() {
+					status, _ := getReady("?timeout=1m")
+					Expect(status).To(Equal(http.StatusOK))
+				})
+
+				It("rejects a longer timeout without checking the node", func() {
+					status, body := getReady("?timeout=2h")
+
+					Expect(status).To(Equal(http.StatusBadRequest))
+					Expect(body).To(ContainSubstring("timeout 2h0m0s exceeds the maximum of 1m0s"))
+					Expect(healthchecker.CheckCallCount()).To(Equal(0))
+				})
+			})
+
+			It("reports a draining node as unavailable", func() {
+				req := createReq("drain", "POST")
+				req.SetBasicAuth(ApiUsername, ApiPassword)
+				resp, err := http.DefaultClient.Do(req)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(resp.StatusCode).To(Equal(http.StatusOK))
+
+				status, body := getReady("?timeout=50ms")
+
+				Expect(status).To(Equal(http.StatusServiceUnavailable))
+				Expect(body).To(Equal("draining"))
+				Expect(healthchecker.CheckCallCount()).To(Equal(0))
+			})
+		})
+
+		Describe("/db_ping", func() {
+			BeforeEach(func() {
+				reqhealthchecker.CheckReqReturns("", errors.New("joining"))
+			})
+
+			It("returns 200 when the database answers a ping", func() {
+				resp, err := http.DefaultClient.Do(createReq("db_ping", "GET"))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(resp.StatusCode).To(Equal(http.StatusOK))
+				Expect(healthchecker.PingDBCallCount()).To(Equal(1))
+				Expect(reqhealthchecker.CheckReqCallCount()).To(Equal(0))
+			})
+
+			It("returns 503 with the error when the ping fails", func() {
+				healthchecker.PingDBReturns(errors.New("connection refused"))
+
+				resp, err := http.DefaultClient.Do(createReq("db_ping", "GET"))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(resp.StatusCode).To(Equal(http.StatusServiceUnavailable))
+				body, err := ioutil.ReadAll(resp.Body)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(string(body)).To(Equal("connection refused"))
+			})
+		})
+
+		Describe("/live", func() {
+			BeforeEach(func() {
+				reqhealthchecker.CheckReqReturns("", errors.New("joining"))
+			})
+
+			It("returns 200 while the database connection is up even if the node is not synced", func() {
+				resp, err := http.DefaultClient.Do(createReq("live", "GET"))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(resp.StatusCode).To(Equal(http.StatusOK))
+
+				resp, err = http.DefaultClient.Do(createReq("", "GET"))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(resp.StatusCode).NotTo(Equal(http.StatusOK))
+			})
+
+			It("returns 503 when the database connection is down", func() {
+				healthchecker.PingReturns(errors.New("connection refused"))
+
+				resp, err := http.DefaultClient.Do(createReq("live", "GET"))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(resp.StatusCode).To(Equal(http.StatusServiceUnavailable))
+			})
+
+			Context("when a liveness timeout is configured", func() {
+				BeforeEach(func() {
+					testConfig.LivenessTimeout = 50 * time.Millisecond
+				})
+
+				It("bounds the database ping with the timeout", func() {
+					healthchecker.PingStub = func(ctx context.Context) error {
+						<-ctx.Done()
+						return ctx.Err()
+					}
+
+					resp, err := http.DefaultClient.Do(createReq("live", "GET"))
+					Expect(err).ToNot(HaveOccurred())
+					Expect(resp.StatusCode).To(Equal(http.StatusServiceUnavailable))
+				})
+			})
+		})
+
+		It("returns the build info at /version", func() {
+			req := createReq("version", "GET")
+			resp, err := http.DefaultClient.Do(req)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(resp.StatusCode).To(Equal(http.StatusOK))
+			Expect(resp.Header.Get("Content-Type")).To(Equal("application/json"))
+			responseBody, err := ioutil.ReadAll(resp.Body)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(responseBody).To(MatchJSON(`{"sha":"fake-sha","version":"1.2.3","built_at":"2020-01-01T00:00:00Z"}`))
+		})
+
+		Describe("/metrics", func() {
+			It("is not served when no metrics registry is provided", func() {
+				req := createReq("metrics", "GET")
+				resp, err := http.DefaultClient.Do(req)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(resp.StatusCode).To(Equal(http.StatusNotFound))
+			})
+
+			Context("when a metrics registry is provided", func() {
+				BeforeEach(func() {
+					metricsRegistry = metrics.NewRegistry()
+				})
+
+				It("counts requests and health checks at the root endpoint", func() {
+					for i := 0; i < 2; i++ {
+						resp, err := http.DefaultClient.Do(createReq("", "GET"))
+						Expect(err).ToNot(HaveOccurred())
+						Expect(resp.StatusCode).To(Equal(http.StatusOK))
+					}
+
+					Expect(metricsRegistry.Requests("root")).To(Equal(uint64(2)))
+					Expect(metricsRegistry.CheckCount()).To(Equal(uint64(2)))
+				})
+
+				It("exposes the metrics without authentication", func() {
+					resp, err := http.DefaultClient.Do(createReq("", "GET"))
+					Expect(err).ToNot(HaveOccurred())
+					Expect(resp.StatusCode).To(Equal(http.StatusOK))
+
+					resp, err = http.DefaultClient.Do(createReq("metrics", "GET"))
+					Expect(err).ToNot(HaveOccurred())
+					Expect(resp.StatusCode).To(Equal(http.StatusOK))
+
+					responseBody, err := ioutil.ReadAll(resp.Body)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(string(responseBody)).To(ContainSubstring(`galera_healthcheck_requests_total{endpoint="root"} 1`))
+					Expect(string(responseBody)).To(ContainSubstring("galera_healthcheck_check_duration_seconds_count 1"))
+				})
+
+			})
+		})
+
+		Describe("/metrics/wsrep", func() {
+			It("requires authentication, as /wsrep_status does", func() {
+				resp, err := http.DefaultClient.Do(createReq("metrics/wsrep", "GET"))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(resp.StatusCode).To(Equal(http.StatusUnauthorized))
+				Expect(healthchecker.WsrepMetricsCallCount()).To(BeZero())
+			})
+		})
+
+		Describe("/api/v1/status", func() {
+			It("Calls State on the stateSnapshotter", func() {
+				req := createReq("api/v1/status", "GET")
+				resp, err := http.DefaultClient.Do(req)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(resp.StatusCode).To(Equal(http.StatusOK))
+				Expect(stateSnapshotter.StateCallCount()).To(Equal(1))
+			})
+
+			Context("when getting the state succeeds", func() {
+				var (
+					returnedState domain.DBState
+				)
+
+				BeforeEach(func() {
+					returnedState = domain.DBState{
+						WsrepLocalIndex: 1,
+						WsrepLocalState: domain.Synced,
+					}
+
+					stateSnapshotter.StateReturns(returnedState, nil)
+				})
+
+				It("has the required fields", func() {
+					req := createReq("api/v1/status", "GET")
+					resp, err := http.DefaultClient.Do(req)
+					Expect(err).ToNot(HaveOccurred())
+
+					Expect(resp.StatusCode).To(Equal(http.StatusOK))
+
+					var state struct {
+						WsrepLocalIndex        uint   `json:"wsrep_local_index"`
+						WsrepLocalState        uint   `json:"wsrep_local_state"`
+						WsrepLocalStateComment string `json:"wsrep_local_state_comment"`
+					}
+
+					json.NewDecoder(resp.Body).Decode(&state)
+
+					Expect(state.WsrepLocalIndex).To(Equal(returnedState.WsrepLocalIndex))
+					Expect(state.WsrepLocalState).To(Equal(uint(returnedState.WsrepLocalState)))
+					Expect(state.WsrepLocalStateComment).To(Equal(string(returnedState.WsrepLocalState.Comment())))
+				})
+			})
+
+			Context("when getting the state fails", func() {
+				BeforeEach(func() {
+					stateSnapshotter.StateReturns(domain.DBState{}, errors.New("possibly not a galera cluster"))
+				})
+
+				It("500s", func() {
+					req := createReq("api/v1/status", "GET")
+					resp, err := http.DefaultClient.Do(req)
+					Expect(err).ToNot(HaveOccurred())
+
+					Expect(resp.StatusCode).To(Equal(http.StatusInternalServerError))
+				})
+			})
+		})
+	})
+
+	Describe("split public and admin routers", func() {
+		var publicServer, adminServer *httptest.Server
+
+		var do = func(server *httptest.Server, method, endpoint string) *http.Response {
+			req, err := http.NewRequest(method, server.URL+"/"+endpoint, nil)
+			Expect(err).ToNot(HaveOccurred())
+			req.SetBasicAuth(ApiUsername, ApiPassword)
+
+			resp, err := http.DefaultClient.Do(req)
+			Expect(err).ToNot(HaveOccurred())
+			return resp
+		}
+
+		JustBeforeEach(func() {
+			public, admin, err := api.NewSplitRouters(
+				testLogger,
+				testConfig,
+				monitClient,
+				sequenceNumber,
+				reqhealthchecker,
+				healthchecker,
+				stateSnapshotter,
+				buildInfo,
+				metricsRegistry,
+			)
+			Expect(err).ToNot(HaveOccurred())
+
+			publicServer = httptest.NewServer(public)
+			adminServer = httptest.NewServer(admin)
+		})
+
+		AfterEach(func() {
+			publicServer.Close()
+			adminServer.Close()
+		})
+
+		It("serves the health endpoints only on the public router", func() {
+			Expect(do(publicServer, "GET", "").StatusCode).To(Equal(http.StatusOK))
+			Expect(do(publicServer, "GET", "galera_status").StatusCode).To(Equal(http.StatusOK))
+			Expect(do(publicServer, "GET", "version").StatusCode).To(Equal(http.StatusOK))
+
+			Expect(do(adminServer, "GET", "").StatusCode).To(Equal(http.StatusNotFound))
+		})
+
+		It("serves /stop_mysql only on the admin router", func() {
+			Expect(do(publicServer, "POST", "stop_mysql").StatusCode).To(Equal(http.StatusNotFound))
+			Expect(monitClient.StopServiceCallCount()).To(Equal(0))
+
+			Expect(do(adminServer, "POST", "stop_mysql").StatusCode).To(Equal(http.StatusOK))
+			Expect(monitClient.StopServiceCallCount()).To(Equal(1))
+		})
+
+		It("requires authentication on the admin router", func() {
+			resp, err := http.Post(adminServer.URL+"/stop_mysql", "", nil)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(resp.StatusCode).To(Equal(http.StatusUnauthorized))
+			Expect(monitClient.StopServiceCallCount()).To(Equal(0))
+		})
+
+		It("takes the node out of rotation on the public router when drained on the admin router", func() {
+			Expect(do(adminServer, "POST", "drain").StatusCode).To(Equal(http.StatusOK))
+			Expect(do(publicServer, "GET", "").StatusCode).To(Equal(http.StatusServiceUnavailable))
 		})
 	})
 })
@@ -2,9 +2,11 @@
 package apifakes
 
 import (
+	"context"
 	"sync"
 
 	"github.com/cloudfoundry-incubator/galera-healthcheck/api"
+	"github.com/cloudfoundry-incubator/galera-healthcheck/healthcheck"
 )
 
 type FakeHealthChecker struct {
@@ -20,6 +22,117 @@ type FakeHealthChecker struct {
 		result1 string
 		result2 error
 	}
+	CheckStatusStub        func() (healthcheck.CheckStatus, error)
+	checkStatusMutex       sync.RWMutex
+	checkStatusArgsForCall []struct {
+	}
+	checkStatusReturns struct {
+		result1 healthcheck.CheckStatus
+		result2 error
+	}
+	checkStatusReturnsOnCall map[int]struct {
+		result1 healthcheck.CheckStatus
+		result2 error
+	}
+	ClientConnectionsStub        func() (int, error)
+	clientConnectionsMutex       sync.RWMutex
+	clientConnectionsArgsForCall []struct {
+	}
+	clientConnectionsReturns struct {
+		result1 int
+		result2 error
+	}
+	clientConnectionsReturnsOnCall map[int]struct {
+		result1 int
+		result2 error
+	}
+	ClusterSizeStub        func() (int, error)
+	clusterSizeMutex       sync.RWMutex
+	clusterSizeArgsForCall []struct {
+	}
+	clusterSizeReturns struct {
+		result1 int
+		result2 error
+	}
+	clusterSizeReturnsOnCall map[int]struct {
+		result1 int
+		result2 error
+	}
+	PingStub        func(context.Context) error
+	pingMutex       sync.RWMutex
+	pingArgsForCall []struct {
+		arg1 context.Context
+	}
+	pingReturns struct {
+		result1 error
+	}
+	pingReturnsOnCall map[int]struct {
+		result1 error
+	}
+	PingDBStub        func() error
+	pingDBMutex       sync.RWMutex
+	pingDBArgsForCall []struct {
+	}
+	pingDBReturns struct {
+		result1 error
+	}
+	pingDBReturnsOnCall map[int]struct {
+		result1 error
+	}
+	ResetCacheStub        func()
+	resetCacheMutex       sync.RWMutex
+	resetCacheArgsForCall []struct {
+	}
+	StatusLikeStub        func(string) (map[string]string, error)
+	statusLikeMutex       sync.RWMutex
+	statusLikeArgsForCall []struct {
+		arg1 string
+	}
+	statusLikeReturns struct {
+		result1 map[string]string
+		result2 error
+	}
+	statusLikeReturnsOnCall map[int]struct {
+		result1 map[string]string
+		result2 error
+	}
+	WsrepMetricsStub        func() (map[string]float64, error)
+	wsrepMetricsMutex       sync.RWMutex
+	wsrepMetricsArgsForCall []struct {
+	}
+	wsrepMetricsReturns struct {
+		result1 map[string]float64
+		result2 error
+	}
+	wsrepMetricsReturnsOnCall map[int]struct {
+		result1 map[string]float64
+		result2 error
+	}
+	WsrepStatusStub        func() (map[string]string, error)
+	wsrepStatusMutex       sync.RWMutex
+	wsrepStatusArgsForCall []struct {
+	}
+	wsrepStatusReturns struct {
+		result1 map[string]string
+		result2 error
+	}
+	wsrepStatusReturnsOnCall map[int]struct {
+		result1 map[string]string
+		result2 error
+	}
+	WsrepVariableStub        func(string) (string, error)
+	wsrepVariableMutex       sync.RWMutex
+	wsrepVariableArgsForCall []struct {
+		arg1 string
+	}
+	wsrepVariableReturns struct {
+		result1 string
+		result2 error
+	}
+	wsrepVariableReturnsOnCall map[int]struct {
+		result1 string
+		result2 error
+	}
 	invocations      map[string][][]interface{}
 	invocationsMutex sync.RWMutex
 }
@@ -79,11 +192,567 @@ func (fake *FakeHealthChecker) CheckReturnsOnCall(i int, result1 string, result2
 	}{result1, result2}
 }
 
+func (fake *FakeHealthChecker) CheckStatus() (healthcheck.CheckStatus, error) {
+	fake.checkStatusMutex.Lock()
+	ret, specificReturn := fake.checkStatusReturnsOnCall[len(fake.checkStatusArgsForCall)]
+	fake.checkStatusArgsForCall = append(fake.checkStatusArgsForCall, struct {
+	}{})
+	fake.recordInvocation("CheckStatus", []interface{}{})
+	fake.checkStatusMutex.Unlock()
+	if fake.CheckStatusStub != nil {
+		return fake.CheckStatusStub()
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.checkStatusReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeHealthChecker) CheckStatusCallCount() int {
+	fake.checkStatusMutex.RLock()
+	defer fake.checkStatusMutex.RUnlock()
+	return len(fake.checkStatusArgsForCall)
+}
+
+func (fake *FakeHealthChecker) CheckStatusCalls(stub func() (healthcheck.CheckStatus, error)) {
+	fake.checkStatusMutex.Lock()
+	defer fake.checkStatusMutex.Unlock()
+	fake.CheckStatusStub = stub
+}
+
+func (fake *FakeHealthChecker) CheckStatusReturns(result1 healthcheck.CheckStatus, result2 error) {
+	fake.checkStatusMutex.Lock()
+	defer fake.checkStatusMutex.Unlock()
+	fake.CheckStatusStub = nil
+	fake.checkStatusReturns = struct {
+		result1 healthcheck.CheckStatus
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeHealthChecker) CheckStatusReturnsOnCall(i int, result1 healthcheck.CheckStatus, result2 error) {
+	fake.checkStatusMutex.Lock()
+	defer fake.checkStatusMutex.Unlock()
+	fake.CheckStatusStub = nil
+	if fake.checkStatusReturnsOnCall == nil {
+		fake.checkStatusReturnsOnCall = make(map[int]struct {
+			result1 healthcheck.CheckStatus
+			result2 error
+		})
+	}
+	fake.checkStatusReturnsOnCall[i] = struct {
+		result1 healthcheck.CheckStatus
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeHealthChecker) ClientConnections() (int, error) {
+	fake.clientConnectionsMutex.Lock()
+	ret, specificReturn := fake.clientConnectionsReturnsOnCall[len(fake.clientConnectionsArgsForCall)]
+	fake.clientConnectionsArgsForCall = append(fake.clientConnectionsArgsForCall, struct {
+	}{})
+	fake.recordInvocation("ClientConnections", []interface{}{})
+	fake.clientConnectionsMutex.Unlock()
+	if fake.ClientConnectionsStub != nil {
+		return fake.ClientConnectionsStub()
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.clientConnectionsReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeHealthChecker) ClientConnectionsCallCount() int {
+	fake.clientConnectionsMutex.RLock()
+	defer fake.clientConnectionsMutex.RUnlock()
+	return len(fake.clientConnectionsArgsForCall)
+}
+
+func (fake *FakeHealthChecker) ClientConnectionsCalls(stub func() (int, error)) {
+	fake.clientConnectionsMutex.Lock()
+	defer fake.clientConnectionsMutex.Unlock()
+	fake.ClientConnectionsStub = stub
+}
+
+func (fake *FakeHealthChecker) ClientConnectionsReturns(result1 int, result2 error) {
+	fake.clientConnectionsMutex.Lock()
+	defer fake.clientConnectionsMutex.Unlock()
+	fake.ClientConnectionsStub = nil
+	fake.clientConnectionsReturns = struct {
+		result1 int
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeHealthChecker) ClientConnectionsReturnsOnCall(i int, result1 int, result2 error) {
+	fake.clientConnectionsMutex.Lock()
+	defer fake.clientConnectionsMutex.Unlock()
+	fake.ClientConnectionsStub = nil
+	if fake.clientConnectionsReturnsOnCall == nil {
+		fake.clientConnectionsReturnsOnCall = make(map[int]struct {
+			result1 int
+			result2 error
+		})
+	}
+	fake.clientConnectionsReturnsOnCall[i] = struct {
+		result1 int
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeHealthChecker) ClusterSize() (int, error) {
+	fake.clusterSizeMutex.Lock()
+	ret, specificReturn := fake.clusterSizeReturnsOnCall[len(fake.clusterSizeArgsForCall)]
+	fake.clusterSizeArgsForCall = append(fake.clusterSizeArgsForCall, struct {
+	}{})
+	fake.recordInvocation("ClusterSize", []interface{}{})
+	fake.clusterSizeMutex.Unlock()
+	if fake.ClusterSizeStub != nil {
+		return fake.ClusterSizeStub()
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.clusterSizeReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeHealthChecker) ClusterSizeCallCount() int {
+	fake.clusterSizeMutex.RLock()
+	defer fake.clusterSizeMutex.RUnlock()
+	return len(fake.clusterSizeArgsForCall)
+}
+
+func (fake *FakeHealthChecker) ClusterSizeCalls(stub func() (int, error)) {
+	fake.clusterSizeMutex.Lock()
+	defer fake.clusterSizeMutex.Unlock()
+	fake.ClusterSizeStub = stub
+}
+
+func (fake *FakeHealthChecker) ClusterSizeReturns(result1 int, result2 error) {
+	fake.clusterSizeMutex.Lock()
+	defer fake.clusterSizeMutex.Unlock()
+	fake.ClusterSizeStub = nil
+	fake.clusterSizeReturns = struct {
+		result1 int
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeHealthChecker) ClusterSizeReturnsOnCall(i int, result1 int, result2 error) {
+	fake.clusterSizeMutex.Lock()
+	defer fake.clusterSizeMutex.Unlock()
+	fake.ClusterSizeStub = nil
+	if fake.clusterSizeReturnsOnCall == nil {
+		fake.clusterSizeReturnsOnCall = make(map[int]struct {
+			result1 int
+			result2 error
+		})
+	}
+	fake.clusterSizeReturnsOnCall[i] = struct {
+		result1 int
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeHealthChecker) Ping(arg1 context.Context) error {
+	fake.pingMutex.Lock()
+	ret, specificReturn := fake.pingReturnsOnCall[len(fake.pingArgsForCall)]
+	fake.pingArgsForCall = append(fake.pingArgsForCall, struct {
+		arg1 context.Context
+	}{arg1})
+	fake.recordInvocation("Ping", []interface{}{arg1})
+	fake.pingMutex.Unlock()
+	if fake.PingStub != nil {
+		return fake.PingStub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	fakeReturns := fake.pingReturns
+	return fakeReturns.result1
+}
+
+func (fake *FakeHealthChecker) PingCallCount() int {
+	fake.pingMutex.RLock()
+	defer fake.pingMutex.RUnlock()
+	return len(fake.pingArgsForCall)
+}
+
+func (fake *FakeHealthChecker) PingCalls(stub func(context.Context) error) {
+	fake.pingMutex.Lock()
+	defer fake.pingMutex.Unlock()
+	fake.PingStub = stub
+}
+
+func (fake *FakeHealthChecker) PingArgsForCall(i int) context.Context {
+	fake.pingMutex.RLock()
+	defer fake.pingMutex.RUnlock()
+	argsForCall := fake.pingArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeHealthChecker) PingReturns(result1 error) {
+	fake.pingMutex.Lock()
+	defer fake.pingMutex.Unlock()
+	fake.PingStub = nil
+	fake.pingReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeHealthChecker) PingReturnsOnCall(i int, result1 error) {
+	fake.pingMutex.Lock()
+	defer fake.pingMutex.Unlock()
+	fake.PingStub = nil
+	if fake.pingReturnsOnCall == nil {
+		fake.pingReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.pingReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeHealthChecker) PingDB() error {
+	fake.pingDBMutex.Lock()
+	ret, specificReturn := fake.pingDBReturnsOnCall[len(fake.pingDBArgsForCall)]
+	fake.pingDBArgsForCall = append(fake.pingDBArgsForCall, struct {
+	}{})
+	fake.recordInvocation("PingDB", []interface{}{})
+	fake.pingDBMutex.Unlock()
+	if fake.PingDBStub != nil {
+		return fake.PingDBStub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	fakeReturns := fake.pingDBReturns
+	return fakeReturns.result1
+}
+
+func (fake *FakeHealthChecker) PingDBCallCount() int {
+	fake.pingDBMutex.RLock()
+	defer fake.pingDBMutex.RUnlock()
+	return len(fake.pingDBArgsForCall)
+}
+
+func (fake *FakeHealthChecker) PingDBCalls(stub func() error) {
+	fake.pingDBMutex.Lock()
+	defer fake.pingDBMutex.Unlock()
+	fake.PingDBStub = stub
+}
+
+func (fake *FakeHealthChecker) PingDBReturns(result1 error) {
+	fake.pingDBMutex.Lock()
+	defer fake.pingDBMutex.Unlock()
+	fake.PingDBStub = nil
+	fake.pingDBReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeHealthChecker) PingDBReturnsOnCall(i int, result1 error) {
+	fake.pingDBMutex.Lock()
+	defer fake.pingDBMutex.Unlock()
+	fake.PingDBStub = nil
+	if fake.pingDBReturnsOnCall == nil {
+		fake.pingDBReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.pingDBReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeHealthChecker) ResetCache() {
+	fake.resetCacheMutex.Lock()
+	fake.resetCacheArgsForCall = append(fake.resetCacheArgsForCall, struct {
+	}{})
+	fake.recordInvocation("ResetCache", []interface{}{})
+	fake.resetCacheMutex.Unlock()
+	if fake.ResetCacheStub != nil {
+		fake.ResetCacheStub()
+	}
+}
+
+func (fake *FakeHealthChecker) ResetCacheCallCount() int {
+	fake.resetCacheMutex.RLock()
+	defer fake.resetCacheMutex.RUnlock()
+	return len(fake.resetCacheArgsForCall)
+}
+
+func (fake *FakeHealthChecker) ResetCacheCalls(stub func()) {
+	fake.resetCacheMutex.Lock()
+	defer fake.resetCacheMutex.Unlock()
+	fake.ResetCacheStub = stub
+}
+
+func (fake *FakeHealthChecker) StatusLike(arg1 string) (map[string]string, error) {
+	fake.statusLikeMutex.Lock()
+	ret, specificReturn := fake.statusLikeReturnsOnCall[len(fake.statusLikeArgsForCall)]
+	fake.statusLikeArgsForCall = append(fake.statusLikeArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	fake.recordInvocation("StatusLike", []interface{}{arg1})
+	fake.statusLikeMutex.Unlock()
+	if fake.StatusLikeStub != nil {
+		return fake.StatusLikeStub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.statusLikeReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeHealthChecker) StatusLikeCallCount() int {
+	fake.statusLikeMutex.RLock()
+	defer fake.statusLikeMutex.RUnlock()
+	return len(fake.statusLikeArgsForCall)
+}
+
+func (fake *FakeHealthChecker) StatusLikeCalls(stub func(string) (map[string]string, error)) {
+	fake.statusLikeMutex.Lock()
+	defer fake.statusLikeMutex.Unlock()
+	fake.StatusLikeStub = stub
+}
+
+func (fake *FakeHealthChecker) StatusLikeArgsForCall(i int) string {
+	fake.statusLikeMutex.RLock()
+	defer fake.statusLikeMutex.RUnlock()
+	argsForCall := fake.statusLikeArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeHealthChecker) StatusLikeReturns(result1 map[string]string, result2 error) {
+	fake.statusLikeMutex.Lock()
+	defer fake.statusLikeMutex.Unlock()
+	fake.StatusLikeStub = nil
+	fake.statusLikeReturns = struct {
+		result1 map[string]string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeHealthChecker) StatusLikeReturnsOnCall(i int, result1 map[string]string, result2 error) {
+	fake.statusLikeMutex.Lock()
+	defer fake.statusLikeMutex.Unlock()
+	fake.StatusLikeStub = nil
+	if fake.statusLikeReturnsOnCall == nil {
+		fake.statusLikeReturnsOnCall = make(map[int]struct {
+			result1 map[string]string
+			result2 error
+		})
+	}
+	fake.statusLikeReturnsOnCall[i] = struct {
+		result1 map[string]string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeHealthChecker) WsrepMetrics() (map[string]float64, error) {
+	fake.wsrepMetricsMutex.Lock()
+	ret, specificReturn := fake.wsrepMetricsReturnsOnCall[len(fake.wsrepMetricsArgsForCall)]
+	fake.wsrepMetricsArgsForCall = append(fake.wsrepMetricsArgsForCall, struct {
+	}{})
+	fake.recordInvocation("WsrepMetrics", []interface{}{})
+	fake.wsrepMetricsMutex.Unlock()
+	if fake.WsrepMetricsStub != nil {
+		return fake.WsrepMetricsStub()
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.wsrepMetricsReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeHealthChecker) WsrepMetricsCallCount() int {
+	fake.wsrepMetricsMutex.RLock()
+	defer fake.wsrepMetricsMutex.RUnlock()
+	return len(fake.wsrepMetricsArgsForCall)
+}
+
+func (fake *FakeHealthChecker) WsrepMetricsCalls(stub func() (map[string]float64, error)) {
+	fake.wsrepMetricsMutex.Lock()
+	defer fake.wsrepMetricsMutex.Unlock()
+	fake.WsrepMetricsStub = stub
+}
+
+func (fake *FakeHealthChecker) WsrepMetricsReturns(result1 map[string]float64, result2 error) {
+	fake.wsrepMetricsMutex.Lock()
+	defer fake.wsrepMetricsMutex.Unlock()
+	fake.WsrepMetricsStub = nil
+	fake.wsrepMetricsReturns = struct {
+		result1 map[string]float64
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeHealthChecker) WsrepMetricsReturnsOnCall(i int, result1 map[string]float64, result2 error) {
+	fake.wsrepMetricsMutex.Lock()
+	defer fake.wsrepMetricsMutex.Unlock()
+	fake.WsrepMetricsStub = nil
+	if fake.wsrepMetricsReturnsOnCall == nil {
+		fake.wsrepMetricsReturnsOnCall = make(map[int]struct {
+			result1 map[string]float64
+			result2 error
+		})
+	}
+	fake.wsrepMetricsReturnsOnCall[i] = struct {
+		result1 map[string]float64
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeHealthChecker) WsrepStatus() (map[string]string, error) {
+	fake.wsrepStatusMutex.Lock()
+	ret, specificReturn := fake.wsrepStatusReturnsOnCall[len(fake.wsrepStatusArgsForCall)]
+	fake.wsrepStatusArgsForCall = append(fake.wsrepStatusArgsForCall, struct {
+	}{})
+	fake.recordInvocation("WsrepStatus", []interface{}{})
+	fake.wsrepStatusMutex.Unlock()
+	if fake.WsrepStatusStub != nil {
+		return fake.WsrepStatusStub()
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.wsrepStatusReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeHealthChecker) WsrepStatusCallCount() int {
+	fake.wsrepStatusMutex.RLock()
+	defer fake.wsrepStatusMutex.RUnlock()
+	return len(fake.wsrepStatusArgsForCall)
+}
+
+func (fake *FakeHealthChecker) WsrepStatusCalls(stub func() (map[string]string, error)) {
+	fake.wsrepStatusMutex.Lock()
+	defer fake.wsrepStatusMutex.Unlock()
+	fake.WsrepStatusStub = stub
+}
+
+func (fake *FakeHealthChecker) WsrepStatusReturns(result1 map[string]string, result2 error) {
+	fake.wsrepStatusMutex.Lock()
+	defer fake.wsrepStatusMutex.Unlock()
+	fake.WsrepStatusStub = nil
+	fake.wsrepStatusReturns = struct {
+		result1 map[string]string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeHealthChecker) WsrepStatusReturnsOnCall(i int, result1 map[string]string, result2 error) {
+	fake.wsrepStatusMutex.Lock()
+	defer fake.wsrepStatusMutex.Unlock()
+	fake.WsrepStatusStub = nil
+	if fake.wsrepStatusReturnsOnCall == nil {
+		fake.wsrepStatusReturnsOnCall = make(map[int]struct {
+			result1 map[string]string
+			result2 error
+		})
+	}
+	fake.wsrepStatusReturnsOnCall[i] = struct {
+		result1 map[string]string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeHealthChecker) WsrepVariable(arg1 string) (string, error) {
+	fake.wsrepVariableMutex.Lock()
+	ret, specificReturn := fake.wsrepVariableReturnsOnCall[len(fake.wsrepVariableArgsForCall)]
+	fake.wsrepVariableArgsForCall = append(fake.wsrepVariableArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	fake.recordInvocation("WsrepVariable", []interface{}{arg1})
+	fake.wsrepVariableMutex.Unlock()
+	if fake.WsrepVariableStub != nil {
+		return fake.WsrepVariableStub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.wsrepVariableReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeHealthChecker) WsrepVariableCallCount() int {
+	fake.wsrepVariableMutex.RLock()
+	defer fake.wsrepVariableMutex.RUnlock()
+	return len(fake.wsrepVariableArgsForCall)
+}
+
+func (fake *FakeHealthChecker) WsrepVariableCalls(stub func(string) (string, error)) {
+	fake.wsrepVariableMutex.Lock()
+	defer fake.wsrepVariableMutex.Unlock()
+	fake.WsrepVariableStub = stub
+}
+
+func (fake *FakeHealthChecker) WsrepVariableArgsForCall(i int) string {
+	fake.wsrepVariableMutex.RLock()
+	defer fake.wsrepVariableMutex.RUnlock()
+	argsForCall := fake.wsrepVariableArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeHealthChecker) WsrepVariableReturns(result1 string, result2 error) {
+	fake.wsrepVariableMutex.Lock()
+	defer fake.wsrepVariableMutex.Unlock()
+	fake.WsrepVariableStub = nil
+	fake.wsrepVariableReturns = struct {
+		result1 string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeHealthChecker) WsrepVariableReturnsOnCall(i int, result1 string, result2 error) {
+	fake.wsrepVariableMutex.Lock()
+	defer fake.wsrepVariableMutex.Unlock()
+	fake.WsrepVariableStub = nil
+	if fake.wsrepVariableReturnsOnCall == nil {
+		fake.wsrepVariableReturnsOnCall = make(map[int]struct {
+			result1 string
+			result2 error
+		})
+	}
+	fake.wsrepVariableReturnsOnCall[i] = struct {
+		result1 string
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *FakeHealthChecker) Invocations() map[string][][]interface{} {
 	fake.invocationsMutex.RLock()
 	defer fake.invocationsMutex.RUnlock()
 	fake.checkMutex.RLock()
 	defer fake.checkMutex.RUnlock()
+	fake.checkStatusMutex.RLock()
+	defer fake.checkStatusMutex.RUnlock()
+	fake.clientConnectionsMutex.RLock()
+	defer fake.clientConnectionsMutex.RUnlock()
+	fake.clusterSizeMutex.RLock()
+	defer fake.clusterSizeMutex.RUnlock()
+	fake.pingMutex.RLock()
+	defer fake.pingMutex.RUnlock()
+	fake.pingDBMutex.RLock()
+	defer fake.pingDBMutex.RUnlock()
+	fake.resetCacheMutex.RLock()
+	defer fake.resetCacheMutex.RUnlock()
+	fake.statusLikeMutex.RLock()
+	defer fake.statusLikeMutex.RUnlock()
+	fake.wsrepMetricsMutex.RLock()
+	defer fake.wsrepMetricsMutex.RUnlock()
+	fake.wsrepStatusMutex.RLock()
+	defer fake.wsrepStatusMutex.RUnlock()
+	fake.wsrepVariableMutex.RLock()
+	defer fake.wsrepVariableMutex.RUnlock()
 	copiedInvocations := map[string][][]interface{}{}
 	for key, value := range fake.invocations {
 		copiedInvocations[key] = value
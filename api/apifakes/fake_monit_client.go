@@ -6,9 +6,49 @@ import (
 	"sync"
 
 	"github.com/cloudfoundry-incubator/galera-healthcheck/api"
+	"github.com/cloudfoundry-incubator/galera-healthcheck/monit_client"
 )
 
 type FakeMonitClient struct {
+	GetProcessStatsStub        func(*http.Request) (monit_client.ProcessStats, error)
+	getProcessStatsMutex       sync.RWMutex
+	getProcessStatsArgsForCall []struct {
+		arg1 *http.Request
+	}
+	getProcessStatsReturns struct {
+		result1 monit_client.ProcessStats
+		result2 error
+	}
+	getProcessStatsReturnsOnCall map[int]struct {
+		result1 monit_client.ProcessStats
+		result2 error
+	}
+	GetServicesStub        func(*http.Request) ([]monit_client.ServiceSummary, error)
+	getServicesMutex       sync.RWMutex
+	getServicesArgsForCall []struct {
+		arg1 *http.Request
+	}
+	getServicesReturns struct {
+		result1 []monit_client.ServiceSummary
+		result2 error
+	}
+	getServicesReturnsOnCall map[int]struct {
+		result1 []monit_client.ServiceSummary
+		result2 error
+	}
+	GetStateStub        func(*http.Request) (*string, error)
+	getStateMutex       sync.RWMutex
+	getStateArgsForCall []struct {
+		arg1 *http.Request
+	}
+	getStateReturns struct {
+		result1 *string
+		result2 error
+	}
+	getStateReturnsOnCall map[int]struct {
+		result1 *string
+		result2 error
+	}
 	GetStatusStub        func(*http.Request) (string, error)
 	getStatusMutex       sync.RWMutex
 	getStatusArgsForCall []struct {
@@ -22,6 +62,32 @@ type FakeMonitClient struct {
 		result1 string
 		result2 error
 	}
+	MonitorServiceStub        func(*http.Request) (string, error)
+	monitorServiceMutex       sync.RWMutex
+	monitorServiceArgsForCall []struct {
+		arg1 *http.Request
+	}
+	monitorServiceReturns struct {
+		result1 string
+		result2 error
+	}
+	monitorServiceReturnsOnCall map[int]struct {
+		result1 string
+		result2 error
+	}
+	ReloadMonitStub        func(*http.Request) (string, error)
+	reloadMonitMutex       sync.RWMutex
+	reloadMonitArgsForCall []struct {
+		arg1 *http.Request
+	}
+	reloadMonitReturns struct {
+		result1 string
+		result2 error
+	}
+	reloadMonitReturnsOnCall map[int]struct {
+		result1 string
+		result2 error
+	}
 	StartServiceBootstrapStub        func(*http.Request) (string, error)
 	startServiceBootstrapMutex       sync.RWMutex
 	startServiceBootstrapArgsForCall []struct {
@@ -74,10 +140,212 @@ type FakeMonitClient struct {
 		result1 string
 		result2 error
 	}
+	UnmonitorServiceStub        func(*http.Request) (string, error)
+	unmonitorServiceMutex       sync.RWMutex
+	unmonitorServiceArgsForCall []struct {
+		arg1 *http.Request
+	}
+	unmonitorServiceReturns struct {
+		result1 string
+		result2 error
+	}
+	unmonitorServiceReturnsOnCall map[int]struct {
+		result1 string
+		result2 error
+	}
 	invocations      map[string][][]interface{}
 	invocationsMutex sync.RWMutex
 }
 
+func (fake *FakeMonitClient) GetProcessStats(arg1 *http.Request) (monit_client.ProcessStats, error) {
+	fake.getProcessStatsMutex.Lock()
+	ret, specificReturn := fake.getProcessStatsReturnsOnCall[len(fake.getProcessStatsArgsForCall)]
+	fake.getProcessStatsArgsForCall = append(fake.getProcessStatsArgsForCall, struct {
+		arg1 *http.Request
+	}{arg1})
+	fake.recordInvocation("GetProcessStats", []interface{}{arg1})
+	fake.getProcessStatsMutex.Unlock()
+	if fake.GetProcessStatsStub != nil {
+		return fake.GetProcessStatsStub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.getProcessStatsReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeMonitClient) GetProcessStatsCallCount() int {
+	fake.getProcessStatsMutex.RLock()
+	defer fake.getProcessStatsMutex.RUnlock()
+	return len(fake.getProcessStatsArgsForCall)
+}
+
+func (fake *FakeMonitClient) GetProcessStatsCalls(stub func(*http.Request) (monit_client.ProcessStats, error)) {
+	fake.getProcessStatsMutex.Lock()
+	defer fake.getProcessStatsMutex.Unlock()
+	fake.GetProcessStatsStub = stub
+}
+
+func (fake *FakeMonitClient) GetProcessStatsArgsForCall(i int) *http.Request {
+	fake.getProcessStatsMutex.RLock()
+	defer fake.getProcessStatsMutex.RUnlock()
+	argsForCall := fake.getProcessStatsArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeMonitClient) GetProcessStatsReturns(result1 monit_client.ProcessStats, result2 error) {
+	fake.getProcessStatsMutex.Lock()
+	defer fake.getProcessStatsMutex.Unlock()
+	fake.GetProcessStatsStub = nil
+	fake.getProcessStatsReturns = struct {
+		result1 monit_client.ProcessStats
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeMonitClient) GetProcessStatsReturnsOnCall(i int, result1 monit_client.ProcessStats, result2 error) {
+	fake.getProcessStatsMutex.Lock()
+	defer fake.getProcessStatsMutex.Unlock()
+	fake.GetProcessStatsStub = nil
+	if fake.getProcessStatsReturnsOnCall == nil {
+		fake.getProcessStatsReturnsOnCall = make(map[int]struct {
+			result1 monit_client.ProcessStats
+			result2 error
+		})
+	}
+	fake.getProcessStatsReturnsOnCall[i] = struct {
+		result1 monit_client.ProcessStats
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeMonitClient) GetServices(arg1 *http.Request) ([]monit_client.ServiceSummary, error) {
+	fake.getServicesMutex.Lock()
+	ret, specificReturn := fake.getServicesReturnsOnCall[len(fake.getServicesArgsForCall)]
+	fake.getServicesArgsForCall = append(fake.getServicesArgsForCall, struct {
+		arg1 *http.Request
+	}{arg1})
+	fake.recordInvocation("GetServices", []interface{}{arg1})
+	fake.getServicesMutex.Unlock()
+	if fake.GetServicesStub != nil {
+		return fake.GetServicesStub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.getServicesReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeMonitClient) GetServicesCallCount() int {
+	fake.getServicesMutex.RLock()
+	defer fake.getServicesMutex.RUnlock()
+	return len(fake.getServicesArgsForCall)
+}
+
+func (fake *FakeMonitClient) GetServicesCalls(stub func(*http.Request) ([]monit_client.ServiceSummary, error)) {
+	fake.getServicesMutex.Lock()
+	defer fake.getServicesMutex.Unlock()
+	fake.GetServicesStub = stub
+}
+
+func (fake *FakeMonitClient) GetServicesArgsForCall(i int) *http.Request {
+	fake.getServicesMutex.RLock()
+	defer fake.getServicesMutex.RUnlock()
+	argsForCall := fake.getServicesArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeMonitClient) GetServicesReturns(result1 []monit_client.ServiceSummary, result2 error) {
+	fake.getServicesMutex.Lock()
+	defer fake.getServicesMutex.Unlock()
+	fake.GetServicesStub = nil
+	fake.getServicesReturns = struct {
+		result1 []monit_client.ServiceSummary
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeMonitClient) GetServicesReturnsOnCall(i int, result1 []monit_client.ServiceSummary, result2 error) {
+	fake.getServicesMutex.Lock()
+	defer fake.getServicesMutex.Unlock()
+	fake.GetServicesStub = nil
+	if fake.getServicesReturnsOnCall == nil {
+		fake.getServicesReturnsOnCall = make(map[int]struct {
+			result1 []monit_client.ServiceSummary
+			result2 error
+		})
+	}
+	fake.getServicesReturnsOnCall[i] = struct {
+		result1 []monit_client.ServiceSummary
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeMonitClient) GetState(arg1 *http.Request) (*string, error) {
+	fake.getStateMutex.Lock()
+	ret, specificReturn := fake.getStateReturnsOnCall[len(fake.getStateArgsForCall)]
+	fake.getStateArgsForCall = append(fake.getStateArgsForCall, struct {
+		arg1 *http.Request
+	}{arg1})
+	fake.recordInvocation("GetState", []interface{}{arg1})
+	fake.getStateMutex.Unlock()
+	if fake.GetStateStub != nil {
+		return fake.GetStateStub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.getStateReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeMonitClient) GetStateCallCount() int {
+	fake.getStateMutex.RLock()
+	defer fake.getStateMutex.RUnlock()
+	return len(fake.getStateArgsForCall)
+}
+
+func (fake *FakeMonitClient) GetStateCalls(stub func(*http.Request) (*string, error)) {
+	fake.getStateMutex.Lock()
+	defer fake.getStateMutex.Unlock()
+	fake.GetStateStub = stub
+}
+
+func (fake *FakeMonitClient) GetStateArgsForCall(i int) *http.Request {
+	fake.getStateMutex.RLock()
+	defer fake.getStateMutex.RUnlock()
+	argsForCall := fake.getStateArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeMonitClient) GetStateReturns(result1 *string, result2 error) {
+	fake.getStateMutex.Lock()
+	defer fake.getStateMutex.Unlock()
+	fake.GetStateStub = nil
+	fake.getStateReturns = struct {
+		result1 *string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeMonitClient) GetStateReturnsOnCall(i int, result1 *string, result2 error) {
+	fake.getStateMutex.Lock()
+	defer fake.getStateMutex.Unlock()
+	fake.GetStateStub = nil
+	if fake.getStateReturnsOnCall == nil {
+		fake.getStateReturnsOnCall = make(map[int]struct {
+			result1 *string
+			result2 error
+		})
+	}
+	fake.getStateReturnsOnCall[i] = struct {
+		result1 *string
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *FakeMonitClient) GetStatus(arg1 *http.Request) (string, error) {
 	fake.getStatusMutex.Lock()
 	ret, specificReturn := fake.getStatusReturnsOnCall[len(fake.getStatusArgsForCall)]
@@ -141,6 +409,132 @@ func (fake *FakeMonitClient) GetStatusReturnsOnCall(i int, result1 string, resul
 	}{result1, result2}
 }
 
+func (fake *FakeMonitClient) MonitorService(arg1 *http.Request) (string, error) {
+	fake.monitorServiceMutex.Lock()
+	ret, specificReturn := fake.monitorServiceReturnsOnCall[len(fake.monitorServiceArgsForCall)]
+	fake.monitorServiceArgsForCall = append(fake.monitorServiceArgsForCall, struct {
+		arg1 *http.Request
+	}{arg1})
+	fake.recordInvocation("MonitorService", []interface{}{arg1})
+	fake.monitorServiceMutex.Unlock()
+	if fake.MonitorServiceStub != nil {
+		return fake.MonitorServiceStub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.monitorServiceReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeMonitClient) MonitorServiceCallCount() int {
+	fake.monitorServiceMutex.RLock()
+	defer fake.monitorServiceMutex.RUnlock()
+	return len(fake.monitorServiceArgsForCall)
+}
+
+func (fake *FakeMonitClient) MonitorServiceCalls(stub func(*http.Request) (string, error)) {
+	fake.monitorServiceMutex.Lock()
+	defer fake.monitorServiceMutex.Unlock()
+	fake.MonitorServiceStub = stub
+}
+
+func (fake *FakeMonitClient) MonitorServiceArgsForCall(i int) *http.Request {
+	fake.monitorServiceMutex.RLock()
+	defer fake.monitorServiceMutex.RUnlock()
+	argsForCall := fake.monitorServiceArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeMonitClient) MonitorServiceReturns(result1 string, result2 error) {
+	fake.monitorServiceMutex.Lock()
+	defer fake.monitorServiceMutex.Unlock()
+	fake.MonitorServiceStub = nil
+	fake.monitorServiceReturns = struct {
+		result1 string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeMonitClient) MonitorServiceReturnsOnCall(i int, result1 string, result2 error) {
+	fake.monitorServiceMutex.Lock()
+	defer fake.monitorServiceMutex.Unlock()
+	fake.MonitorServiceStub = nil
+	if fake.monitorServiceReturnsOnCall == nil {
+		fake.monitorServiceReturnsOnCall = make(map[int]struct {
+			result1 string
+			result2 error
+		})
+	}
+	fake.monitorServiceReturnsOnCall[i] = struct {
+		result1 string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeMonitClient) ReloadMonit(arg1 *http.Request) (string, error) {
+	fake.reloadMonitMutex.Lock()
+	ret, specificReturn := fake.reloadMonitReturnsOnCall[len(fake.reloadMonitArgsForCall)]
+	fake.reloadMonitArgsForCall = append(fake.reloadMonitArgsForCall, struct {
+		arg1 *http.Request
+	}{arg1})
+	fake.recordInvocation("ReloadMonit", []interface{}{arg1})
+	fake.reloadMonitMutex.Unlock()
+	if fake.ReloadMonitStub != nil {
+		return fake.ReloadMonitStub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.reloadMonitReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeMonitClient) ReloadMonitCallCount() int {
+	fake.reloadMonitMutex.RLock()
+	defer fake.reloadMonitMutex.RUnlock()
+	return len(fake.reloadMonitArgsForCall)
+}
+
+func (fake *FakeMonitClient) ReloadMonitCalls(stub func(*http.Request) (string, error)) {
+	fake.reloadMonitMutex.Lock()
+	defer fake.reloadMonitMutex.Unlock()
+	fake.ReloadMonitStub = stub
+}
+
+func (fake *FakeMonitClient) ReloadMonitArgsForCall(i int) *http.Request {
+	fake.reloadMonitMutex.RLock()
+	defer fake.reloadMonitMutex.RUnlock()
+	argsForCall := fake.reloadMonitArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeMonitClient) ReloadMonitReturns(result1 string, result2 error) {
+	fake.reloadMonitMutex.Lock()
+	defer fake.reloadMonitMutex.Unlock()
+	fake.ReloadMonitStub = nil
+	fake.reloadMonitReturns = struct {
+		result1 string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeMonitClient) ReloadMonitReturnsOnCall(i int, result1 string, result2 error) {
+	fake.reloadMonitMutex.Lock()
+	defer fake.reloadMonitMutex.Unlock()
+	fake.ReloadMonitStub = nil
+	if fake.reloadMonitReturnsOnCall == nil {
+		fake.reloadMonitReturnsOnCall = make(map[int]struct {
+			result1 string
+			result2 error
+		})
+	}
+	fake.reloadMonitReturnsOnCall[i] = struct {
+		result1 string
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *FakeMonitClient) StartServiceBootstrap(arg1 *http.Request) (string, error) {
 	fake.startServiceBootstrapMutex.Lock()
 	ret, specificReturn := fake.startServiceBootstrapReturnsOnCall[len(fake.startServiceBootstrapArgsForCall)]
@@ -393,11 +787,84 @@ func (fake *FakeMonitClient) StopServiceReturnsOnCall(i int, result1 string, res
 	}{result1, result2}
 }
 
+func (fake *FakeMonitClient) UnmonitorService(arg1 *http.Request) (string, error) {
+	fake.unmonitorServiceMutex.Lock()
+	ret, specificReturn := fake.unmonitorServiceReturnsOnCall[len(fake.unmonitorServiceArgsForCall)]
+	fake.unmonitorServiceArgsForCall = append(fake.unmonitorServiceArgsForCall, struct {
+		arg1 *http.Request
+	}{arg1})
+	fake.recordInvocation("UnmonitorService", []interface{}{arg1})
+	fake.unmonitorServiceMutex.Unlock()
+	if fake.UnmonitorServiceStub != nil {
+		return fake.UnmonitorServiceStub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.unmonitorServiceReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeMonitClient) UnmonitorServiceCallCount() int {
+	fake.unmonitorServiceMutex.RLock()
+	defer fake.unmonitorServiceMutex.RUnlock()
+	return len(fake.unmonitorServiceArgsForCall)
+}
+
+func (fake *FakeMonitClient) UnmonitorServiceCalls(stub func(*http.Request) (string, error)) {
+	fake.unmonitorServiceMutex.Lock()
+	defer fake.unmonitorServiceMutex.Unlock()
+	fake.UnmonitorServiceStub = stub
+}
+
+func (fake *FakeMonitClient) UnmonitorServiceArgsForCall(i int) *http.Request {
+	fake.unmonitorServiceMutex.RLock()
+	defer fake.unmonitorServiceMutex.RUnlock()
+	argsForCall := fake.unmonitorServiceArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeMonitClient) UnmonitorServiceReturns(result1 string, result2 error) {
+	fake.unmonitorServiceMutex.Lock()
+	defer fake.unmonitorServiceMutex.Unlock()
+	fake.UnmonitorServiceStub = nil
+	fake.unmonitorServiceReturns = struct {
+		result1 string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeMonitClient) UnmonitorServiceReturnsOnCall(i int, result1 string, result2 error) {
+	fake.unmonitorServiceMutex.Lock()
+	defer fake.unmonitorServiceMutex.Unlock()
+	fake.UnmonitorServiceStub = nil
+	if fake.unmonitorServiceReturnsOnCall == nil {
+		fake.unmonitorServiceReturnsOnCall = make(map[int]struct {
+			result1 string
+			result2 error
+		})
+	}
+	fake.unmonitorServiceReturnsOnCall[i] = struct {
+		result1 string
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *FakeMonitClient) Invocations() map[string][][]interface{} {
 	fake.invocationsMutex.RLock()
 	defer fake.invocationsMutex.RUnlock()
+	fake.getProcessStatsMutex.RLock()
+	defer fake.getProcessStatsMutex.RUnlock()
+	fake.getServicesMutex.RLock()
+	defer fake.getServicesMutex.RUnlock()
+	fake.getStateMutex.RLock()
+	defer fake.getStateMutex.RUnlock()
 	fake.getStatusMutex.RLock()
 	defer fake.getStatusMutex.RUnlock()
+	fake.monitorServiceMutex.RLock()
+	defer fake.monitorServiceMutex.RUnlock()
+	fake.reloadMonitMutex.RLock()
+	defer fake.reloadMonitMutex.RUnlock()
 	fake.startServiceBootstrapMutex.RLock()
 	defer fake.startServiceBootstrapMutex.RUnlock()
 	fake.startServiceJoinMutex.RLock()
@@ -406,6 +873,8 @@ func (fake *FakeMonitClient) Invocations() map[string][][]interface{} {
 	defer fake.startServiceSingleNodeMutex.RUnlock()
 	fake.stopServiceMutex.RLock()
 	defer fake.stopServiceMutex.RUnlock()
+	fake.unmonitorServiceMutex.RLock()
+	defer fake.unmonitorServiceMutex.RUnlock()
 	copiedInvocations := map[string][][]interface{}{}
 	for key, value := range fake.invocations {
 		copiedInvocations[key] = value
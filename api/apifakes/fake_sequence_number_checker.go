@@ -6,6 +6,7 @@ import (
 	"sync"
 
 	"github.com/cloudfoundry-incubator/galera-healthcheck/api"
+	"github.com/cloudfoundry-incubator/galera-healthcheck/mysqld_cmd"
 )
 
 type FakeSequenceNumberChecker struct {
@@ -22,6 +23,19 @@ type FakeSequenceNumberChecker struct {
 		result1 string
 		result2 error
 	}
+	RecoverPositionStub        func(*http.Request) (mysqld_cmd.RecoveredPosition, error)
+	recoverPositionMutex       sync.RWMutex
+	recoverPositionArgsForCall []struct {
+		arg1 *http.Request
+	}
+	recoverPositionReturns struct {
+		result1 mysqld_cmd.RecoveredPosition
+		result2 error
+	}
+	recoverPositionReturnsOnCall map[int]struct {
+		result1 mysqld_cmd.RecoveredPosition
+		result2 error
+	}
 	invocations      map[string][][]interface{}
 	invocationsMutex sync.RWMutex
 }
@@ -89,11 +103,76 @@ func (fake *FakeSequenceNumberChecker) CheckReturnsOnCall(i int, result1 string,
 	}{result1, result2}
 }
 
+func (fake *FakeSequenceNumberChecker) RecoverPosition(arg1 *http.Request) (mysqld_cmd.RecoveredPosition, error) {
+	fake.recoverPositionMutex.Lock()
+	ret, specificReturn := fake.recoverPositionReturnsOnCall[len(fake.recoverPositionArgsForCall)]
+	fake.recoverPositionArgsForCall = append(fake.recoverPositionArgsForCall, struct {
+		arg1 *http.Request
+	}{arg1})
+	fake.recordInvocation("RecoverPosition", []interface{}{arg1})
+	fake.recoverPositionMutex.Unlock()
+	if fake.RecoverPositionStub != nil {
+		return fake.RecoverPositionStub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.recoverPositionReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeSequenceNumberChecker) RecoverPositionCallCount() int {
+	fake.recoverPositionMutex.RLock()
+	defer fake.recoverPositionMutex.RUnlock()
+	return len(fake.recoverPositionArgsForCall)
+}
+
+func (fake *FakeSequenceNumberChecker) RecoverPositionCalls(stub func(*http.Request) (mysqld_cmd.RecoveredPosition, error)) {
+	fake.recoverPositionMutex.Lock()
+	defer fake.recoverPositionMutex.Unlock()
+	fake.RecoverPositionStub = stub
+}
+
+func (fake *FakeSequenceNumberChecker) RecoverPositionArgsForCall(i int) *http.Request {
+	fake.recoverPositionMutex.RLock()
+	defer fake.recoverPositionMutex.RUnlock()
+	argsForCall := fake.recoverPositionArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeSequenceNumberChecker) RecoverPositionReturns(result1 mysqld_cmd.RecoveredPosition, result2 error) {
+	fake.recoverPositionMutex.Lock()
+	defer fake.recoverPositionMutex.Unlock()
+	fake.RecoverPositionStub = nil
+	fake.recoverPositionReturns = struct {
+		result1 mysqld_cmd.RecoveredPosition
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeSequenceNumberChecker) RecoverPositionReturnsOnCall(i int, result1 mysqld_cmd.RecoveredPosition, result2 error) {
+	fake.recoverPositionMutex.Lock()
+	defer fake.recoverPositionMutex.Unlock()
+	fake.RecoverPositionStub = nil
+	if fake.recoverPositionReturnsOnCall == nil {
+		fake.recoverPositionReturnsOnCall = make(map[int]struct {
+			result1 mysqld_cmd.RecoveredPosition
+			result2 error
+		})
+	}
+	fake.recoverPositionReturnsOnCall[i] = struct {
+		result1 mysqld_cmd.RecoveredPosition
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *FakeSequenceNumberChecker) Invocations() map[string][][]interface{} {
 	fake.invocationsMutex.RLock()
 	defer fake.invocationsMutex.RUnlock()
 	fake.checkMutex.RLock()
 	defer fake.checkMutex.RUnlock()
+	fake.recoverPositionMutex.RLock()
+	defer fake.recoverPositionMutex.RUnlock()
 	copiedInvocations := map[string][][]interface{}{}
 	for key, value := range fake.invocations {
 		copiedInvocations[key] = value
@@ -0,0 +1,51 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+
+	"code.cloudfoundry.org/lager"
+)
+
+// ClusterTooSmallError is returned when stopping the node would leave fewer
+// than MinClusterSize nodes in the cluster.
+type ClusterTooSmallError struct {
+	ClusterSize    int
+	MinClusterSize int
+}
+
+func (e ClusterTooSmallError) Error() string {
+	return fmt.Sprintf("refusing to stop: cluster size is %d and stopping this node would drop it below the minimum of %d (use force=true to override)", e.ClusterSize, e.MinClusterSize)
+}
+
+func (ClusterTooSmallError) StatusCode() int {
+	return http.StatusConflict
+}
+
+// guardClusterSize refuses to run a stop when the cluster would be left with
+// fewer than MinClusterSize nodes, unless the request sets force=true. It
+// has no effect when MinClusterSize is not configured.
+func (r router) guardClusterSize(run RunFunc) RunFunc {
+	minSize := r.rootConfig.MinClusterSize
+	if minSize <= 0 {
+		return run
+	}
+
+	return func(req *http.Request) (string, error) {
+		if req.URL.Query().Get("force") == "true" {
+			r.requestLogger(req).Info("skipping-cluster-size-check", lager.Data{"min_cluster_size": minSize})
+			return run(req)
+		}
+
+		size, err := r.healthchecker.ClusterSize()
+		if err != nil {
+			return "", fmt.Errorf("refusing to stop: could not determine cluster size: %s", err)
+		}
+
+		if size-1 < minSize {
+			return "", ClusterTooSmallError{ClusterSize: size, MinClusterSize: minSize}
+		}
+
+		return run(req)
+	}
+}
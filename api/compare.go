@@ -0,0 +1,76 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/cloudfoundry-incubator/galera-healthcheck/sequence_number"
+)
+
+// BadRequestError is returned when a request body cannot be used.
+type BadRequestError struct {
+	Err error
+}
+
+func (e BadRequestError) Error() string {
+	return e.Err.Error()
+}
+
+func (e BadRequestError) Unwrap() error {
+	return e.Err
+}
+
+func (BadRequestError) StatusCode() int {
+	return http.StatusBadRequest
+}
+
+// SequenceNumberComparisonResponse extends the comparison of the reported
+// seqnos with this node's own seqno and whether it holds the highest one.
+// In a tie every tied node is a winner and Winner names the one to choose.
+type SequenceNumberComparisonResponse struct {
+	sequence_number.Comparison
+	LocalSequenceNumber *int `json:"local_sequence_number"`
+	IsWinner            bool `json:"is_winner"`
+}
+
+// compareSequenceNumbers takes the seqnos an orchestrator collected from
+// every node via GET /sequence_number and reports which node should
+// bootstrap the cluster.
+func (r router) compareSequenceNumbers() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		var nodes []sequence_number.NodeSeqno
+		if err := json.NewDecoder(req.Body).Decode(&nodes); err != nil {
+			r.writeError(w, req, ErrorCodeSequenceNumberFailed, BadRequestError{Err: err})
+			return
+		}
+
+		comparison, err := sequence_number.Compare(nodes)
+		if err != nil {
+			r.writeError(w, req, ErrorCodeSequenceNumberFailed, BadRequestError{Err: err})
+			return
+		}
+
+		response := SequenceNumberComparisonResponse{Comparison: comparison}
+
+		if !r.rootConfig.IsArbitrator() {
+			seqno, err := r.sequenceNumberChecker.Check(req)
+			if err != nil {
+				r.writeError(w, req, ErrorCodeSequenceNumberFailed, err)
+				return
+			}
+
+			local, err := strconv.Atoi(seqno)
+			if err != nil {
+				r.writeError(w, req, ErrorCodeSequenceNumberFailed, err)
+				return
+			}
+
+			response.LocalSequenceNumber = &local
+			response.IsWinner = local == comparison.HighestSeqno
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(response)
+	})
+}
@@ -0,0 +1,36 @@
+package api
+
+import (
+	"net/http"
+	"sync"
+)
+
+// drainFlag records whether the node has been taken out of rotation with
+// /drain. It lives for as long as the process does.
+type drainFlag struct {
+	mu       sync.RWMutex
+	draining bool
+}
+
+func (d *drainFlag) Set(draining bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.draining = draining
+}
+
+func (d *drainFlag) IsSet() bool {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.draining
+}
+
+// DrainingError is returned by the health checks while the node is draining.
+type DrainingError struct{}
+
+func (DrainingError) Error() string {
+	return "draining"
+}
+
+func (DrainingError) StatusCode() int {
+	return http.StatusServiceUnavailable
+}
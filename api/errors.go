@@ -0,0 +1,49 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// ErrorCode is a stable identifier for the kind of failure reported in an
+// ErrorResponse, so that clients need not match on error messages.
+type ErrorCode string
+
+const (
+	ErrorCodeServiceStopFailed    ErrorCode = "SERVICE_STOP_FAILED"
+	ErrorCodeServiceStartFailed   ErrorCode = "SERVICE_START_FAILED"
+	ErrorCodeServiceStatusFailed  ErrorCode = "SERVICE_STATUS_FAILED"
+	ErrorCodeMonitReloadFailed    ErrorCode = "MONIT_RELOAD_FAILED"
+	ErrorCodeMonitActionFailed    ErrorCode = "MONIT_ACTION_FAILED"
+	ErrorCodeStateFailed          ErrorCode = "STATE_FAILED"
+	ErrorCodeInvalidRole          ErrorCode = "INVALID_ROLE"
+	ErrorCodeSequenceNumberFailed ErrorCode = "SEQUENCE_NUMBER_FAILED"
+	ErrorCodeRecoveryFailed       ErrorCode = "RECOVERY_FAILED"
+	ErrorCodeWsrepStatusFailed    ErrorCode = "WSREP_STATUS_FAILED"
+	ErrorCodeUnhealthy            ErrorCode = "UNHEALTHY"
+	ErrorCodeInternal             ErrorCode = "INTERNAL_ERROR"
+)
+
+type ErrorResponse struct {
+	Error string    `json:"error"`
+	Code  ErrorCode `json:"code"`
+}
+
+// writeError reports a failed request. The body is an ErrorResponse when the
+// client wants JSON and the bare error message otherwise.
+func (r router) writeError(w http.ResponseWriter, req *http.Request, code ErrorCode, err error) {
+	r.requestLogger(req).Error("Failed to process request", err)
+
+	if !r.wantsJSON(req) {
+		w.WriteHeader(errorStatusCode(err))
+		w.Write([]byte(err.Error()))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(errorStatusCode(err))
+	json.NewEncoder(w).Encode(ErrorResponse{
+		Error: err.Error(),
+		Code:  code,
+	})
+}
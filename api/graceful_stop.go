@@ -0,0 +1,69 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"code.cloudfoundry.org/lager"
+)
+
+// stopGracefully takes the node out of rotation, waits up to
+// GracefulStopTimeout for clients to disconnect and then stops the database.
+// The database is stopped once the timeout passes even if clients are still
+// connected. The node stays drained afterwards; /undrain puts it back.
+func (r router) stopGracefully(req *http.Request) (string, error) {
+	logger := r.requestLogger(req)
+
+	r.drain.Set(true)
+	logger.Info("graceful-stop-draining", lager.Data{"timeout": r.rootConfig.GracefulStopTimeout.String()})
+
+	reason, err := r.waitForClientsToDisconnect(req, logger)
+	if err != nil {
+		return "", err
+	}
+
+	message, err := r.monitClient.StopService(req)
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%s; %s", reason, message), nil
+}
+
+func (r router) waitForClientsToDisconnect(req *http.Request, logger lager.Logger) (string, error) {
+	pollInterval := r.rootConfig.GracefulStopPollInterval
+	if pollInterval <= 0 {
+		pollInterval = 1 * time.Second
+	}
+
+	deadline := time.NewTimer(r.rootConfig.GracefulStopTimeout)
+	defer deadline.Stop()
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	connections := -1
+	for {
+		count, err := r.healthchecker.ClientConnections()
+		if err != nil {
+			logger.Error("graceful-stop-count-connections", err)
+		} else {
+			connections = count
+			if connections == 0 {
+				return "client connections drained", nil
+			}
+		}
+
+		select {
+		case <-ticker.C:
+		case <-deadline.C:
+			logger.Info("graceful-stop-timed-out", lager.Data{"connections": connections})
+			if connections < 0 {
+				return fmt.Sprintf("grace period of %s expired", r.rootConfig.GracefulStopTimeout), nil
+			}
+			return fmt.Sprintf("grace period of %s expired with %d client connections open", r.rootConfig.GracefulStopTimeout, connections), nil
+		case <-req.Context().Done():
+			return "", fmt.Errorf("stopped waiting for client connections to drain: %s", req.Context().Err())
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/cloudfoundry-incubator/galera-healthcheck/domain"
+	"github.com/cloudfoundry-incubator/galera-healthcheck/healthcheck"
+	"github.com/cloudfoundry-incubator/galera-healthcheck/monit_client"
+)
+
+// HealthResponse combines the state of every subsystem the sidecar knows
+// about. Healthy is true only when each subsystem reports no error, mysqld is
+// running and the node is not draining. TransferRole flags a node taking part
+// in a state transfer, such as an SST donor, whether or not it counts as
+// healthy; it is omitted when the wsrep state cannot be read. It is unrelated
+// to the primary/replica role reported by /api/v1/status.
+type HealthResponse struct {
+	Healthy        bool            `json:"healthy"`
+	Draining       bool            `json:"draining"`
+	TransferRole   string          `json:"transfer_role,omitempty"`
+	Wsrep          SubsystemHealth `json:"wsrep"`
+	Monit          SubsystemHealth `json:"monit"`
+	SequenceNumber SubsystemHealth `json:"sequence_number"`
+}
+
+type SubsystemHealth struct {
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+func newSubsystemHealth(status string, err error) SubsystemHealth {
+	if err != nil {
+		return SubsystemHealth{Error: err.Error()}
+	}
+	return SubsystemHealth{Status: status}
+}
+
+func (s SubsystemHealth) ok() bool {
+	return s.Error == ""
+}
+
+func (r router) health() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		status, err := r.healthchecker.CheckStatus()
+		response := HealthResponse{
+			Draining:       r.drain.IsSet(),
+			Wsrep:          newSubsystemHealth(status.State, err),
+			Monit:          newSubsystemHealth(r.monitClient.GetStatus(req)),
+			SequenceNumber: newSubsystemHealth(r.sequenceNumberChecker.Check(req)),
+		}
+
+		if status.LocalState != 0 {
+			response.TransferRole = healthcheck.ClassifyRole(domain.WsrepLocalState(status.LocalState))
+		}
+
+		response.Healthy = !response.Draining &&
+			response.Wsrep.ok() &&
+			response.Monit.ok() && monit_client.NormalizeStatus(response.Monit.Status) == monit_client.StateRunning &&
+			response.SequenceNumber.ok()
+
+		w.Header().Set("Content-Type", "application/json")
+		if !response.Healthy {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		json.NewEncoder(w).Encode(response)
+	})
+}
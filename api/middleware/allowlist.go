@@ -0,0 +1,75 @@
+package middleware
+
+import (
+	"net"
+	"net/http"
+	"strings"
+)
+
+// Allowlist rejects requests with 403 Forbidden unless the client's address
+// is in one of Allowed. The client's address is the request's RemoteAddr,
+// unless that is one of TrustedProxies, in which case it is the last address
+// in X-Forwarded-For that is not itself a trusted proxy.
+type Allowlist struct {
+	Allowed        []*net.IPNet
+	TrustedProxies []*net.IPNet
+}
+
+func NewAllowlist(allowed, trustedProxies []*net.IPNet) Middleware {
+	return Allowlist{
+		Allowed:        allowed,
+		TrustedProxies: trustedProxies,
+	}
+}
+
+func (a Allowlist) Wrap(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		ip := a.clientIP(req)
+		if ip == nil || !contains(a.Allowed, ip) {
+			http.Error(rw, "Forbidden", http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(rw, req)
+	})
+}
+
+func (a Allowlist) clientIP(req *http.Request) net.IP {
+	host, _, err := net.SplitHostPort(req.RemoteAddr)
+	if err != nil {
+		host = req.RemoteAddr
+	}
+
+	ip := net.ParseIP(host)
+	if ip == nil || !contains(a.TrustedProxies, ip) {
+		return ip
+	}
+
+	header := strings.Join(req.Header.Values("X-Forwarded-For"), ",")
+	if header == "" {
+		return ip
+	}
+
+	// Each proxy appends the address it received the request from, so the
+	// rightmost untrusted address is the first one we can't vouch for.
+	forwarded := strings.Split(header, ",")
+	for i := len(forwarded) - 1; i >= 0; i-- {
+		hop := net.ParseIP(strings.TrimSpace(forwarded[i]))
+		if hop == nil {
+			return nil
+		}
+		ip = hop
+		if !contains(a.TrustedProxies, ip) {
+			break
+		}
+	}
+	return ip
+}
+
+func contains(networks []*net.IPNet, ip net.IP) bool {
+	for _, network := range networks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,65 @@
+package middleware
+
+import (
+	"net"
+	"net/http"
+
+	"code.cloudfoundry.org/lager"
+
+	"github.com/cloudfoundry-incubator/galera-healthcheck/requestid"
+)
+
+// Audit logs who called an endpoint and how the request was answered. POST
+// requests change node state and are logged at info level; all other
+// requests are logged at debug level. The correlation ID assigned by the
+// RequestID middleware is included in every audit line.
+type Audit struct {
+	Logger   lager.Logger
+	Endpoint string
+}
+
+func NewAudit(logger lager.Logger, endpoint string) Middleware {
+	return Audit{
+		Logger:   logger,
+		Endpoint: endpoint,
+	}
+}
+
+func (a Audit) Wrap(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		requestID, _ := requestid.FromContext(req.Context())
+		recorder := &statusRecorder{ResponseWriter: rw, status: http.StatusOK}
+		next.ServeHTTP(recorder, req)
+
+		username, _, _ := req.BasicAuth()
+		sourceIP, _, err := net.SplitHostPort(req.RemoteAddr)
+		if err != nil {
+			sourceIP = req.RemoteAddr
+		}
+
+		data := lager.Data{
+			"endpoint":   a.Endpoint,
+			"method":     req.Method,
+			"username":   username,
+			"source_ip":  sourceIP,
+			"status":     recorder.status,
+			"request_id": requestID,
+		}
+
+		if req.Method == http.MethodPost {
+			a.Logger.Info("audit", data)
+		} else {
+			a.Logger.Debug("audit", data)
+		}
+	})
+}
+
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (s *statusRecorder) WriteHeader(status int) {
+	s.status = status
+	s.ResponseWriter.WriteHeader(status)
+}
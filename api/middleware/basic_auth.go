@@ -1,38 +1,66 @@
 package middleware
 
 import (
+	"crypto/sha256"
 	"crypto/subtle"
+	"fmt"
 	"net/http"
 )
 
+// DefaultRealm is the realm announced in the WWW-Authenticate challenge when
+// none is configured.
+const DefaultRealm = "Authorization Required"
+
 type BasicAuth struct {
+	Realm       string
+	Credentials []Credential
+}
+
+type Credential struct {
 	Username, Password string
 }
 
-func NewBasicAuth(username, password string) Middleware {
+func NewBasicAuth(realm string, credentials ...Credential) Middleware {
+	if realm == "" {
+		realm = DefaultRealm
+	}
+
 	return BasicAuth{
-		Username: username,
-		Password: password,
+		Realm:       realm,
+		Credentials: credentials,
 	}
 }
 
 func (b BasicAuth) Wrap(next http.Handler) http.Handler {
 	handler := http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
 		username, password, ok := req.BasicAuth()
-		if ok &&
-			secureCompare(username, b.Username) &&
-			secureCompare(password, b.Password) {
+		if ok && b.matches(username, password) {
 			next.ServeHTTP(rw, req)
 		} else {
-			rw.Header().Set("WWW-Authenticate", "Basic realm=\"Authorization Required\"")
+			rw.Header().Set("WWW-Authenticate", fmt.Sprintf("Basic realm=%q", b.Realm))
 			http.Error(rw, "Not Authorized", http.StatusUnauthorized)
 		}
 	})
 	return handler
 }
 
-func secureCompare(a, b string) bool {
-	x := []byte(a)
-	y := []byte(b)
-	return subtle.ConstantTimeCompare(x, y) == 1
+// matches compares against every credential so that the time taken does not
+// reveal which pair, if any, matched.
+func (b BasicAuth) matches(username, password string) bool {
+	matched := 0
+	for _, credential := range b.Credentials {
+		usernameMatch := secureCompare(username, credential.Username)
+		passwordMatch := secureCompare(password, credential.Password)
+		matched |= usernameMatch & passwordMatch
+	}
+	return matched == 1
+}
+
+// secureCompare returns 1 if a and b are equal and 0 otherwise. Both sides
+// are hashed first because ConstantTimeCompare returns early, revealing the
+// length of the secret, when its inputs differ in length.
+func secureCompare(a, b string) int {
+	aHash := sha256.Sum256([]byte(a))
+	bHash := sha256.Sum256([]byte(b))
+	return subtle.ConstantTimeCompare(aHash[:], bHash[:])
 }
@@ -0,0 +1,33 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+)
+
+const ConfirmationHeader = "X-Confirm"
+
+// Confirmation rejects requests with 412 Precondition Failed unless they
+// carry an "X-Confirm: true" header or a "confirm=true" query parameter. It
+// guards endpoints that stop or restart the database against accidental
+// calls.
+type Confirmation struct{}
+
+func NewConfirmation() Middleware {
+	return Confirmation{}
+}
+
+func (c Confirmation) Wrap(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if !confirmed(req) {
+			http.Error(rw, "Confirmation required: set the X-Confirm: true header or the confirm=true query parameter", http.StatusPreconditionFailed)
+			return
+		}
+		next.ServeHTTP(rw, req)
+	})
+}
+
+func confirmed(req *http.Request) bool {
+	return strings.EqualFold(req.Header.Get(ConfirmationHeader), "true") ||
+		strings.EqualFold(req.URL.Query().Get("confirm"), "true")
+}
@@ -0,0 +1,61 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+)
+
+// CORS allows browsers on AllowedOrigins to call the wrapped handler. An
+// AllowedOrigins entry of "*" allows any origin, but only without credentials;
+// credentialed requests are allowed only from explicitly listed origins.
+// Preflight OPTIONS requests are answered directly with 204 No Content.
+type CORS struct {
+	AllowedOrigins []string
+	AllowedMethods []string
+}
+
+func NewCORS(allowedOrigins, allowedMethods []string) Middleware {
+	return CORS{
+		AllowedOrigins: allowedOrigins,
+		AllowedMethods: allowedMethods,
+	}
+}
+
+func (c CORS) Wrap(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		origin := req.Header.Get("Origin")
+		allowed := false
+
+		if origin != "" {
+			if c.listed(origin) {
+				allowed = true
+				rw.Header().Set("Access-Control-Allow-Origin", origin)
+				rw.Header().Set("Access-Control-Allow-Credentials", "true")
+				rw.Header().Add("Vary", "Origin")
+			} else if c.listed("*") {
+				allowed = true
+				rw.Header().Set("Access-Control-Allow-Origin", "*")
+			}
+		}
+
+		if req.Method == http.MethodOptions {
+			if allowed {
+				rw.Header().Set("Access-Control-Allow-Methods", strings.Join(c.AllowedMethods, ", "))
+				rw.Header().Set("Access-Control-Allow-Headers", "Authorization, Accept")
+			}
+			rw.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		next.ServeHTTP(rw, req)
+	})
+}
+
+func (c CORS) listed(origin string) bool {
+	for _, allowed := range c.AllowedOrigins {
+		if allowed == origin {
+			return true
+		}
+	}
+	return false
+}
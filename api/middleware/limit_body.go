@@ -0,0 +1,48 @@
+package middleware
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"net/http"
+)
+
+// LimitBody rejects requests with a body larger than MaxBytes with 413
+// Request Entity Too Large. A zero MaxBytes allows bodies of any size.
+//
+// The body is read up front, so that the limit holds for chunked bodies that
+// carry no Content-Length even when the handler never reads the body.
+type LimitBody struct {
+	MaxBytes int64
+}
+
+func NewLimitBody(maxBytes int64) Middleware {
+	return LimitBody{
+		MaxBytes: maxBytes,
+	}
+}
+
+func (l LimitBody) Wrap(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if l.MaxBytes > 0 {
+			if req.ContentLength > l.MaxBytes {
+				http.Error(rw, "Request Entity Too Large", http.StatusRequestEntityTooLarge)
+				return
+			}
+
+			body, err := ioutil.ReadAll(io.LimitReader(req.Body, l.MaxBytes+1))
+			req.Body.Close()
+			if err != nil {
+				http.Error(rw, "Failed to read request body", http.StatusBadRequest)
+				return
+			}
+			if int64(len(body)) > l.MaxBytes {
+				http.Error(rw, "Request Entity Too Large", http.StatusRequestEntityTooLarge)
+				return
+			}
+			req.Body = ioutil.NopCloser(bytes.NewReader(body))
+		}
+
+		next.ServeHTTP(rw, req)
+	})
+}
@@ -0,0 +1,29 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/cloudfoundry-incubator/galera-healthcheck/requestid"
+)
+
+// RequestID makes sure every request carries a correlation ID. The ID sent
+// by the client in the X-Request-Id header is reused when present, otherwise
+// a new one is generated. The ID is echoed back in the response and stored in
+// the request context so that downstream logging can be tagged with it.
+type RequestID struct{}
+
+func NewRequestID() Middleware {
+	return RequestID{}
+}
+
+func (RequestID) Wrap(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		id := req.Header.Get(requestid.Header)
+		if id == "" {
+			id = requestid.New()
+		}
+		rw.Header().Set(requestid.Header, id)
+
+		next.ServeHTTP(rw, req.WithContext(requestid.NewContext(req.Context(), id)))
+	})
+}
@@ -0,0 +1,28 @@
+package middleware
+
+import "net/http"
+
+// ResponseHeaders sets Headers on every response of the wrapped handler.
+// Headers with an empty value are left unset, so operators can remove a
+// default header.
+type ResponseHeaders struct {
+	Headers map[string]string
+}
+
+func NewResponseHeaders(headers map[string]string) Middleware {
+	return ResponseHeaders{
+		Headers: headers,
+	}
+}
+
+func (h ResponseHeaders) Wrap(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		for name, value := range h.Headers {
+			if value != "" {
+				rw.Header().Set(name, value)
+			}
+		}
+
+		next.ServeHTTP(rw, req)
+	})
+}
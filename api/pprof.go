@@ -0,0 +1,40 @@
+package api
+
+import (
+	"net/http"
+	"net/http/pprof"
+
+	"github.com/tedsuo/rata"
+)
+
+// withProfiling mounts the net/http/pprof handlers under /debug/pprof. They
+// require authentication and are not subject to the endpoint timeouts,
+// since CPU profiles and traces run for as long as the client asks.
+func (r router) withProfiling(routes rata.Routes, handlers rata.Handlers) rata.Routes {
+	// pprof.Index finds named profiles by their path, so it must see the path
+	// without the BasePath.
+	index := http.StripPrefix(r.rootConfig.BasePath, http.HandlerFunc(pprof.Index))
+
+	profiling := map[string]http.Handler{
+		"pprof_cmdline": http.HandlerFunc(pprof.Cmdline),
+		"pprof_profile": http.HandlerFunc(pprof.Profile),
+		"pprof_symbol":  http.HandlerFunc(pprof.Symbol),
+		"pprof_trace":   http.HandlerFunc(pprof.Trace),
+		"pprof_index":   index,
+	}
+
+	// The index route matches every path under /debug/pprof/, so it must be
+	// added after the more specific routes.
+	routes = append(routes,
+		rata.Route{Name: "pprof_cmdline", Method: "GET", Path: "/debug/pprof/cmdline"},
+		rata.Route{Name: "pprof_profile", Method: "GET", Path: "/debug/pprof/profile"},
+		rata.Route{Name: "pprof_symbol", Method: "GET", Path: "/debug/pprof/symbol"},
+		rata.Route{Name: "pprof_trace", Method: "GET", Path: "/debug/pprof/trace"},
+		rata.Route{Name: "pprof_index", Method: "GET", Path: "/debug/pprof/"},
+	)
+
+	for name, handler := range profiling {
+		handlers[name] = r.authenticated(handler)
+	}
+	return routes
+}
@@ -0,0 +1,72 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"code.cloudfoundry.org/lager"
+)
+
+const defaultReadyTimeout = 30 * time.Second
+
+// ready blocks until the node is healthy or the timeout given by the timeout
+// query parameter passes. It answers 200 with the node's state once healthy
+// and 503 with the last reported state on timeout. A draining node is never
+// healthy. Timeouts longer than MaxReadyTimeout are rejected, since anyone
+// may call the endpoint and each call holds a connection open.
+func (r router) ready() http.Handler {
+	check := r.unlessDraining(func(*http.Request) (string, error) {
+		return r.healthchecker.Check()
+	})
+
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		maxTimeout := r.rootConfig.MaxReadyTimeout
+
+		timeout := defaultReadyTimeout
+		if maxTimeout > 0 && timeout > maxTimeout {
+			timeout = maxTimeout
+		}
+		if raw := req.URL.Query().Get("timeout"); raw != "" {
+			var err error
+			timeout, err = time.ParseDuration(raw)
+			if err != nil || timeout < 0 {
+				http.Error(w, fmt.Sprintf("invalid timeout %q", raw), http.StatusBadRequest)
+				return
+			}
+			if maxTimeout > 0 && timeout > maxTimeout {
+				http.Error(w, fmt.Sprintf("timeout %s exceeds the maximum of %s", timeout, maxTimeout), http.StatusBadRequest)
+				return
+			}
+		}
+
+		pollInterval := r.rootConfig.ReadyPollInterval
+		if pollInterval <= 0 {
+			pollInterval = 1 * time.Second
+		}
+
+		deadline := time.NewTimer(timeout)
+		defer deadline.Stop()
+		ticker := time.NewTicker(pollInterval)
+		defer ticker.Stop()
+
+		for {
+			state, err := check(req)
+			if err == nil {
+				w.Write([]byte(state))
+				return
+			}
+
+			select {
+			case <-ticker.C:
+			case <-deadline.C:
+				r.requestLogger(req).Info("ready-timed-out", lager.Data{"timeout": timeout.String(), "state": err.Error()})
+				w.WriteHeader(http.StatusServiceUnavailable)
+				w.Write([]byte(err.Error()))
+				return
+			case <-req.Context().Done():
+				return
+			}
+		}
+	})
+}
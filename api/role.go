@@ -0,0 +1,62 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+
+	"code.cloudfoundry.org/lager"
+
+	"github.com/cloudfoundry-incubator/galera-healthcheck/config"
+)
+
+// nodeRole holds the role assigned to the node by an external election. It
+// starts from the configured Role and may be changed at runtime with
+// POST /role.
+type nodeRole struct {
+	mu   sync.RWMutex
+	role string
+}
+
+func newNodeRole(role string) *nodeRole {
+	if role == "" {
+		role = config.RoleReplica
+	}
+	return &nodeRole{role: role}
+}
+
+func (n *nodeRole) Set(role string) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.role = role
+}
+
+func (n *nodeRole) Get() string {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	return n.role
+}
+
+// InvalidRoleError is returned when POST /role names an unknown role.
+type InvalidRoleError struct {
+	Role string
+}
+
+func (e InvalidRoleError) Error() string {
+	return fmt.Sprintf("invalid role %q: must be %q or %q", e.Role, config.RolePrimary, config.RoleReplica)
+}
+
+func (InvalidRoleError) StatusCode() int {
+	return http.StatusBadRequest
+}
+
+func (r router) setRole(req *http.Request) (string, error) {
+	role := req.FormValue("role")
+	if !config.IsValidRole(role) {
+		return "", InvalidRoleError{Role: role}
+	}
+
+	r.role.Set(role)
+	r.requestLogger(req).Info("role", lager.Data{"role": role})
+	return role, nil
+}
@@ -0,0 +1,38 @@
+package api
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Serve serves handler on l until ctx is done. It then stops accepting new
+// connections and waits up to drainTimeout for in-flight requests, such as a
+// start_mysql_bootstrap waiting on galera-init, to complete before returning.
+func Serve(ctx context.Context, l net.Listener, handler http.Handler, drainTimeout time.Duration) error {
+	server := &http.Server{Handler: handler}
+
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- server.Serve(l)
+	}()
+
+	select {
+	case err := <-serveErr:
+		return err
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), drainTimeout)
+	defer cancel()
+
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		server.Close()
+		return errors.Wrap(err, "failed to drain in-flight requests")
+	}
+
+	return nil
+}
@@ -0,0 +1,103 @@
+package api_test
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/cloudfoundry-incubator/galera-healthcheck/api"
+)
+
+var _ = Describe("Serve", func() {
+	var (
+		listener     net.Listener
+		url          string
+		requestStart chan struct{}
+		releaseSlow  chan struct{}
+		ctx          context.Context
+		cancel       context.CancelFunc
+		serveErr     chan error
+		drainTimeout time.Duration
+	)
+
+	BeforeEach(func() {
+		var err error
+		listener, err = net.Listen("tcp", "127.0.0.1:0")
+		Expect(err).NotTo(HaveOccurred())
+		url = "http://" + listener.Addr().String()
+
+		requestStart = make(chan struct{}, 1)
+		releaseSlow = make(chan struct{})
+		drainTimeout = 5 * time.Second
+		ctx, cancel = context.WithCancel(context.Background())
+	})
+
+	JustBeforeEach(func() {
+		handler := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			if req.URL.Path == "/slow" {
+				requestStart <- struct{}{}
+				<-releaseSlow
+			}
+			w.Write([]byte("done"))
+		})
+
+		serveErr = make(chan error, 1)
+		go func() {
+			serveErr <- api.Serve(ctx, listener, handler, drainTimeout)
+		}()
+	})
+
+	AfterEach(func() {
+		cancel()
+	})
+
+	It("lets in-flight requests complete and refuses new ones after shutdown", func() {
+		slowResp := make(chan *http.Response, 1)
+		go func() {
+			defer GinkgoRecover()
+			resp, err := http.Get(url + "/slow")
+			Expect(err).NotTo(HaveOccurred())
+			slowResp <- resp
+		}()
+		Eventually(requestStart).Should(Receive())
+
+		cancel()
+
+		Eventually(func() error {
+			_, err := (&http.Client{Transport: &http.Transport{DisableKeepAlives: true}}).Get(url + "/fast")
+			return err
+		}).Should(HaveOccurred())
+
+		Consistently(serveErr, 100*time.Millisecond).ShouldNot(Receive())
+		close(releaseSlow)
+
+		var resp *http.Response
+		Eventually(slowResp).Should(Receive(&resp))
+		Expect(resp.StatusCode).To(Equal(http.StatusOK))
+
+		Eventually(serveErr).Should(Receive(BeNil()))
+	})
+
+	Context("when in-flight requests outlast the drain timeout", func() {
+		BeforeEach(func() {
+			drainTimeout = 50 * time.Millisecond
+		})
+
+		AfterEach(func() {
+			close(releaseSlow)
+		})
+
+		It("returns an error", func() {
+			go http.Get(url + "/slow")
+			Eventually(requestStart).Should(Receive())
+
+			cancel()
+
+			Eventually(serveErr).Should(Receive(MatchError(ContainSubstring("failed to drain in-flight requests"))))
+		})
+	})
+})
@@ -0,0 +1,48 @@
+package api
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"io/ioutil"
+
+	"github.com/pkg/errors"
+
+	"github.com/cloudfoundry-incubator/galera-healthcheck/config"
+)
+
+// NewTLSConfig builds the server side TLS configuration for the sidecar API.
+// It returns nil when TLS has not been configured so that callers can keep
+// serving plain HTTP. When a client CA is supplied, clients are required to
+// present a certificate signed by that CA in addition to basic auth.
+func NewTLSConfig(tlsConfig config.TLSConfig) (*tls.Config, error) {
+	if !tlsConfig.Enabled() {
+		return nil, nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(tlsConfig.CertificatePath, tlsConfig.PrivateKeyPath)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to load server certificate")
+	}
+
+	serverConfig := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+
+	if tlsConfig.ClientCAPath != "" {
+		caPEM, err := ioutil.ReadFile(tlsConfig.ClientCAPath)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to read client CA")
+		}
+
+		clientCAs := x509.NewCertPool()
+		if !clientCAs.AppendCertsFromPEM(caPEM) {
+			return nil, errors.Errorf("no certificates found in client CA %s", tlsConfig.ClientCAPath)
+		}
+
+		serverConfig.ClientCAs = clientCAs
+		serverConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+
+	return serverConfig, nil
+}
@@ -0,0 +1,246 @@
+package api_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"time"
+
+	"code.cloudfoundry.org/lager/lagertest"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/cloudfoundry-incubator/galera-healthcheck/api"
+	"github.com/cloudfoundry-incubator/galera-healthcheck/api/apifakes"
+	"github.com/cloudfoundry-incubator/galera-healthcheck/config"
+)
+
+type testCertificate struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pem  []byte
+	der  []byte
+}
+
+func generateCertificate(commonName string, isCA bool, parent *testCertificate) testCertificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	Expect(err).NotTo(HaveOccurred())
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: commonName},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		BasicConstraintsValid: true,
+		IsCA:                  isCA,
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+	}
+
+	signerCert, signerKey := template, key
+	if parent != nil {
+		signerCert, signerKey = parent.cert, parent.key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, signerCert, &key.PublicKey, signerKey)
+	Expect(err).NotTo(HaveOccurred())
+
+	cert, err := x509.ParseCertificate(der)
+	Expect(err).NotTo(HaveOccurred())
+
+	return testCertificate{
+		cert: cert,
+		key:  key,
+		der:  der,
+		pem:  pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+	}
+}
+
+func (c testCertificate) keyPEM() []byte {
+	keyDER, err := x509.MarshalECPrivateKey(c.key)
+	Expect(err).NotTo(HaveOccurred())
+	return pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
+
+func (c testCertificate) tlsCertificate() tls.Certificate {
+	return tls.Certificate{
+		Certificate: [][]byte{c.der},
+		PrivateKey:  c.key,
+	}
+}
+
+var _ = Describe("TLS", func() {
+	var (
+		tempDir   string
+		ca        testCertificate
+		tlsConfig config.TLSConfig
+	)
+
+	BeforeEach(func() {
+		var err error
+		tempDir, err = ioutil.TempDir("", "api-tls")
+		Expect(err).NotTo(HaveOccurred())
+
+		ca = generateCertificate("test-ca", true, nil)
+		server := generateCertificate("127.0.0.1", false, &ca)
+
+		tlsConfig = config.TLSConfig{
+			CertificatePath: filepath.Join(tempDir, "server.crt"),
+			PrivateKeyPath:  filepath.Join(tempDir, "server.key"),
+		}
+		Expect(ioutil.WriteFile(tlsConfig.CertificatePath, server.pem, 0600)).To(Succeed())
+		Expect(ioutil.WriteFile(tlsConfig.PrivateKeyPath, server.keyPEM(), 0600)).To(Succeed())
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(tempDir)
+	})
+
+	It("returns no TLS configuration when TLS is not configured", func() {
+		serverConfig, err := api.NewTLSConfig(config.TLSConfig{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(serverConfig).To(BeNil())
+	})
+
+	It("returns an error when the server certificate cannot be loaded", func() {
+		tlsConfig.CertificatePath = filepath.Join(tempDir, "missing.crt")
+
+		_, err := api.NewTLSConfig(tlsConfig)
+		Expect(err).To(MatchError(ContainSubstring("failed to load server certificate")))
+	})
+
+	Context("when serving the router over HTTPS", func() {
+		var (
+			ts          *httptest.Server
+			monitClient *apifakes.FakeMonitClient
+			rootCAs     *x509.CertPool
+		)
+
+		var startServer = func() {
+			serverConfig, err := api.NewTLSConfig(tlsConfig)
+			Expect(err).NotTo(HaveOccurred())
+
+			monitClient = &apifakes.FakeMonitClient{}
+			monitClient.GetStatusReturns("running", nil)
+
+			handler, err := api.NewRouter(
+				lagertest.NewTestLogger("tls"),
+				&config.Config{
+					SidecarEndpoint: config.SidecarEndpointConfig{
+						Username: ApiUsername,
+						Password: ApiPassword,
+					},
+				},
+				monitClient,
+				&apifakes.FakeSequenceNumberChecker{},
+				&apifakes.FakeReqHealthChecker{},
+				&apifakes.FakeHealthChecker{},
+				&apifakes.FakeStateSnapshotter{},
+				api.BuildInfo{},
+				nil,
+			)
+			Expect(err).NotTo(HaveOccurred())
+
+			ts = httptest.NewUnstartedServer(handler)
+			ts.TLS = serverConfig
+			ts.StartTLS()
+
+			rootCAs = x509.NewCertPool()
+			rootCAs.AddCert(ca.cert)
+		}
+
+		var getStatus = func(clientCerts ...tls.Certificate) (*http.Response, error) {
+			client := &http.Client{
+				Transport: &http.Transport{
+					TLSClientConfig: &tls.Config{
+						RootCAs:      rootCAs,
+						Certificates: clientCerts,
+					},
+				},
+			}
+
+			req, err := http.NewRequest("GET", ts.URL+"/mysql_status", nil)
+			Expect(err).NotTo(HaveOccurred())
+			req.SetBasicAuth(ApiUsername, ApiPassword)
+
+			return client.Do(req)
+		}
+
+		AfterEach(func() {
+			ts.Close()
+		})
+
+		Context("when no client CA is configured", func() {
+			BeforeEach(startServer)
+
+			It("serves requests without a client certificate", func() {
+				resp, err := getStatus()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(resp.StatusCode).To(Equal(http.StatusOK))
+				Expect(monitClient.GetStatusCallCount()).To(Equal(1))
+			})
+		})
+
+		Context("when a client CA is configured", func() {
+			BeforeEach(func() {
+				tlsConfig.ClientCAPath = filepath.Join(tempDir, "client-ca.crt")
+				Expect(ioutil.WriteFile(tlsConfig.ClientCAPath, ca.pem, 0600)).To(Succeed())
+
+				startServer()
+			})
+
+			It("accepts a client certificate signed by the client CA", func() {
+				client := generateCertificate("client", false, &ca)
+
+				resp, err := getStatus(client.tlsCertificate())
+				Expect(err).NotTo(HaveOccurred())
+				Expect(resp.StatusCode).To(Equal(http.StatusOK))
+				Expect(monitClient.GetStatusCallCount()).To(Equal(1))
+			})
+
+			It("still requires basic auth with a valid client certificate", func() {
+				client := generateCertificate("client", false, &ca)
+				httpClient := &http.Client{
+					Transport: &http.Transport{
+						TLSClientConfig: &tls.Config{
+							RootCAs:      rootCAs,
+							Certificates: []tls.Certificate{client.tlsCertificate()},
+						},
+					},
+				}
+
+				resp, err := httpClient.Get(ts.URL + "/mysql_status")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(resp.StatusCode).To(Equal(http.StatusUnauthorized))
+				Expect(monitClient.GetStatusCallCount()).To(Equal(0))
+			})
+
+			It("rejects a client certificate signed by an unknown CA", func() {
+				otherCA := generateCertificate("other-ca", true, nil)
+				client := generateCertificate("client", false, &otherCA)
+
+				_, err := getStatus(client.tlsCertificate())
+				Expect(err).To(HaveOccurred())
+				Expect(monitClient.GetStatusCallCount()).To(Equal(0))
+			})
+
+			It("rejects clients without a certificate", func() {
+				_, err := getStatus()
+				Expect(err).To(HaveOccurred())
+				Expect(monitClient.GetStatusCallCount()).To(Equal(0))
+			})
+		})
+	})
+})
@@ -1,9 +1,18 @@
 package config
 
 import (
+	"database/sql"
 	"errors"
 	"flag"
 	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
 
 	"code.cloudfoundry.org/lager"
 	"code.cloudfoundry.org/lager/lagerflags"
@@ -14,37 +23,329 @@ import (
 )
 
 type Config struct {
-	DB                    DBConfig    `yaml:"DB" validate:"nonzero"`
-	Monit                 MonitConfig `yaml:"Monit" validate:"nonzero"`
-	Host                  string      `yaml:"Host" validate:"nonzero"`
-	Port                  int         `yaml:"Port" validate:"nonzero"`
-	AvailableWhenDonor    bool        `yaml:"AvailableWhenDonor"`
-	AvailableWhenReadOnly bool        `yaml:"AvailableWhenReadOnly"`
-	Logger                lager.Logger
-	MysqldPath            string                `yaml:"MysqldPath" validate:"nonzero"`
-	MyCnfPath             string                `yaml:"MyCnfPath" validate:"nonzero"`
-	SidecarEndpoint       SidecarEndpointConfig `yaml:"SidecarEndpoint" validate:"nonzero"`
+	DB                       DBConfig               `yaml:"DB" validate:"nonzero"`
+	Monit                    MonitConfig            `yaml:"Monit" validate:"nonzero"`
+	Host                     string                 `yaml:"Host" validate:"nonzero"`
+	Port                     int                    `yaml:"Port" validate:"nonzero"`
+	AdminHost                string                 `yaml:"AdminHost"`
+	AdminPort                int                    `yaml:"AdminPort"`
+	AvailableWhenDonor       bool                   `yaml:"AvailableWhenDonor"`
+	AvailableWhenReadOnly    bool                   `yaml:"AvailableWhenReadOnly"`
+	AvailableWhenNonPrimary  bool                   `yaml:"AvailableWhenNonPrimary"`
+	Logger                   lager.Logger           `json:"-"`
+	LogLevel                 string                 `yaml:"LogLevel"`
+	LogFormat                string                 `yaml:"LogFormat"`
+	MysqldPath               string                 `yaml:"MysqldPath" validate:"nonzero"`
+	MyCnfPath                string                 `yaml:"MyCnfPath" validate:"nonzero"`
+	GrastatePath             string                 `yaml:"GrastatePath"`
+	WsrepRecoverArgs         []string               `yaml:"WsrepRecoverArgs"`
+	WsrepRecoverPattern      string                 `yaml:"WsrepRecoverPattern"`
+	SidecarEndpoint          SidecarEndpointConfig  `yaml:"SidecarEndpoint" validate:"nonzero"`
+	TLS                      TLSConfig              `yaml:"TLS"`
+	ResponseFormat           string                 `yaml:"ResponseFormat"`
+	AllowedStates            []string               `yaml:"AllowedStates"`
+	EnableMetrics            bool                   `yaml:"EnableMetrics"`
+	EnableProfiling          bool                   `yaml:"EnableProfiling"`
+	ReplicationLag           ReplicationLagConfig   `yaml:"ReplicationLag"`
+	FlowControl              FlowControlConfig      `yaml:"FlowControl"`
+	ClusterConfChanges       ConfChangesConfig      `yaml:"ClusterConfChanges"`
+	JoinerGracePeriod        time.Duration          `yaml:"JoinerGracePeriod"`
+	CheckCacheTTL            time.Duration          `yaml:"CheckCacheTTL"`
+	BasePath                 string                 `yaml:"BasePath"`
+	ReadyPollInterval        time.Duration          `yaml:"ReadyPollInterval"`
+	MaxReadyTimeout          time.Duration          `yaml:"MaxReadyTimeout"`
+	Role                     string                 `yaml:"Role"`
+	ShutdownTimeout          time.Duration          `yaml:"ShutdownTimeout"`
+	DryRun                   bool                   `yaml:"DryRun"`
+	LivenessTimeout          time.Duration          `yaml:"LivenessTimeout"`
+	RequireConfirmation      bool                   `yaml:"RequireConfirmation"`
+	ReportNodeIdentity       bool                   `yaml:"ReportNodeIdentity"`
+	UnhealthyStatusCode      int                    `yaml:"UnhealthyStatusCode"`
+	LegacyHealthStatus       bool                   `yaml:"LegacyHealthStatus"`
+	ReadOnly                 bool                   `yaml:"ReadOnly"`
+	EndpointTimeouts         EndpointTimeoutsConfig `yaml:"EndpointTimeouts"`
+	CORS                     CORSConfig             `yaml:"CORS"`
+	ResponseHeaders          map[string]string      `yaml:"ResponseHeaders"`
+	MutatingAllowlist        AllowlistConfig        `yaml:"MutatingAllowlist"`
+	MinClusterSize           int                    `yaml:"MinClusterSize"`
+	ArbitratorSeqnoFormat    string                 `yaml:"ArbitratorSeqnoFormat"`
+	MaxRequestBodyBytes      int64                  `yaml:"MaxRequestBodyBytes"`
+	StateWebhook             StateWebhookConfig     `yaml:"StateWebhook"`
+	GracefulStopTimeout      time.Duration          `yaml:"GracefulStopTimeout"`
+	GracefulStopPollInterval time.Duration          `yaml:"GracefulStopPollInterval"`
+}
+
+const (
+	ResponseFormatText = "text"
+	ResponseFormatJSON = "json"
+)
+
+// Representations of an arbitrator's sequence number. The arbitrator holds no
+// data and so has no seqno; consumers differ in what they expect instead.
+const (
+	ArbitratorSeqnoMessage    = "message"
+	ArbitratorSeqnoSentinel   = "sentinel"
+	ArbitratorSeqnoStructured = "structured"
+)
+
+// Roles a node may be assigned by an external election. Galera itself treats
+// every node as a writer; the role only tells proxies which node to prefer.
+const (
+	RolePrimary = "primary"
+	RoleReplica = "replica"
+)
+
+func IsValidRole(role string) bool {
+	return role == RolePrimary || role == RoleReplica
 }
 
 type DBConfig struct {
-	User     string `yaml:"User" validate:"nonzero"`
-	Password string `yaml:"Password" validate:"nonzero"`
-	Socket   string `yaml:"Socket" validate:"nonzero"`
+	User            string        `yaml:"User" validate:"nonzero"`
+	Password        string        `yaml:"Password" validate:"nonzero"`
+	Socket          string        `yaml:"Socket" validate:"nonzero"`
+	QueryTimeout    time.Duration `yaml:"QueryTimeout"`
+	PingTimeout     time.Duration `yaml:"PingTimeout"`
+	MaxOpenConns    int           `yaml:"MaxOpenConns"`
+	MaxIdleConns    int           `yaml:"MaxIdleConns"`
+	ConnMaxLifetime time.Duration `yaml:"ConnMaxLifetime"`
+}
+
+// ApplyPoolLimits bounds the number and lifetime of connections db holds to
+// mysqld, so that bursts of health checks cannot exhaust its connections.
+func (d DBConfig) ApplyPoolLimits(db *sql.DB) {
+	db.SetMaxOpenConns(d.MaxOpenConns)
+	db.SetMaxIdleConns(d.MaxIdleConns)
+	db.SetConnMaxLifetime(d.ConnMaxLifetime)
 }
 
 type MonitConfig struct {
-	Host                          string `yaml:"Host" validate:"nonzero"`
-	User                          string `yaml:"User" validate:"nonzero"`
-	Port                          string `yaml:"Port" validate:"nonzero"`
-	Password                      string `yaml:"Password" validate:"nonzero"`
-	MysqlStateFilePath            string `yaml:"MysqlStateFilePath"`
-	ServiceName                   string `yaml:"ServiceName" validate:"nonzero"`
-	GaleraInitStatusServerAddress string `yaml:"GaleraInitStatusServerAddress" validate:"nonzero"`
+	Host                          string        `yaml:"Host" validate:"nonzero"`
+	User                          string        `yaml:"User" validate:"nonzero"`
+	Port                          string        `yaml:"Port" validate:"nonzero"`
+	Password                      string        `yaml:"Password" validate:"nonzero"`
+	MysqlStateFilePath            string        `yaml:"MysqlStateFilePath"`
+	ServiceName                   string        `yaml:"ServiceName" validate:"nonzero"`
+	GaleraInitStatusServerAddress string        `yaml:"GaleraInitStatusServerAddress" validate:"nonzero"`
+	GaleraInitStatusServerScheme  string        `yaml:"GaleraInitStatusServerScheme"`
+	GaleraInitStatusServerPath    string        `yaml:"GaleraInitStatusServerPath"`
+	StartupTimeout                time.Duration `yaml:"StartupTimeout"`
+	StartupPollInterval           time.Duration `yaml:"StartupPollInterval"`
+	StartupPollJitter             float64       `yaml:"StartupPollJitter"`
+	StartupGracePolls             int           `yaml:"StartupGracePolls"`
+	GaleraInitRetryBudget         int           `yaml:"GaleraInitRetryBudget"`
+	RetryMaxAttempts              int           `yaml:"RetryMaxAttempts"`
+	RetryInitialInterval          time.Duration `yaml:"RetryInitialInterval"`
+	RetryMaxInterval              time.Duration `yaml:"RetryMaxInterval"`
+	UnixSocket                    string        `yaml:"UnixSocket"`
+	RequestTimeout                time.Duration `yaml:"RequestTimeout"`
+	ManagedServices               []string      `yaml:"ManagedServices"`
+	BinaryPath                    string        `yaml:"BinaryPath"`
 }
 
+// SidecarEndpointConfig holds the basic auth credentials accepted by the
+// sidecar API. Username/Password is the primary pair; Credentials lists any
+// additional pairs, which allows rotating credentials without changing every
+// client at once. Realm is announced in the challenge sent with 401 responses.
 type SidecarEndpointConfig struct {
-	Username string `yaml:"Username" validate:"nonzero"`
-	Password string `yaml:"Password" validate:"nonzero"`
+	Username    string             `yaml:"Username"`
+	Password    string             `yaml:"Password"`
+	Credentials []CredentialConfig `yaml:"Credentials"`
+	Realm       string             `yaml:"Realm"`
+}
+
+type CredentialConfig struct {
+	Username string `yaml:"Username"`
+	Password string `yaml:"Password"`
+}
+
+// AllCredentials returns every credential pair accepted by the sidecar API.
+func (s SidecarEndpointConfig) AllCredentials() []CredentialConfig {
+	var credentials []CredentialConfig
+	if s.Username != "" || s.Password != "" {
+		credentials = append(credentials, CredentialConfig{
+			Username: s.Username,
+			Password: s.Password,
+		})
+	}
+	return append(credentials, s.Credentials...)
+}
+
+// ReplicationLagConfig makes the healthcheck fail when a numeric wsrep status
+// variable, such as wsrep_local_recv_queue_avg or wsrep_flow_control_paused,
+// exceeds Threshold. The check is disabled while Threshold is zero.
+type ReplicationLagConfig struct {
+	Variable  string  `yaml:"Variable"`
+	Threshold float64 `yaml:"Threshold"`
+}
+
+func (r ReplicationLagConfig) Enabled() bool {
+	return r.Threshold > 0
+}
+
+// EndpointTimeoutsConfig bounds how long the API spends on a request. Mutating
+// endpoints wait for galera-init during startup, so their timeout should
+// exceed Monit.StartupTimeout. A zero timeout disables the limit.
+type EndpointTimeoutsConfig struct {
+	Read     time.Duration `yaml:"Read"`
+	Mutating time.Duration `yaml:"Mutating"`
+}
+
+// FlowControlConfig makes the healthcheck fail when the node spent more than
+// MaxPausedFraction of the time since the previous check paused by flow
+// control. The check is disabled while MaxPausedFraction is zero.
+type FlowControlConfig struct {
+	MaxPausedFraction float64 `yaml:"MaxPausedFraction"`
+}
+
+func (f FlowControlConfig) Enabled() bool {
+	return f.MaxPausedFraction > 0
+}
+
+// ConfChangesConfig makes the healthcheck fail while the cluster
+// membership is flapping: when wsrep_cluster_conf_id changed more than
+// MaxChanges times within Window. The check is disabled while MaxChanges is
+// zero.
+type ConfChangesConfig struct {
+	MaxChanges int           `yaml:"MaxChanges"`
+	Window     time.Duration `yaml:"Window"`
+}
+
+func (c ConfChangesConfig) Enabled() bool {
+	return c.MaxChanges > 0
+}
+
+// CORSConfig lets browsers on AllowedOrigins call the API's GET endpoints.
+// CORS is disabled while AllowedOrigins is empty. An entry of "*" allows any
+// origin, but without credentials.
+type CORSConfig struct {
+	AllowedOrigins []string `yaml:"AllowedOrigins"`
+	AllowedMethods []string `yaml:"AllowedMethods"`
+}
+
+func (c CORSConfig) Enabled() bool {
+	return len(c.AllowedOrigins) > 0
+}
+
+// AllowlistConfig restricts the endpoints that stop and start mysqld to
+// clients whose address is in one of AllowedCIDRs. Requests from
+// TrustedProxies are attributed to the client named in their
+// X-Forwarded-For header. The allowlist is disabled while AllowedCIDRs is
+// empty.
+type AllowlistConfig struct {
+	AllowedCIDRs   []string `yaml:"AllowedCIDRs"`
+	TrustedProxies []string `yaml:"TrustedProxies"`
+}
+
+func (a AllowlistConfig) Enabled() bool {
+	return len(a.AllowedCIDRs) > 0
+}
+
+// AllowedNetworks parses AllowedCIDRs. Validate reports any that are invalid.
+func (a AllowlistConfig) AllowedNetworks() []*net.IPNet {
+	return parseCIDRs(a.AllowedCIDRs)
+}
+
+// TrustedProxyNetworks parses TrustedProxies. Validate reports any that are
+// invalid.
+func (a AllowlistConfig) TrustedProxyNetworks() []*net.IPNet {
+	return parseCIDRs(a.TrustedProxies)
+}
+
+func parseCIDRs(cidrs []string) []*net.IPNet {
+	var networks []*net.IPNet
+	for _, cidr := range cidrs {
+		if _, network, err := net.ParseCIDR(cidr); err == nil {
+			networks = append(networks, network)
+		}
+	}
+	return networks
+}
+
+// StateWebhookConfig has the sidecar POST to URL whenever the node becomes
+// healthy or unhealthy, at most once per MinInterval. Node identifies the
+// node in the payload and defaults to the hostname. The webhook is disabled
+// while URL is empty.
+type StateWebhookConfig struct {
+	URL         string        `yaml:"URL"`
+	Node        string        `yaml:"Node"`
+	MinInterval time.Duration `yaml:"MinInterval"`
+	Timeout     time.Duration `yaml:"Timeout"`
+}
+
+func (s StateWebhookConfig) Enabled() bool {
+	return s.URL != ""
+}
+
+type TLSConfig struct {
+	CertificatePath string `yaml:"CertificatePath"`
+	PrivateKeyPath  string `yaml:"PrivateKeyPath"`
+	ClientCAPath    string `yaml:"ClientCAPath"`
+}
+
+const redacted = "[REDACTED]"
+
+// Redacted returns a copy of the config that is safe to show to operators,
+// with every username and password replaced. The webhook URL keeps its host
+// and path but loses its userinfo and query string, which may carry tokens.
+func (c Config) Redacted() Config {
+	redact := func(secret string) string {
+		if secret == "" {
+			return ""
+		}
+		return redacted
+	}
+
+	c.DB.User = redact(c.DB.User)
+	c.DB.Password = redact(c.DB.Password)
+	c.Monit.User = redact(c.Monit.User)
+	c.Monit.Password = redact(c.Monit.Password)
+	c.SidecarEndpoint.Username = redact(c.SidecarEndpoint.Username)
+	c.SidecarEndpoint.Password = redact(c.SidecarEndpoint.Password)
+
+	credentials := make([]CredentialConfig, len(c.SidecarEndpoint.Credentials))
+	for i, credential := range c.SidecarEndpoint.Credentials {
+		credentials[i] = CredentialConfig{
+			Username: redact(credential.Username),
+			Password: redact(credential.Password),
+		}
+	}
+	c.SidecarEndpoint.Credentials = credentials
+	c.StateWebhook.URL = redactURL(c.StateWebhook.URL)
+
+	return c
+}
+
+func redactURL(raw string) string {
+	if raw == "" {
+		return ""
+	}
+
+	u, err := url.Parse(raw)
+	if err != nil {
+		return redacted
+	}
+
+	hasUser, hasQuery, fragment := u.User != nil, u.RawQuery != "", u.Fragment
+	u.User = nil
+	u.RawQuery = ""
+	u.ForceQuery = false
+	u.Fragment = ""
+
+	result := u.String()
+	if hasUser {
+		result = strings.Replace(result, "//", "//"+redacted+"@", 1)
+	}
+	if hasQuery {
+		result += "?" + redacted
+	}
+	if fragment != "" {
+		result += "#" + fragment
+	}
+	return result
+}
+
+func (t TLSConfig) Enabled() bool {
+	return t.CertificatePath != "" || t.PrivateKeyPath != ""
 }
 
 func defaultConfig() *Config {
@@ -52,15 +353,75 @@ func defaultConfig() *Config {
 		Host: "0.0.0.0",
 		Port: 8080,
 		DB: DBConfig{
-			Socket:   "/var/vcap/sys/run/pxc-mysql/mysqld.sock",
-			User:     "root",
-			Password: "",
+			Socket:          "/var/vcap/sys/run/pxc-mysql/mysqld.sock",
+			User:            "root",
+			Password:        "",
+			QueryTimeout:    2 * time.Second,
+			PingTimeout:     1 * time.Second,
+			MaxOpenConns:    2,
+			MaxIdleConns:    1,
+			ConnMaxLifetime: 5 * time.Minute,
+		},
+		Monit: MonitConfig{
+			StartupTimeout:      1 * time.Hour,
+			StartupPollInterval: 1 * time.Second,
+			StartupGracePolls:   5,
+			RequestTimeout:      5 * time.Second,
+			BinaryPath:          "/var/vcap/bosh/bin/monit",
+
+			GaleraInitStatusServerScheme: "http",
+			GaleraInitRetryBudget:        10,
 		},
 		AvailableWhenDonor:    true,
 		AvailableWhenReadOnly: false,
+		AllowedStates:         []string{string(domain.SyncedString)},
+		ReplicationLag: ReplicationLagConfig{
+			Variable: "wsrep_local_recv_queue_avg",
+		},
+		ClusterConfChanges: ConfChangesConfig{
+			Window: 5 * time.Minute,
+		},
+		ShutdownTimeout: 30 * time.Second,
+		LivenessTimeout: 2 * time.Second,
+
+		ReadyPollInterval: 1 * time.Second,
+		MaxReadyTimeout:   5 * time.Minute,
+		Role:              RoleReplica,
+
+		UnhealthyStatusCode: http.StatusServiceUnavailable,
+		CORS: CORSConfig{
+			AllowedMethods: []string{"GET", "HEAD"},
+		},
+		ResponseHeaders: map[string]string{
+			"Cache-Control": "no-store, no-cache",
+		},
+		EndpointTimeouts: EndpointTimeoutsConfig{
+			Read:     10 * time.Second,
+			Mutating: 90 * time.Minute,
+		},
+		ArbitratorSeqnoFormat:    ArbitratorSeqnoMessage,
+		LogFormat:                LogFormatJSON,
+		MaxRequestBodyBytes:      64 * 1024,
+		GracefulStopTimeout:      30 * time.Second,
+		GracefulStopPollInterval: 1 * time.Second,
+		StateWebhook: StateWebhookConfig{
+			MinInterval: 30 * time.Second,
+			Timeout:     5 * time.Second,
+		},
 	}
 }
 
+var wsrepVariablePattern = regexp.MustCompile(`^wsrep_[a-z_]+$`)
+
+func hasSubexp(pattern *regexp.Regexp, name string) bool {
+	for _, subexp := range pattern.SubexpNames() {
+		if subexp == name {
+			return true
+		}
+	}
+	return false
+}
+
 func NewConfig(osArgs []string) (*Config, error) {
 	var rootConfig Config
 
@@ -75,9 +436,20 @@ func NewConfig(osArgs []string) (*Config, error) {
 	serviceConfig.AddDefaults(defaultConfig())
 	flags.Parse(configurationOptions)
 
-	rootConfig.Logger, _ = lagerflags.NewFromConfig(binaryName, lagerflags.ConfigFromFlags())
-
 	err := serviceConfig.Read(&rootConfig)
+	if err == nil {
+		err = rootConfig.applyEnv(os.LookupEnv)
+	}
+
+	flagConfig := lagerflags.ConfigFromFlags()
+	logger, logErr := rootConfig.newLogger(binaryName, os.Stdout, flagConfig)
+	if logErr != nil {
+		// An invalid LogLevel is reported by Validate, which needs a logger
+		// to report it with.
+		logger, _ = lagerflags.NewFromConfig(binaryName, flagConfig)
+	}
+	rootConfig.Logger = logger
+
 	return &rootConfig, err
 }
 
@@ -88,6 +460,161 @@ func (c Config) Validate() error {
 		errString = formatErrorString(rootConfigErr, "")
 	}
 
+	if c.Port < 0 || c.Port > 65535 {
+		errString += fmt.Sprintf("Port : %d is not a valid port\n", c.Port)
+	}
+
+	if c.AdminPort < 0 || c.AdminPort > 65535 {
+		errString += fmt.Sprintf("AdminPort : %d is not a valid port\n", c.AdminPort)
+	} else if c.SplitAdmin() && c.AdminBindAddress() == c.BindAddress() {
+		errString += "AdminPort : must differ from Port\n"
+	}
+
+	if c.MaxRequestBodyBytes < 0 {
+		errString += fmt.Sprintf("MaxRequestBodyBytes : %d must not be negative\n", c.MaxRequestBodyBytes)
+	}
+
+	switch c.LogLevel {
+	case "", lagerflags.DEBUG, lagerflags.INFO, lagerflags.ERROR, lagerflags.FATAL:
+	default:
+		errString += fmt.Sprintf("LogLevel : %q must be one of debug, info, error or fatal\n", c.LogLevel)
+	}
+
+	switch c.LogFormat {
+	case "", LogFormatJSON, LogFormatText:
+	default:
+		errString += fmt.Sprintf("LogFormat : %q must be %q or %q\n", c.LogFormat, LogFormatJSON, LogFormatText)
+	}
+
+	if c.GracefulStopTimeout < 0 {
+		errString += fmt.Sprintf("GracefulStopTimeout : %s must not be negative\n", c.GracefulStopTimeout)
+	}
+
+	if c.MinClusterSize < 0 {
+		errString += fmt.Sprintf("MinClusterSize : %d must not be negative\n", c.MinClusterSize)
+	}
+
+	if c.Monit.StartupGracePolls < 0 {
+		errString += fmt.Sprintf("Monit.StartupGracePolls : %d must not be negative\n", c.Monit.StartupGracePolls)
+	}
+
+	if c.Monit.GaleraInitRetryBudget < 0 {
+		errString += fmt.Sprintf("Monit.GaleraInitRetryBudget : %d must not be negative\n", c.Monit.GaleraInitRetryBudget)
+	}
+
+	switch c.Monit.GaleraInitStatusServerScheme {
+	case "", "http", "https":
+	default:
+		errString += "Monit.GaleraInitStatusServerScheme : must be \"http\" or \"https\"\n"
+	}
+
+	if c.FlowControl.MaxPausedFraction < 0 || c.FlowControl.MaxPausedFraction > 1 {
+		errString += fmt.Sprintf("FlowControl.MaxPausedFraction : %v is not between 0 and 1\n", c.FlowControl.MaxPausedFraction)
+	}
+
+	if c.Monit.StartupPollJitter < 0 || c.Monit.StartupPollJitter >= 1 {
+		errString += fmt.Sprintf("Monit.StartupPollJitter : %v is not between 0 and 1\n", c.Monit.StartupPollJitter)
+	}
+
+	if c.JoinerGracePeriod < 0 {
+		errString += "JoinerGracePeriod : must not be negative\n"
+	}
+
+	if c.MaxReadyTimeout < 0 {
+		errString += "MaxReadyTimeout : must not be negative\n"
+	}
+
+	if c.ClusterConfChanges.MaxChanges < 0 {
+		errString += "ClusterConfChanges.MaxChanges : must not be negative\n"
+	}
+
+	if c.ClusterConfChanges.Enabled() && c.ClusterConfChanges.Window <= 0 {
+		errString += "ClusterConfChanges.Window : must be positive\n"
+	}
+
+	for name := range c.ResponseHeaders {
+		if name == "" || strings.ContainsAny(name, " \t\r\n:") {
+			errString += fmt.Sprintf("ResponseHeaders : %q is not a valid header name\n", name)
+		}
+	}
+
+	for _, cidr := range c.MutatingAllowlist.AllowedCIDRs {
+		if _, _, err := net.ParseCIDR(cidr); err != nil {
+			errString += fmt.Sprintf("MutatingAllowlist.AllowedCIDRs : %q is not a CIDR\n", cidr)
+		}
+	}
+
+	for _, cidr := range c.MutatingAllowlist.TrustedProxies {
+		if _, _, err := net.ParseCIDR(cidr); err != nil {
+			errString += fmt.Sprintf("MutatingAllowlist.TrustedProxies : %q is not a CIDR\n", cidr)
+		}
+	}
+
+	if c.Role != "" && !IsValidRole(c.Role) {
+		errString += fmt.Sprintf("Role : must be %q or %q\n", RolePrimary, RoleReplica)
+	}
+
+	if c.BasePath != "" && (!strings.HasPrefix(c.BasePath, "/") || strings.HasSuffix(c.BasePath, "/")) {
+		errString += fmt.Sprintf("BasePath : %q must start with / and must not end with /\n", c.BasePath)
+	}
+
+	if c.UnhealthyStatusCode != 0 && (c.UnhealthyStatusCode < 400 || c.UnhealthyStatusCode > 599) {
+		errString += fmt.Sprintf("UnhealthyStatusCode : %d is not an HTTP error status\n", c.UnhealthyStatusCode)
+	}
+
+	sidecar := c.SidecarEndpoint
+	if len(sidecar.Credentials) == 0 || sidecar.Username != "" || sidecar.Password != "" {
+		if sidecar.Username == "" {
+			errString += "SidecarEndpoint.Username : zero value\n"
+		}
+		if sidecar.Password == "" {
+			errString += "SidecarEndpoint.Password : zero value\n"
+		}
+	}
+
+	for i, credential := range sidecar.Credentials {
+		if credential.Username == "" || credential.Password == "" {
+			errString += fmt.Sprintf("SidecarEndpoint.Credentials[%d] : username and password are required\n", i)
+		}
+	}
+
+	if strings.ContainsAny(sidecar.Realm, "\"\\") {
+		errString += "SidecarEndpoint.Realm : must not contain quotes or backslashes\n"
+	}
+
+	if c.TLS.Enabled() {
+		if c.TLS.CertificatePath == "" {
+			errString += "TLS.CertificatePath : zero value\n"
+		}
+		if c.TLS.PrivateKeyPath == "" {
+			errString += "TLS.PrivateKeyPath : zero value\n"
+		}
+	}
+
+	if c.WsrepRecoverPattern != "" {
+		if pattern, err := regexp.Compile(c.WsrepRecoverPattern); err != nil {
+			errString += fmt.Sprintf("WsrepRecoverPattern : %s\n", err)
+		} else if !hasSubexp(pattern, "seqno") {
+			errString += "WsrepRecoverPattern : must have a (?P<seqno>...) group\n"
+		}
+	}
+
+	if c.ReplicationLag.Enabled() && !wsrepVariablePattern.MatchString(c.ReplicationLag.Variable) {
+		errString += "ReplicationLag.Variable : must be a wsrep status variable\n"
+	}
+
+	switch c.ArbitratorSeqnoFormat {
+	case "", ArbitratorSeqnoMessage, ArbitratorSeqnoSentinel, ArbitratorSeqnoStructured:
+	default:
+		errString += fmt.Sprintf("ArbitratorSeqnoFormat : must be %q, %q or %q\n", ArbitratorSeqnoMessage, ArbitratorSeqnoSentinel, ArbitratorSeqnoStructured)
+	}
+
+	switch c.ResponseFormat {
+	case "", ResponseFormatText, ResponseFormatJSON:
+	default:
+		errString += fmt.Sprintf("ResponseFormat : must be %q or %q\n", ResponseFormatText, ResponseFormatJSON)
+	}
+
 	if len(errString) > 0 {
 		return errors.New(fmt.Sprintf("Validation errors: %s\n", errString))
 	}
@@ -103,10 +630,54 @@ func formatErrorString(err error, keyPrefix string) string {
 	return errsString
 }
 
+// BindAddress is the address the sidecar API listens on, e.g. "0.0.0.0:8080"
+// or "[::1]:8080".
+func (c *Config) BindAddress() string {
+	return net.JoinHostPort(c.Host, strconv.Itoa(c.Port))
+}
+
+// SplitAdmin reports whether the admin endpoints are served separately from
+// the public health endpoints, on AdminPort.
+func (c *Config) SplitAdmin() bool {
+	return c.AdminPort != 0
+}
+
+// AdminBindAddress is the address the admin endpoints listen on when
+// SplitAdmin is set. AdminHost defaults to Host.
+func (c *Config) AdminBindAddress() string {
+	host := c.AdminHost
+	if host == "" {
+		host = c.Host
+	}
+	return net.JoinHostPort(host, strconv.Itoa(c.AdminPort))
+}
+
+// IsArbitrator reports whether this node runs the galera arbitrator (garbd)
+// rather than a database.
+func (c *Config) IsArbitrator() bool {
+	return c.Monit.ServiceName == "garbd"
+}
+
 func (c *Config) IsHealthy(state domain.DBState) bool {
 	if state.ReadOnly && !c.AvailableWhenReadOnly {
 		return false
 	}
 
-	return (domain.WsrepLocalState(state.WsrepLocalState) == domain.Synced) || (domain.WsrepLocalState(state.WsrepLocalState) == domain.DonorDesynced && c.AvailableWhenDonor)
+	return (domain.WsrepLocalState(state.WsrepLocalState) == domain.Synced) || (domain.WsrepLocalState(state.WsrepLocalState) == domain.DonorDesynced && c.AvailableWhenDonor) || c.IsAllowedState(state.WsrepLocalState)
+}
+
+// IsAllowedState reports whether a node in the given wsrep state should be
+// considered healthy. Synced is always allowed; AllowedStates lists any
+// additional states by their wsrep_local_state_comment, e.g. "Donor/Desynced".
+func (c *Config) IsAllowedState(state domain.WsrepLocalState) bool {
+	if state == domain.Synced {
+		return true
+	}
+
+	for _, allowed := range c.AllowedStates {
+		if strings.EqualFold(allowed, string(state.Comment())) {
+			return true
+		}
+	}
+	return false
 }
@@ -0,0 +1,55 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// EnvPrefix starts the name of every environment variable that overrides a
+// config field.
+const EnvPrefix = "GALERA_HC_"
+
+// envOverrides maps environment variables to the config fields they
+// override. Values from the environment take precedence over the config
+// file, which takes precedence over the defaults.
+func (c *Config) envOverrides() map[string]interface{} {
+	return map[string]interface{}{
+		EnvPrefix + "HOST":           &c.Host,
+		EnvPrefix + "PORT":           &c.Port,
+		EnvPrefix + "LOG_LEVEL":      &c.LogLevel,
+		EnvPrefix + "USERNAME":       &c.SidecarEndpoint.Username,
+		EnvPrefix + "PASSWORD":       &c.SidecarEndpoint.Password,
+		EnvPrefix + "DB_USER":        &c.DB.User,
+		EnvPrefix + "DB_PASSWORD":    &c.DB.Password,
+		EnvPrefix + "DB_SOCKET":      &c.DB.Socket,
+		EnvPrefix + "MONIT_HOST":     &c.Monit.Host,
+		EnvPrefix + "MONIT_PORT":     &c.Monit.Port,
+		EnvPrefix + "MONIT_USER":     &c.Monit.User,
+		EnvPrefix + "MONIT_PASSWORD": &c.Monit.Password,
+		EnvPrefix + "SERVICE_NAME":   &c.Monit.ServiceName,
+		EnvPrefix + "STATE_FILE":     &c.Monit.MysqlStateFilePath,
+	}
+}
+
+// applyEnv overrides config fields with the environment variables that
+// lookupEnv finds. Variables that are not set leave the field alone.
+func (c *Config) applyEnv(lookupEnv func(string) (string, bool)) error {
+	for name, field := range c.envOverrides() {
+		value, ok := lookupEnv(name)
+		if !ok {
+			continue
+		}
+
+		switch field := field.(type) {
+		case *string:
+			*field = value
+		case *int:
+			parsed, err := strconv.Atoi(value)
+			if err != nil {
+				return fmt.Errorf("%s : %q is not an integer", name, value)
+			}
+			*field = parsed
+		}
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+
+	"code.cloudfoundry.org/lager"
+	"code.cloudfoundry.org/lager/lagerflags"
+)
+
+const (
+	LogFormatJSON = "json"
+	LogFormatText = "text"
+)
+
+// NewLogger builds a logger that writes lines at or above LogLevel to w in
+// the configured LogFormat.
+func (c Config) NewLogger(component string, w io.Writer) (lager.Logger, error) {
+	return c.newLogger(component, w, lagerflags.DefaultLagerConfig())
+}
+
+// newLogger is NewLogger honouring the lager command line flags. The flags'
+// log level applies only when the config does not set one.
+func (c Config) newLogger(component string, w io.Writer, flags lagerflags.LagerConfig) (lager.Logger, error) {
+	level := c.LogLevel
+	if level == "" {
+		level = flags.LogLevel
+	}
+
+	minLogLevel, err := lager.LogLevelFromString(level)
+	if err != nil {
+		return nil, err
+	}
+
+	var sink lager.Sink
+	switch {
+	case c.LogFormat == LogFormatText:
+		sink = &textSink{writer: w}
+	case flags.TimeFormat == lagerflags.FormatRFC3339:
+		sink = lager.NewPrettySink(w, lager.DEBUG)
+	default:
+		sink = lager.NewWriterSink(w, lager.DEBUG)
+	}
+
+	if flags.RedactSecrets {
+		sink, err = lager.NewRedactingSink(sink, nil, nil)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	logger := lager.NewLogger(component)
+	logger.RegisterSink(lager.NewReconfigurableSink(sink, minLogLevel))
+	return logger, nil
+}
+
+// textSink writes each log entry as a single human readable line of
+// timestamp, level and message followed by the data as sorted key=value
+// pairs.
+type textSink struct {
+	writer io.Writer
+	mu     sync.Mutex
+}
+
+func (s *textSink) Log(log lager.LogFormat) {
+	var line bytes.Buffer
+	fmt.Fprintf(&line, "%s %s %s", log.Timestamp, log.LogLevel, log.Message)
+
+	keys := make([]string, 0, len(log.Data))
+	for key := range log.Data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		fmt.Fprintf(&line, " %s=%v", key, log.Data[key])
+	}
+	line.WriteString("\n")
+
+	s.mu.Lock()
+	s.writer.Write(line.Bytes())
+	s.mu.Unlock()
+}
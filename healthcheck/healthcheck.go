@@ -1,15 +1,26 @@
 package healthcheck
 
 import (
+	"context"
 	"database/sql"
+	"database/sql/driver"
 	"errors"
 	"fmt"
+	"net"
+	"strconv"
 	"strings"
+	"sync"
+	"syscall"
+	"time"
 
 	"net/http"
+	"regexp"
 
 	"code.cloudfoundry.org/lager"
 	"github.com/cloudfoundry-incubator/galera-healthcheck/config"
+	"github.com/cloudfoundry-incubator/galera-healthcheck/domain"
+	"github.com/cloudfoundry-incubator/galera-healthcheck/metrics"
+	"github.com/cloudfoundry-incubator/galera-healthcheck/webhook"
 )
 
 const (
@@ -19,10 +30,131 @@ const (
 	STATE_SYNCED         = 4
 )
 
+// Roles classify a node by its part in state transfers. A donor is streaming
+// a snapshot to a joiner and is slow until the transfer completes, even when
+// it is configured to keep receiving traffic.
+const (
+	RoleDonor  = "donor"
+	RoleJoiner = "joiner"
+	RoleMember = "member"
+)
+
+// ClassifyRole returns the role of a node in the given wsrep state.
+func ClassifyRole(state domain.WsrepLocalState) string {
+	switch state {
+	case domain.DonorDesynced:
+		return RoleDonor
+	case domain.Joining, domain.Joined:
+		return RoleJoiner
+	default:
+		return RoleMember
+	}
+}
+
 type HealthChecker struct {
 	db     *sql.DB
 	config config.Config
 	logger lager.Logger
+
+	// Metrics, when set, records the last observed wsrep_local_state.
+	Metrics *metrics.Registry
+	// Now returns the current time. Defaults to time.Now.
+	Now func() time.Time
+	// Webhook, when set, is told the outcome of every check so that it can
+	// report the node becoming healthy or unhealthy.
+	Webhook *webhook.Notifier
+
+	flowControlMu   sync.Mutex
+	flowControlPrev *flowControlSample
+
+	confChangesMu sync.Mutex
+	confID        *int64
+	confChanges   []time.Time
+
+	joinerMu        sync.Mutex
+	joinerSince     time.Time
+	joinerEscalated bool
+
+	cacheMu sync.Mutex
+	cached  *cachedCheck
+}
+
+type cachedCheck struct {
+	status  CheckStatus
+	err     error
+	expires time.Time
+}
+
+type flowControlSample struct {
+	pausedNs int64
+	at       time.Time
+}
+
+// UnreachableError is returned by Check when mysqld cannot be reached, as
+// opposed to when it reports an unhealthy wsrep state.
+type UnreachableError struct {
+	Err        error
+	statusCode int
+}
+
+func (e UnreachableError) Error() string {
+	return e.Err.Error()
+}
+
+func (e UnreachableError) Unwrap() error {
+	return e.Err
+}
+
+// StatusCode is the configured UnhealthyStatusCode, or 503 by default.
+func (e UnreachableError) StatusCode() int {
+	if e.statusCode == 0 {
+		return http.StatusServiceUnavailable
+	}
+	return e.statusCode
+}
+
+// UnhealthyError is returned by Check when mysqld answered but the node is
+// not fit to serve traffic, for example because it is not synced.
+type UnhealthyError struct {
+	Err        error
+	statusCode int
+}
+
+func (e UnhealthyError) Error() string {
+	return e.Err.Error()
+}
+
+func (e UnhealthyError) Unwrap() error {
+	return e.Err
+}
+
+// StatusCode is the configured UnhealthyStatusCode, or 503 by default.
+func (e UnhealthyError) StatusCode() int {
+	if e.statusCode == 0 {
+		return http.StatusServiceUnavailable
+	}
+	return e.statusCode
+}
+
+// CheckStatus is the outcome of Check together with the wsrep status it was
+// judged against, for callers that want more than the state string.
+type CheckStatus struct {
+	State             string  `json:"state"`
+	Ready             bool    `json:"ready"`
+	Reason            string  `json:"reason,omitempty"`
+	LocalState        int     `json:"local_state"`
+	LocalStateComment string  `json:"local_state_comment"`
+	ClusterStatus     string  `json:"cluster_status"`
+	ClusterSize       int     `json:"cluster_size"`
+	FlowControlPaused float64 `json:"flow_control_paused"`
+}
+
+var checkStatusVariables = []string{
+	"wsrep_local_state",
+	"wsrep_local_state_comment",
+	"wsrep_cluster_status",
+	"wsrep_cluster_size",
+	"wsrep_flow_control_paused",
 }
 
 func New(db *sql.DB, config config.Config, logger lager.Logger) *HealthChecker {
@@ -33,70 +165,802 @@ func New(db *sql.DB, config config.Config, logger lager.Logger) *HealthChecker {
 	}
 }
 
+// Ping reports whether the database connection is up. Unlike Check it does
+// not inspect wsrep state, so it answers promptly even on a hung Galera node.
+func (h *HealthChecker) Ping(ctx context.Context) error {
+	return h.db.PingContext(ctx)
+}
+
+// PingDB is Ping bounded by DB.PingTimeout. It tells whether a failing
+// health check is down to database connectivity rather than wsrep state.
+func (h *HealthChecker) PingDB() error {
+	ctx := context.Background()
+	if h.config.DB.PingTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, h.config.DB.PingTimeout)
+		defer cancel()
+	}
+
+	return h.Ping(ctx)
+}
+
 func (h *HealthChecker) CheckReq(req *http.Request) (string, error) {
 	return h.Check()
 }
 
+// Check reports the node's health as the State of CheckStatus.
 func (h *HealthChecker) Check() (string, error) {
-	if h.config.Monit.ServiceName == "garbd" {
-		return "", errors.New("arbitrator node")
+	status, err := h.CheckStatus()
+	return status.State, err
+}
+
+// ResetCache discards any cached result so that the next Check queries the
+// database.
+func (h *HealthChecker) ResetCache() {
+	h.cacheMu.Lock()
+	defer h.cacheMu.Unlock()
+
+	h.cached = nil
+}
+
+// CheckStatus reports the node's health together with the wsrep status it
+// was judged against, which is read with a single query. When the node is
+// unhealthy, Ready is false, Reason explains why and the error is returned.
+// It gives up and reports the node as unhealthy once DB.QueryTimeout elapses,
+// so that a deadlocked database does not hang the health check.
+//
+// When CheckCacheTTL is set, results are reused until they are that old.
+// Concurrent calls made while the result is stale share a single check.
+func (h *HealthChecker) CheckStatus() (CheckStatus, error) {
+	if h.config.CheckCacheTTL <= 0 {
+		return h.observedCheck()
 	}
 
-	var unused string
-	var value int
-	err := h.db.QueryRow("SHOW STATUS LIKE 'wsrep_local_state'").Scan(&unused, &value)
+	h.cacheMu.Lock()
+	defer h.cacheMu.Unlock()
 
-	if err == sql.ErrNoRows {
-		return "", errors.New("wsrep_local_state variable not set (possibly not a galera db)")
-	} else if err != nil {
-		if strings.Contains(err.Error(), "connection refused") {
-			return "", errors.New("Cannot get status from galera")
-		} else {
-			return "", err
+	if h.cached != nil && h.now().Before(h.cached.expires) {
+		return h.cached.status, h.cached.err
+	}
+
+	status, err := h.observedCheck()
+	h.cached = &cachedCheck{
+		status:  status,
+		err:     err,
+		expires: h.now().Add(h.config.CheckCacheTTL),
+	}
+	return status, err
+}
+
+// checkVariables are the status variables read by a check: those reported
+// by CheckStatus and those needed by the optional checks that are enabled.
+func (h *HealthChecker) checkVariables() []string {
+	names := append([]string{}, checkStatusVariables...)
+	if h.config.ReplicationLag.Enabled() {
+		names = append(names, h.config.ReplicationLag.Variable)
+	}
+	if h.config.FlowControl.Enabled() {
+		names = append(names, "wsrep_flow_control_paused_ns")
+	}
+	if h.config.ClusterConfChanges.Enabled() {
+		names = append(names, "wsrep_cluster_conf_id")
+	}
+	return names
+}
+
+func parseCheckStatus(variables map[string]string) (CheckStatus, error) {
+	status := CheckStatus{
+		LocalStateComment: variables["wsrep_local_state_comment"],
+		ClusterStatus:     variables["wsrep_cluster_status"],
+	}
+
+	var err error
+	if status.LocalState, err = atoiIfSet(variables, "wsrep_local_state"); err != nil {
+		return status, err
+	}
+	if status.ClusterSize, err = atoiIfSet(variables, "wsrep_cluster_size"); err != nil {
+		return status, err
+	}
+	if rawPaused, ok := variables["wsrep_flow_control_paused"]; ok {
+		if status.FlowControlPaused, err = strconv.ParseFloat(rawPaused, 64); err != nil {
+			return status, err
 		}
 	}
 
+	return status, nil
+}
+
+func atoiIfSet(variables map[string]string, name string) (int, error) {
+	rawValue, ok := variables[name]
+	if !ok {
+		return 0, nil
+	}
+	return strconv.Atoi(rawValue)
+}
+
+func (h *HealthChecker) observedCheck() (CheckStatus, error) {
+	status, err := h.checkWithTimeout()
+	status.Ready = err == nil
+	if err != nil {
+		status.State = ""
+		status.Reason = err.Error()
+		h.Webhook.Observe(err.Error(), false)
+	} else {
+		h.Webhook.Observe(status.State, true)
+	}
+	return status, err
+}
+
+func (h *HealthChecker) checkWithTimeout() (CheckStatus, error) {
+	if h.config.IsArbitrator() {
+		return CheckStatus{}, errors.New("arbitrator node")
+	}
+
+	ctx, cancel := h.queryContext()
+	defer cancel()
+
+	type result struct {
+		status CheckStatus
+		err    error
+	}
+
+	done := make(chan result, 1)
+	go func() {
+		status, err := h.check(ctx)
+		done <- result{status: status, err: err}
+	}()
+
+	select {
+	case r := <-done:
+		return r.status, r.err
+	case <-ctx.Done():
+		return CheckStatus{}, h.unreachable(fmt.Errorf("timed out after %s waiting for database", h.config.DB.QueryTimeout))
+	}
+}
+
+func (h *HealthChecker) unreachable(err error) error {
+	return UnreachableError{Err: err, statusCode: h.config.UnhealthyStatusCode}
+}
+
+func (h *HealthChecker) unhealthy(err error) error {
+	return UnhealthyError{Err: err, statusCode: h.config.UnhealthyStatusCode}
+}
+
+func (h *HealthChecker) queryContext() (context.Context, context.CancelFunc) {
+	if h.config.DB.QueryTimeout > 0 {
+		return context.WithTimeout(context.Background(), h.config.DB.QueryTimeout)
+	}
+	return context.WithCancel(context.Background())
+}
+
+// check evaluates the node's health. The variables it reads are logged at
+// debug level for post-incident analysis.
+func (h *HealthChecker) check(ctx context.Context) (CheckStatus, error) {
+	snapshot := lager.Data{}
+	defer h.logger.Debug("wsrep-status", snapshot)
+
+	status, err := h.evaluate(withSnapshot(ctx, snapshot))
+	if err != nil {
+		return status, h.classify(err)
+	}
+	return status, nil
+}
+
+// classify reports a query that could not reach mysqld, or that ran out of
+// time, as unreachable so that it is answered with UnhealthyStatusCode
+// rather than 500.
+func (h *HealthChecker) classify(err error) error {
+	var unreachable UnreachableError
+	var unhealthy UnhealthyError
+	if errors.As(err, &unreachable) || errors.As(err, &unhealthy) {
+		return err
+	}
+
+	if errors.Is(err, context.DeadlineExceeded) {
+		return h.unreachable(fmt.Errorf("timed out after %s waiting for database", h.config.DB.QueryTimeout))
+	}
+
+	if isConnectionError(err) {
+		return h.unreachable(errors.New("Cannot get status from galera"))
+	}
+	return err
+}
+
+// isConnectionError reports whether err means mysqld could not be reached,
+// over TCP or its unix socket.
+func isConnectionError(err error) bool {
+	if err == driver.ErrBadConn || errors.Is(err, context.Canceled) {
+		return true
+	}
+
+	if errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.ENOENT) {
+		return true
+	}
+
+	var opErr *net.OpError
+	if errors.As(err, &opErr) {
+		return true
+	}
+
+	return strings.Contains(err.Error(), "connection refused")
+}
+
+// evaluate reads the check variables and judges the node's health against
+// them.
+func (h *HealthChecker) evaluate(ctx context.Context) (CheckStatus, error) {
+	variables, err := h.statusVariables(ctx, h.checkVariables()...)
+	if err != nil {
+		return CheckStatus{}, err
+	}
+
+	if _, ok := variables["wsrep_local_state"]; !ok {
+		return CheckStatus{}, errors.New("wsrep_local_state variable not set (possibly not a galera db)")
+	}
+
+	status, err := parseCheckStatus(variables)
+	if err != nil {
+		return status, err
+	}
+
+	status.State, err = h.assess(ctx, status.LocalState, variables)
+	return status, err
+}
+
+// assess judges the node's health from its wsrep_local_state and the other
+// check variables, returning the state reported when it is healthy.
+func (h *HealthChecker) assess(ctx context.Context, value int, variables map[string]string) (string, error) {
+	h.Metrics.SetWsrepLocalState(value)
+	if value != STATE_JOINING && value != STATE_JOINED {
+		h.resetJoiner()
+	}
+
+	if value != STATE_SYNCED && h.config.IsAllowedState(domain.WsrepLocalState(value)) {
+		return h.healthy(ctx, variables, strings.ToLower(string(domain.WsrepLocalState(value).Comment())))
+	}
+
 	switch value {
 	case STATE_JOINING:
-		return "", errors.New("joining")
+		return "", h.joinerError("joining")
 	case STATE_DONOR_DESYNCED:
 		if h.config.AvailableWhenDonor {
-			return h.healthy(value)
+			return h.healthy(ctx, variables, "synced")
 		}
-		return "", errors.New("not synced")
+		return "", h.unhealthy(errors.New("not synced"))
 	case STATE_JOINED:
-		return "", errors.New("joined")
+		return "", h.joinerError("joined")
 	case STATE_SYNCED:
-		return h.healthy(value)
+		return h.healthy(ctx, variables, "synced")
 	default:
 		return "", fmt.Errorf("Unrecognized state: %d", value)
 	}
+}
+
+// joinerError reports a node that is joining the cluster. Within
+// JoinerGracePeriod of the node becoming a joiner it reports "initializing":
+// still unhealthy, but expected while an IST or SST is under way. Once the
+// grace period is over it reports how long the node has been a joiner.
+func (h *HealthChecker) joinerError(state string) error {
+	grace := h.config.JoinerGracePeriod
+	if grace <= 0 {
+		return h.unhealthy(errors.New(state))
+	}
+
+	h.joinerMu.Lock()
+	defer h.joinerMu.Unlock()
+
+	now := h.now()
+	if h.joinerSince.IsZero() {
+		h.joinerSince = now
+	}
+
+	elapsed := now.Sub(h.joinerSince)
+	if elapsed < grace {
+		return h.unhealthy(errors.New("initializing"))
+	}
+
+	err := fmt.Errorf("%s for %s, exceeding grace period %s", state, elapsed, grace)
+	if !h.joinerEscalated {
+		h.joinerEscalated = true
+		h.logger.Error("joiner-grace-period-exceeded", err)
+	}
+	return h.unhealthy(err)
+}
+
+func (h *HealthChecker) resetJoiner() {
+	h.joinerMu.Lock()
+	defer h.joinerMu.Unlock()
 
+	h.joinerSince = time.Time{}
+	h.joinerEscalated = false
 }
 
-func (h *HealthChecker) healthy(value int) (string, error) {
+func (h *HealthChecker) healthy(ctx context.Context, variables map[string]string, state string) (string, error) {
+	// A node cut off in a minority partition can still report itself synced,
+	// but it no longer accepts writes from the rest of the cluster.
+	if !h.config.AvailableWhenNonPrimary {
+		clusterStatus, ok := variables["wsrep_cluster_status"]
+		if !ok {
+			return "", h.unhealthy(errors.New("wsrep_cluster_status variable not set"))
+		}
+
+		if clusterStatus != "Primary" {
+			return "", h.unhealthy(errors.New("non-primary"))
+		}
+	}
+
 	if !h.config.AvailableWhenReadOnly {
-		readOnly, err := h.isReadOnly()
+		readOnly, err := h.isReadOnly(ctx)
 		if err != nil {
 			return "", err
 		}
 
 		if readOnly {
-			return "", errors.New("read-only")
+			return "", h.unhealthy(errors.New("read-only"))
+		}
+	}
+
+	if h.config.ReplicationLag.Enabled() {
+		if err := h.checkReplicationLag(variables); err != nil {
+			return "", err
 		}
 	}
-	return "synced", nil
+
+	if h.config.FlowControl.Enabled() {
+		if err := h.checkFlowControl(variables); err != nil {
+			return "", err
+		}
+	}
+
+	if h.config.ClusterConfChanges.Enabled() {
+		if err := h.checkConfChanges(variables); err != nil {
+			return "", err
+		}
+	}
+
+	return state, nil
 }
 
-func (h *HealthChecker) isReadOnly() (bool, error) {
+// checkFlowControl fails when the node spent more than the configured
+// fraction of the time since the previous check paused by flow control.
+// wsrep_flow_control_paused_ns is cumulative, so each check compares it with
+// the value seen by the previous one. The first check only records a sample.
+func (h *HealthChecker) checkFlowControl(status map[string]string) error {
+	const variable = "wsrep_flow_control_paused_ns"
+
+	rawValue, ok := status[variable]
+	if !ok {
+		return fmt.Errorf("%s variable not set", variable)
+	}
+
+	pausedNs, err := strconv.ParseInt(rawValue, 10, 64)
+	if err != nil {
+		return err
+	}
+
+	h.flowControlMu.Lock()
+	defer h.flowControlMu.Unlock()
+
+	current := &flowControlSample{pausedNs: pausedNs, at: h.now()}
+	previous := h.flowControlPrev
+	h.flowControlPrev = current
+
+	// The counter restarts with mysqld or FLUSH STATUS.
+	if previous == nil || current.pausedNs < previous.pausedNs {
+		return nil
+	}
+
+	window := current.at.Sub(previous.at)
+	if window <= 0 {
+		return nil
+	}
+
+	paused := float64(current.pausedNs-previous.pausedNs) / float64(window.Nanoseconds())
+	if paused > h.config.FlowControl.MaxPausedFraction {
+		return h.unhealthy(fmt.Errorf("flow control paused %.2f of the last %s, exceeding %v", paused, window, h.config.FlowControl.MaxPausedFraction))
+	}
+	return nil
+}
+
+// checkConfChanges fails while wsrep_cluster_conf_id, which increments on
+// every membership change, has changed more than the configured number of
+// times within the window. It only sees changes between checks, so a burst
+// of changes between two checks counts once.
+func (h *HealthChecker) checkConfChanges(status map[string]string) error {
+	const variable = "wsrep_cluster_conf_id"
+
+	rawValue, ok := status[variable]
+	if !ok {
+		return fmt.Errorf("%s variable not set", variable)
+	}
+
+	confID, err := strconv.ParseInt(rawValue, 10, 64)
+	if err != nil {
+		return err
+	}
+
+	h.confChangesMu.Lock()
+	defer h.confChangesMu.Unlock()
+
+	now := h.now()
+	if h.confID != nil && *h.confID != confID {
+		h.confChanges = append(h.confChanges, now)
+	}
+	h.confID = &confID
+
+	window := h.config.ClusterConfChanges.Window
+	recent := h.confChanges[:0]
+	for _, at := range h.confChanges {
+		if now.Sub(at) < window {
+			recent = append(recent, at)
+		}
+	}
+	h.confChanges = recent
+
+	if len(recent) > h.config.ClusterConfChanges.MaxChanges {
+		return h.unhealthy(fmt.Errorf("%s changed %d times in the last %s, exceeding %d", variable, len(recent), window, h.config.ClusterConfChanges.MaxChanges))
+	}
+	return nil
+}
+
+func (h *HealthChecker) now() time.Time {
+	if h.Now != nil {
+		return h.Now()
+	}
+	return time.Now()
+}
+
+func (h *HealthChecker) checkReplicationLag(status map[string]string) error {
+	variable := h.config.ReplicationLag.Variable
+
+	rawValue, ok := status[variable]
+	if !ok {
+		return fmt.Errorf("%s variable not set", variable)
+	}
+
+	value, err := strconv.ParseFloat(rawValue, 64)
+	if err != nil {
+		return err
+	}
+
+	if value > h.config.ReplicationLag.Threshold {
+		return h.unhealthy(fmt.Errorf("%s %v exceeds threshold %v", variable, value, h.config.ReplicationLag.Threshold))
+	}
+	return nil
+}
+
+func (h *HealthChecker) isReadOnly(ctx context.Context) (bool, error) {
 	var unused, readOnly string
-	err := h.db.QueryRow("SHOW GLOBAL VARIABLES LIKE 'read_only'").Scan(&unused, &readOnly)
+	err := h.db.QueryRowContext(ctx, "SHOW GLOBAL VARIABLES LIKE 'read_only'").Scan(&unused, &readOnly)
 	if err != nil {
 		return false, err
 	}
+	recordVariable(ctx, "read_only", readOnly)
 
 	if readOnly == "ON" {
 		return true, nil
 	}
 	return false, nil
 }
+
+// WsrepStatusVariables are the variables reported by WsrepStatus.
+var WsrepStatusVariables = []string{
+	"wsrep_cluster_status",
+	"wsrep_connected",
+	"wsrep_ready",
+	"wsrep_local_state_comment",
+	"wsrep_cluster_size",
+}
+
+// WsrepMetricVariables are the numeric status variables reported by
+// WsrepMetrics.
+var WsrepMetricVariables = []string{
+	"wsrep_cluster_conf_id",
+	"wsrep_cluster_size",
+	"wsrep_flow_control_paused",
+	"wsrep_flow_control_paused_ns",
+	"wsrep_flow_control_recv",
+	"wsrep_flow_control_sent",
+	"wsrep_last_committed",
+	"wsrep_local_bf_aborts",
+	"wsrep_local_cert_failures",
+	"wsrep_local_index",
+	"wsrep_local_recv_queue",
+	"wsrep_local_recv_queue_avg",
+	"wsrep_local_send_queue",
+	"wsrep_local_send_queue_avg",
+	"wsrep_local_state",
+	"wsrep_received",
+	"wsrep_replicated",
+}
+
+// NodeIdentityStatusVariables and NodeIdentityGlobalVariables identify the
+// local node. WsrepStatus includes them when ReportNodeIdentity is set.
+var (
+	NodeIdentityStatusVariables = []string{"wsrep_gcomm_uuid"}
+	NodeIdentityGlobalVariables = []string{"wsrep_node_name", "wsrep_node_address"}
+)
+
+// QueryableWsrepVariables are the status variables that WsrepVariable will
+// look up. Names are checked against this list before they reach SHOW
+// STATUS.
+var QueryableWsrepVariables = []string{
+	"wsrep_cluster_conf_id",
+	"wsrep_cluster_size",
+	"wsrep_cluster_state_uuid",
+	"wsrep_cluster_status",
+	"wsrep_connected",
+	"wsrep_flow_control_paused",
+	"wsrep_gcomm_uuid",
+	"wsrep_last_committed",
+	"wsrep_local_index",
+	"wsrep_local_recv_queue_avg",
+	"wsrep_local_send_queue_avg",
+	"wsrep_local_state",
+	"wsrep_local_state_comment",
+	"wsrep_local_state_uuid",
+	"wsrep_ready",
+}
+
+// InvalidVariableError is returned by WsrepVariable for a name that is not
+// in QueryableWsrepVariables.
+type InvalidVariableError struct {
+	Name string
+}
+
+func (e InvalidVariableError) Error() string {
+	return fmt.Sprintf("%q is not a queryable wsrep variable", e.Name)
+}
+
+func (InvalidVariableError) StatusCode() int {
+	return http.StatusBadRequest
+}
+
+// VariableNotFoundError is returned by WsrepVariable when mysqld does not
+// report the variable, as happens when galera is not loaded.
+type VariableNotFoundError struct {
+	Name string
+}
+
+func (e VariableNotFoundError) Error() string {
+	return fmt.Sprintf("wsrep variable %q not found", e.Name)
+}
+
+func (VariableNotFoundError) StatusCode() int {
+	return http.StatusNotFound
+}
+
+// InvalidPatternError is returned by StatusLike for a pattern that is not a
+// variable name optionally followed by a single trailing %.
+type InvalidPatternError struct {
+	Pattern string
+}
+
+func (e InvalidPatternError) Error() string {
+	return fmt.Sprintf("%q is not a valid status pattern: use letters, digits and underscores, optionally ending in %%", e.Pattern)
+}
+
+func (InvalidPatternError) StatusCode() int {
+	return http.StatusBadRequest
+}
+
+// statusPattern is deliberately narrow, since the pattern is interpolated into
+// the query: quotes, spaces and backslashes can never get through.
+var statusPattern = regexp.MustCompile(`^[A-Za-z0-9_]+%?$`)
+
+// StatusLike returns the status variables whose names match pattern, as SHOW
+// STATUS LIKE does, keyed by lowercase variable name.
+func (h *HealthChecker) StatusLike(pattern string) (map[string]string, error) {
+	if !statusPattern.MatchString(pattern) {
+		return nil, InvalidPatternError{Pattern: pattern}
+	}
+
+	ctx, cancel := h.queryContext()
+	defer cancel()
+
+	rows, err := h.db.QueryContext(ctx, fmt.Sprintf("SHOW STATUS LIKE '%s'", pattern))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	status := map[string]string{}
+	for rows.Next() {
+		var name, value string
+		if err := rows.Scan(&name, &value); err != nil {
+			return nil, err
+		}
+		status[strings.ToLower(name)] = value
+	}
+
+	return status, rows.Err()
+}
+
+// WsrepVariable returns the value of a single wsrep status variable.
+func (h *HealthChecker) WsrepVariable(name string) (string, error) {
+	if !isQueryableWsrepVariable(name) {
+		return "", InvalidVariableError{Name: name}
+	}
+
+	ctx, cancel := h.queryContext()
+	defer cancel()
+
+	status, err := h.statusVariables(ctx, name)
+	if err != nil {
+		return "", err
+	}
+
+	value, ok := status[name]
+	if !ok {
+		return "", VariableNotFoundError{Name: name}
+	}
+	return value, nil
+}
+
+func isQueryableWsrepVariable(name string) bool {
+	for _, queryable := range QueryableWsrepVariables {
+		if name == queryable {
+			return true
+		}
+	}
+	return false
+}
+
+// WsrepStatus returns a snapshot of the key wsrep status variables, keyed by
+// variable name without the "wsrep_" prefix.
+func (h *HealthChecker) WsrepStatus() (map[string]string, error) {
+	ctx, cancel := h.queryContext()
+	defer cancel()
+
+	names := WsrepStatusVariables
+	if h.config.ReportNodeIdentity {
+		names = append(append([]string{}, names...), NodeIdentityStatusVariables...)
+	}
+
+	status, err := h.statusVariables(ctx, names...)
+	if err != nil {
+		return nil, err
+	}
+
+	if h.config.ReportNodeIdentity {
+		identity, err := h.globalVariables(ctx, NodeIdentityGlobalVariables...)
+		if err != nil {
+			return nil, err
+		}
+		for name, value := range identity {
+			status[name] = value
+		}
+	}
+
+	snapshot := map[string]string{}
+	for name, value := range status {
+		snapshot[strings.TrimPrefix(name, "wsrep_")] = value
+	}
+	return snapshot, nil
+}
+
+// WsrepMetrics returns the values of WsrepMetricVariables keyed by variable
+// name. Variables the server does not report, or reports with a non-numeric
+// value, are omitted.
+func (h *HealthChecker) WsrepMetrics() (map[string]float64, error) {
+	ctx, cancel := h.queryContext()
+	defer cancel()
+
+	status, err := h.statusVariables(ctx, WsrepMetricVariables...)
+	if err != nil {
+		return nil, err
+	}
+
+	values := map[string]float64{}
+	for name, rawValue := range status {
+		value, err := strconv.ParseFloat(rawValue, 64)
+		if err != nil {
+			continue
+		}
+		values[name] = value
+	}
+	return values, nil
+}
+
+// ClusterSize returns wsrep_cluster_size. It returns an UnhealthyError when
+// the node is not part of the primary component, since the size it reports is
+// then only that of its own partition.
+func (h *HealthChecker) ClusterSize() (int, error) {
+	ctx, cancel := h.queryContext()
+	defer cancel()
+
+	status, err := h.statusVariables(ctx, "wsrep_cluster_status", "wsrep_cluster_size")
+	if err != nil {
+		return 0, err
+	}
+
+	if clusterStatus := status["wsrep_cluster_status"]; clusterStatus != "Primary" {
+		return 0, h.unhealthy(fmt.Errorf("node is not part of the primary component (wsrep_cluster_status: %q)", clusterStatus))
+	}
+
+	rawSize, ok := status["wsrep_cluster_size"]
+	if !ok {
+		return 0, errors.New("wsrep_cluster_size variable not set (possibly not a galera db)")
+	}
+
+	return strconv.Atoi(rawSize)
+}
+
+// ClientConnections returns the number of connections to mysqld other than
+// the health checker's own.
+func (h *HealthChecker) ClientConnections() (int, error) {
+	ctx, cancel := h.queryContext()
+	defer cancel()
+
+	status, err := h.statusVariables(ctx, "Threads_connected")
+	if err != nil {
+		return 0, err
+	}
+
+	rawConnected, ok := status["threads_connected"]
+	if !ok {
+		return 0, errors.New("Threads_connected variable not set")
+	}
+
+	connected, err := strconv.Atoi(rawConnected)
+	if err != nil {
+		return 0, err
+	}
+
+	clients := connected - h.db.Stats().OpenConnections
+	if clients < 0 {
+		clients = 0
+	}
+	return clients, nil
+}
+
+// snapshotKey is the context key under which a check's variables are recorded.
+type snapshotKey struct{}
+
+// withSnapshot returns a context under which recordVariable stores the
+// variables read from the database in snapshot.
+func withSnapshot(ctx context.Context, snapshot lager.Data) context.Context {
+	return context.WithValue(ctx, snapshotKey{}, snapshot)
+}
+
+func recordVariable(ctx context.Context, name, value string) {
+	if snapshot, ok := ctx.Value(snapshotKey{}).(lager.Data); ok {
+		snapshot[name] = value
+	}
+}
+
+// statusVariables fetches the named status variables with a single SHOW
+// STATUS query. Variables the server does not report are omitted from the
+// result. Names must come from configuration or code, never from clients.
+func (h *HealthChecker) statusVariables(ctx context.Context, names ...string) (map[string]string, error) {
+	return h.show(ctx, "STATUS", names)
+}
+
+// globalVariables is statusVariables for SHOW GLOBAL VARIABLES.
+func (h *HealthChecker) globalVariables(ctx context.Context, names ...string) (map[string]string, error) {
+	return h.show(ctx, "GLOBAL VARIABLES", names)
+}
+
+func (h *HealthChecker) show(ctx context.Context, what string, names []string) (map[string]string, error) {
+	quoted := make([]string, len(names))
+	for i, name := range names {
+		quoted[i] = "'" + name + "'"
+	}
+
+	rows, err := h.db.QueryContext(ctx, fmt.Sprintf("SHOW %s WHERE Variable_name IN (%s)", what, strings.Join(quoted, ", ")))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	status := map[string]string{}
+	for rows.Next() {
+		var name, value string
+		if err := rows.Scan(&name, &value); err != nil {
+			return nil, err
+		}
+		status[strings.ToLower(name)] = value
+		recordVariable(ctx, strings.ToLower(name), value)
+	}
+
+	return status, rows.Err()
+}
@@ -1,17 +1,34 @@
 package healthcheck_test
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
 
 	"database/sql"
+	"database/sql/driver"
+	"time"
 
 	testdb "github.com/erikstmartin/go-testdb"
 
+	"code.cloudfoundry.org/lager"
 	"code.cloudfoundry.org/lager/lagertest"
 	"github.com/cloudfoundry-incubator/galera-healthcheck/config"
+	"github.com/cloudfoundry-incubator/galera-healthcheck/domain"
 	"github.com/cloudfoundry-incubator/galera-healthcheck/healthcheck"
+	"github.com/cloudfoundry-incubator/galera-healthcheck/metrics"
+	"github.com/cloudfoundry-incubator/galera-healthcheck/webhook"
 	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
 	. "github.com/onsi/gomega"
 )
 
@@ -157,12 +174,568 @@ var _ = Describe("GaleraHealthChecker", func() {
 				})
 			})
 
+			Context("when the node is synced", func() {
+				It("returns synced when the node is in the primary component", func() {
+					config := healthcheckTestHelperConfig{
+						wsrepStatus:   healthcheck.STATE_SYNCED,
+						clusterStatus: "Primary",
+					}
+
+					result, err := healthcheckTestHelper(config)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(result).To(Equal("synced"))
+				})
+
+				It("returns unhealthy when the node is in a non-primary component", func() {
+					config := healthcheckTestHelperConfig{
+						wsrepStatus:   healthcheck.STATE_SYNCED,
+						clusterStatus: "non-Primary",
+					}
+
+					_, err := healthcheckTestHelper(config)
+					Expect(err).To(MatchError("non-primary"))
+
+					var unhealthy healthcheck.UnhealthyError
+					Expect(errors.As(err, &unhealthy)).To(BeTrue())
+				})
+
+				It("returns unhealthy when wsrep_cluster_status is missing", func() {
+					db, _ := sql.Open("testdb", "")
+					testdb.StubQuery(checkStatusQuery(), testdb.RowsFromCSVString([]string{"Variable_name", "Value"}, fmt.Sprintf("wsrep_local_state,%d", healthcheck.STATE_SYNCED)))
+					healthchecker := healthcheck.New(db, config.Config{}, lagertest.NewTestLogger("healthcheck test"))
+
+					_, err := healthchecker.Check()
+					Expect(err).To(MatchError("wsrep_cluster_status variable not set"))
+
+					var unhealthy healthcheck.UnhealthyError
+					Expect(errors.As(err, &unhealthy)).To(BeTrue())
+				})
+
+				It("returns synced in a non-primary component when AvailableWhenNonPrimary is set", func() {
+					config := healthcheckTestHelperConfig{
+						wsrepStatus:             healthcheck.STATE_SYNCED,
+						clusterStatus:           "non-Primary",
+						availableWhenNonPrimary: true,
+					}
+
+					result, err := healthcheckTestHelper(config)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(result).To(Equal("synced"))
+				})
+			})
+
+			Context("when AllowedStates is configured", func() {
+				It("returns synced when the node is synced", func() {
+					config := healthcheckTestHelperConfig{
+						wsrepStatus:   healthcheck.STATE_SYNCED,
+						allowedStates: []string{"Synced"},
+					}
+
+					result, err := healthcheckTestHelper(config)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(result).To(Equal("synced"))
+				})
+
+				It("returns the state when the node is donor/desynced and donor/desynced is allowed", func() {
+					config := healthcheckTestHelperConfig{
+						wsrepStatus:   healthcheck.STATE_DONOR_DESYNCED,
+						allowedStates: []string{"Synced", "Donor/Desynced"},
+					}
+
+					result, err := healthcheckTestHelper(config)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(result).To(Equal("donor/desynced"))
+				})
+
+				It("returns not synced when the node is donor/desynced and donor/desynced is not allowed", func() {
+					config := healthcheckTestHelperConfig{
+						wsrepStatus:   healthcheck.STATE_DONOR_DESYNCED,
+						allowedStates: []string{"Synced"},
+					}
+
+					_, err := healthcheckTestHelper(config)
+					Expect(err).To(MatchError("not synced"))
+				})
+
+				It("still checks read_only for allowed states", func() {
+					config := healthcheckTestHelperConfig{
+						wsrepStatus:   healthcheck.STATE_JOINED,
+						readOnly:      true,
+						allowedStates: []string{"joined"},
+					}
+
+					_, err := healthcheckTestHelper(config)
+					Expect(err).To(MatchError("read-only"))
+				})
+			})
+
+			It("reports states that cannot serve traffic as unhealthy rather than failed", func() {
+				config := healthcheckTestHelperConfig{
+					wsrepStatus: healthcheck.STATE_JOINING,
+					readOnly:    false,
+				}
+
+				_, err := healthcheckTestHelper(config)
+
+				var unhealthy healthcheck.UnhealthyError
+				Expect(errors.As(err, &unhealthy)).To(BeTrue())
+				Expect(unhealthy.StatusCode()).To(Equal(http.StatusServiceUnavailable))
+			})
+
+			Context("when a replication lag threshold is configured", func() {
+				var healthchecker *healthcheck.HealthChecker
+
+				BeforeEach(func() {
+					db, _ := sql.Open("testdb", "")
+
+					columns := []string{"Variable_name", "Value"}
+					testdb.StubQuery("SHOW GLOBAL VARIABLES LIKE 'read_only'", testdb.RowsFromCSVString(columns, "read_only,OFF"))
+
+					healthchecker = healthcheck.New(db, config.Config{
+						ReplicationLag: config.ReplicationLagConfig{
+							Variable:  "wsrep_local_recv_queue_avg",
+							Threshold: 0.5,
+						},
+					}, lagertest.NewTestLogger("healthcheck test"))
+				})
+
+				It("returns synced when the variable is under the threshold", func() {
+					stubCheckStatus(healthcheck.STATE_SYNCED, "Primary", "wsrep_local_recv_queue_avg,0.25")
+
+					result, err := healthchecker.Check()
+					Expect(err).ToNot(HaveOccurred())
+					Expect(result).To(Equal("synced"))
+				})
+
+				It("returns an error when the variable exceeds the threshold", func() {
+					stubCheckStatus(healthcheck.STATE_SYNCED, "Primary", "wsrep_local_recv_queue_avg,2.75")
+
+					_, err := healthchecker.Check()
+					Expect(err).To(MatchError("wsrep_local_recv_queue_avg 2.75 exceeds threshold 0.5"))
+				})
+
+				It("returns an error when the variable cannot be queried", func() {
+					testdb.StubQueryError(checkStatusQuery("wsrep_local_recv_queue_avg"), errors.New("lag query error"))
+
+					_, err := healthchecker.Check()
+					Expect(err).To(MatchError("lag query error"))
+				})
+			})
+
+			Context("when logging at debug level", func() {
+				var (
+					healthchecker *healthcheck.HealthChecker
+					logger        *lagertest.TestLogger
+				)
+
+				BeforeEach(func() {
+					db, _ := sql.Open("testdb", "")
+
+					columns := []string{"Variable_name", "Value"}
+					testdb.StubQuery("SHOW GLOBAL VARIABLES LIKE 'read_only'", testdb.RowsFromCSVString(columns, "read_only,OFF"))
+					stubCheckStatus(healthcheck.STATE_SYNCED, "Primary", "wsrep_local_recv_queue_avg,0.25")
+
+					logger = lagertest.NewTestLogger("healthcheck test")
+					healthchecker = healthcheck.New(db, config.Config{
+						ReplicationLag: config.ReplicationLagConfig{
+							Variable:  "wsrep_local_recv_queue_avg",
+							Threshold: 0.5,
+						},
+					}, logger)
+				})
+
+				It("logs the wsrep variables read by each check", func() {
+					Expect(healthchecker.Check()).To(Equal("synced"))
+
+					var snapshots []lager.Data
+					for _, log := range logger.Logs() {
+						if log.Message == "healthcheck test.wsrep-status" {
+							Expect(log.LogLevel).To(Equal(lager.DEBUG))
+							snapshots = append(snapshots, log.Data)
+						}
+					}
+
+					Expect(snapshots).To(HaveLen(1))
+					Expect(snapshots[0]).To(HaveKeyWithValue("wsrep_local_state", "4"))
+					Expect(snapshots[0]).To(HaveKeyWithValue("wsrep_cluster_status", "Primary"))
+					Expect(snapshots[0]).To(HaveKeyWithValue("read_only", "OFF"))
+					Expect(snapshots[0]).To(HaveKeyWithValue("wsrep_local_recv_queue_avg", "0.25"))
+				})
+			})
+
+			Context("when a flow control threshold is configured", func() {
+				var (
+					healthchecker *healthcheck.HealthChecker
+					now           time.Time
+				)
+
+				var stubPausedNs = func(value string) {
+					stubCheckStatus(healthcheck.STATE_SYNCED, "Primary", "wsrep_flow_control_paused_ns,"+value)
+				}
+
+				BeforeEach(func() {
+					db, _ := sql.Open("testdb", "")
+
+					columns := []string{"Variable_name", "Value"}
+					testdb.StubQuery("SHOW GLOBAL VARIABLES LIKE 'read_only'", testdb.RowsFromCSVString(columns, "read_only,OFF"))
+
+					now = time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+					healthchecker = healthcheck.New(db, config.Config{
+						FlowControl: config.FlowControlConfig{MaxPausedFraction: 0.5},
+					}, lagertest.NewTestLogger("healthcheck test"))
+					healthchecker.Now = func() time.Time { return now }
+				})
+
+				It("passes the first check, which has nothing to compare against", func() {
+					stubPausedNs("900000000000")
+
+					Expect(healthchecker.Check()).To(Equal("synced"))
+				})
+
+				It("fails once the paused time since the previous check crosses the threshold", func() {
+					stubPausedNs("1000000000")
+					Expect(healthchecker.Check()).To(Equal("synced"))
+
+					now = now.Add(10 * time.Second)
+					stubPausedNs("3000000000")
+					Expect(healthchecker.Check()).To(Equal("synced"))
+
+					now = now.Add(10 * time.Second)
+					stubPausedNs("11000000000")
+					_, err := healthchecker.Check()
+					Expect(err).To(MatchError("flow control paused 0.80 of the last 10s, exceeding 0.5"))
+
+					now = now.Add(10 * time.Second)
+					stubPausedNs("12000000000")
+					Expect(healthchecker.Check()).To(Equal("synced"))
+				})
+
+				It("starts over when the counter is reset", func() {
+					stubPausedNs("50000000000")
+					Expect(healthchecker.Check()).To(Equal("synced"))
+
+					now = now.Add(10 * time.Second)
+					stubPausedNs("1000000000")
+					Expect(healthchecker.Check()).To(Equal("synced"))
+
+					now = now.Add(10 * time.Second)
+					stubPausedNs("2000000000")
+					Expect(healthchecker.Check()).To(Equal("synced"))
+				})
+			})
+
+			Context("when a joiner grace period is configured", func() {
+				var (
+					healthchecker *healthcheck.HealthChecker
+					logger        *lagertest.TestLogger
+					now           time.Time
+				)
+
+				var stubLocalState = func(state int) {
+					stubCheckStatus(state, "Primary")
+				}
+
+				BeforeEach(func() {
+					db, _ := sql.Open("testdb", "")
+
+					columns := []string{"Variable_name", "Value"}
+					testdb.StubQuery("SHOW GLOBAL VARIABLES LIKE 'read_only'", testdb.RowsFromCSVString(columns, "read_only,OFF"))
+
+					now = time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+					logger = lagertest.NewTestLogger("healthcheck test")
+					healthchecker = healthcheck.New(db, config.Config{
+						JoinerGracePeriod: 10 * time.Minute,
+					}, logger)
+					healthchecker.Now = func() time.Time { return now }
+				})
+
+				It("reports a joiner within the grace period as initializing", func() {
+					stubLocalState(healthcheck.STATE_JOINING)
+					_, err := healthchecker.Check()
+					Expect(err).To(MatchError("initializing"))
+
+					var unhealthy healthcheck.UnhealthyError
+					Expect(errors.As(err, &unhealthy)).To(BeTrue())
+
+					now = now.Add(9 * time.Minute)
+					stubLocalState(healthcheck.STATE_JOINED)
+					_, err = healthchecker.Check()
+					Expect(err).To(MatchError("initializing"))
+					Expect(logger.LogMessages()).NotTo(ContainElement("healthcheck test.joiner-grace-period-exceeded"))
+				})
+
+				It("escalates once the node has been a joiner for longer than the grace period", func() {
+					stubLocalState(healthcheck.STATE_JOINING)
+					_, err := healthchecker.Check()
+					Expect(err).To(MatchError("initializing"))
+
+					now = now.Add(11 * time.Minute)
+					_, err = healthchecker.Check()
+					Expect(err).To(MatchError("joining for 11m0s, exceeding grace period 10m0s"))
+
+					var unhealthy healthcheck.UnhealthyError
+					Expect(errors.As(err, &unhealthy)).To(BeTrue())
+					Expect(logger.LogMessages()).To(ContainElement("healthcheck test.joiner-grace-period-exceeded"))
+				})
+
+				It("starts the grace period over after the node syncs", func() {
+					stubLocalState(healthcheck.STATE_JOINING)
+					_, _ = healthchecker.Check()
+
+					now = now.Add(5 * time.Minute)
+					stubLocalState(healthcheck.STATE_SYNCED)
+					Expect(healthchecker.Check()).To(Equal("synced"))
+
+					now = now.Add(8 * time.Minute)
+					stubLocalState(healthcheck.STATE_JOINING)
+					_, err := healthchecker.Check()
+					Expect(err).To(MatchError("initializing"))
+				})
+			})
+
+			Context("when a cluster conf_id change limit is configured", func() {
+				var (
+					healthchecker *healthcheck.HealthChecker
+					now           time.Time
+				)
+
+				var stubConfID = func(value string) {
+					stubCheckStatus(healthcheck.STATE_SYNCED, "Primary", "wsrep_cluster_conf_id,"+value)
+				}
+
+				BeforeEach(func() {
+					db, _ := sql.Open("testdb", "")
+
+					columns := []string{"Variable_name", "Value"}
+					testdb.StubQuery("SHOW GLOBAL VARIABLES LIKE 'read_only'", testdb.RowsFromCSVString(columns, "read_only,OFF"))
+
+					now = time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+					healthchecker = healthcheck.New(db, config.Config{
+						ClusterConfChanges: config.ConfChangesConfig{
+							MaxChanges: 2,
+							Window:     time.Minute,
+						},
+					}, lagertest.NewTestLogger("healthcheck test"))
+					healthchecker.Now = func() time.Time { return now }
+				})
+
+				It("stays healthy while the conf_id is stable", func() {
+					for i := 0; i < 10; i++ {
+						stubConfID("12")
+						Expect(healthchecker.Check()).To(Equal("synced"))
+						now = now.Add(5 * time.Second)
+					}
+				})
+
+				It("fails while the conf_id changes too often within the window", func() {
+					stubConfID("12")
+					Expect(healthchecker.Check()).To(Equal("synced"))
+
+					now = now.Add(5 * time.Second)
+					stubConfID("13")
+					Expect(healthchecker.Check()).To(Equal("synced"))
+
+					now = now.Add(5 * time.Second)
+					stubConfID("14")
+					Expect(healthchecker.Check()).To(Equal("synced"))
+
+					now = now.Add(5 * time.Second)
+					stubConfID("15")
+					_, err := healthchecker.Check()
+					Expect(err).To(MatchError("wsrep_cluster_conf_id changed 3 times in the last 1m0s, exceeding 2"))
+
+					var unhealthy healthcheck.UnhealthyError
+					Expect(errors.As(err, &unhealthy)).To(BeTrue())
+				})
+
+				It("recovers once the changes age out of the window", func() {
+					for i, confID := range []string{"12", "13", "14", "15"} {
+						now = now.Add(time.Duration(i) * time.Second)
+						stubConfID(confID)
+						_, _ = healthchecker.Check()
+					}
+
+					now = now.Add(time.Minute)
+					stubConfID("15")
+					Expect(healthchecker.Check()).To(Equal("synced"))
+				})
+			})
+
+			Context("when a check cache TTL is configured", func() {
+				var (
+					healthchecker *healthcheck.HealthChecker
+					now           time.Time
+					queries       int32
+				)
+
+				BeforeEach(func() {
+					db, _ := sql.Open("testdb", "")
+
+					queries = 0
+					testdb.SetQueryFunc(func(query string) (driver.Rows, error) {
+						atomic.AddInt32(&queries, 1)
+						return testdb.RowsFromCSVString([]string{"Variable_name", "Value"}, "wsrep_local_state,4"), nil
+					})
+
+					now = time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+					healthchecker = healthcheck.New(db, config.Config{
+						AvailableWhenReadOnly:   true,
+						AvailableWhenNonPrimary: true,
+						CheckCacheTTL:           time.Second,
+					}, lagertest.NewTestLogger("healthcheck test"))
+					healthchecker.Now = func() time.Time { return now }
+				})
+
+				AfterEach(func() {
+					testdb.Reset()
+				})
+
+				It("queries the database once for concurrent checks within the TTL", func() {
+					var wg sync.WaitGroup
+					for i := 0; i < 10; i++ {
+						wg.Add(1)
+						go func() {
+							defer GinkgoRecover()
+							defer wg.Done()
+
+							Expect(healthchecker.Check()).To(Equal("synced"))
+						}()
+					}
+					wg.Wait()
+
+					Expect(atomic.LoadInt32(&queries)).To(Equal(int32(1)))
+				})
+
+				It("checks again once the cached result expires", func() {
+					Expect(healthchecker.Check()).To(Equal("synced"))
+					Expect(healthchecker.Check()).To(Equal("synced"))
+					Expect(atomic.LoadInt32(&queries)).To(Equal(int32(1)))
+
+					now = now.Add(time.Second)
+					Expect(healthchecker.Check()).To(Equal("synced"))
+					Expect(atomic.LoadInt32(&queries)).To(Equal(int32(2)))
+				})
+
+				It("checks again after ResetCache", func() {
+					Expect(healthchecker.Check()).To(Equal("synced"))
+					Expect(atomic.LoadInt32(&queries)).To(Equal(int32(1)))
+
+					healthchecker.ResetCache()
+					Expect(healthchecker.Check()).To(Equal("synced"))
+					Expect(atomic.LoadInt32(&queries)).To(Equal(int32(2)))
+				})
+			})
+
+			Context("when a metrics registry is set", func() {
+				It("records the observed wsrep_local_state", func() {
+					db, _ := sql.Open("testdb", "")
+					stubCheckStatus(healthcheck.STATE_JOINED, "Primary")
+
+					registry := metrics.NewRegistry()
+					healthchecker := healthcheck.New(db, config.Config{}, lagertest.NewTestLogger("healthcheck test"))
+					healthchecker.Metrics = registry
+
+					_, err := healthchecker.Check()
+					Expect(err).To(MatchError("joined"))
+					Expect(registry.WsrepLocalState()).To(Equal(healthcheck.STATE_JOINED))
+				})
+			})
+
+			Context("when a state webhook is set", func() {
+				var (
+					healthchecker *healthcheck.HealthChecker
+					receiver      *httptest.Server
+					transitions   chan webhook.Transition
+					localState    int32
+				)
+
+				BeforeEach(func() {
+					transitions = make(chan webhook.Transition, 10)
+					receiver = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+						var transition webhook.Transition
+						if json.NewDecoder(req.Body).Decode(&transition) == nil {
+							transitions <- transition
+						}
+					}))
+
+					db, _ := sql.Open("testdb", "")
+					atomic.StoreInt32(&localState, healthcheck.STATE_SYNCED)
+					testdb.SetQueryFunc(func(query string) (driver.Rows, error) {
+						return testdb.RowsFromCSVString([]string{"Variable_name", "Value"},
+							fmt.Sprintf("wsrep_local_state,%d", atomic.LoadInt32(&localState))), nil
+					})
+
+					logger := lagertest.NewTestLogger("healthcheck test")
+					healthchecker = healthcheck.New(db, config.Config{AvailableWhenReadOnly: true, AvailableWhenNonPrimary: true}, logger)
+					healthchecker.Webhook = webhook.NewNotifier(receiver.URL, "mysql/0", 0, time.Second, logger)
+				})
+
+				AfterEach(func() {
+					receiver.Close()
+					testdb.Reset()
+				})
+
+				It("posts the transition when the node stops being synced", func() {
+					Expect(healthchecker.Check()).To(Equal("synced"))
+
+					atomic.StoreInt32(&localState, healthcheck.STATE_JOINING)
+					_, err := healthchecker.Check()
+					Expect(err).To(MatchError("joining"))
+
+					var transition webhook.Transition
+					Eventually(transitions).Should(Receive(&transition))
+					Expect(transition.Node).To(Equal("mysql/0"))
+					Expect(transition.OldState).To(Equal("synced"))
+					Expect(transition.NewState).To(Equal("joining"))
+				})
+
+				It("does not post while the state is unchanged", func() {
+					for i := 0; i < 3; i++ {
+						Expect(healthchecker.Check()).To(Equal("synced"))
+					}
+
+					Consistently(transitions, 100*time.Millisecond).ShouldNot(Receive())
+				})
+			})
+
+			Context("when the database does not answer within DB.QueryTimeout", func() {
+				var (
+					db      *sql.DB
+					unblock chan struct{}
+				)
+
+				BeforeEach(func() {
+					db, _ = sql.Open("testdb", "")
+					unblock = make(chan struct{})
+					testdb.SetQueryFunc(func(query string) (driver.Rows, error) {
+						<-unblock
+						return nil, errors.New("query unblocked")
+					})
+				})
+
+				AfterEach(func() {
+					close(unblock)
+					Eventually(func() int { return db.Stats().InUse }).Should(BeZero())
+					testdb.Reset()
+				})
+
+				It("returns promptly with an unhealthy result", func() {
+					healthchecker := healthcheck.New(db, config.Config{
+						DB: config.DBConfig{QueryTimeout: 50 * time.Millisecond},
+					}, lagertest.NewTestLogger("healthcheck test"))
+
+					start := time.Now()
+					_, err := healthchecker.Check()
+					Expect(err).To(MatchError("timed out after 50ms waiting for database"))
+					Expect(time.Since(start)).To(BeNumerically("<", 1*time.Second))
+				})
+			})
+
 			Context("when SHOW STATUS returns an error", func() {
 				It("returns false and the error message", func() {
 					db, _ := sql.Open("testdb", "")
 
-					sql := "SHOW STATUS LIKE 'wsrep_local_state'"
-					testdb.StubQueryError(sql, errors.New("test error"))
+					testdb.StubQueryError(checkStatusQuery(), errors.New("test error"))
 
 					config := config.Config{
 						AvailableWhenDonor:    false,
@@ -184,13 +757,8 @@ var _ = Describe("GaleraHealthChecker", func() {
 				It("returns false and the error message", func() {
 					db, _ := sql.Open("testdb", "")
 
-					sql := "SHOW STATUS LIKE 'wsrep_local_state'"
-					columns := []string{"Variable_name", "Value"}
-					result := "wsrep_local_state,4"
-					testdb.StubQuery(sql, testdb.RowsFromCSVString(columns, result))
-
-					sql = "SHOW GLOBAL VARIABLES LIKE 'read_only'"
-					testdb.StubQueryError(sql, errors.New("another test error"))
+					stubCheckStatus(healthcheck.STATE_SYNCED, "Primary")
+					testdb.StubQueryError("SHOW GLOBAL VARIABLES LIKE 'read_only'", errors.New("another test error"))
 
 					config := config.Config{
 						AvailableWhenDonor:    false,
@@ -220,10 +788,11 @@ var _ = Describe("GaleraHealthChecker", func() {
 						Monit: config.MonitConfig{
 							ServiceName: "mariadb_ctrl",
 						},
+						UnhealthyStatusCode: http.StatusBadGateway,
 					}
 
 					err := fmt.Errorf("connection refused")
-					testdb.StubQueryError("SHOW STATUS LIKE 'wsrep_local_state'", err)
+					testdb.StubQueryError(checkStatusQuery(), err)
 
 					logger := lagertest.NewTestLogger("healthcheck test")
 					healthchecker = healthcheck.New(db, config, logger)
@@ -234,6 +803,51 @@ var _ = Describe("GaleraHealthChecker", func() {
 					Expect(err).To(MatchError("Cannot get status from galera"))
 				})
 
+				It("reports the database as unreachable with the configured status code", func() {
+					_, err := healthchecker.Check()
+
+					var unreachable healthcheck.UnreachableError
+					Expect(errors.As(err, &unreachable)).To(BeTrue())
+					Expect(unreachable.StatusCode()).To(Equal(http.StatusBadGateway))
+				})
+
+			})
+
+			Context("when the query fails to reach the database", func() {
+				var check = func(queryErr error) error {
+					db, _ := sql.Open("testdb", "")
+					testdb.StubQueryError(checkStatusQuery(), queryErr)
+
+					healthchecker := healthcheck.New(db, config.Config{
+						DB:                  config.DBConfig{QueryTimeout: 2 * time.Second},
+						UnhealthyStatusCode: http.StatusBadGateway,
+					}, lagertest.NewTestLogger("healthcheck test"))
+
+					_, err := healthchecker.Check()
+					return err
+				}
+
+				It("reports a missing unix socket as unreachable", func() {
+					err := check(&net.OpError{
+						Op:  "dial",
+						Net: "unix",
+						Err: &os.SyscallError{Syscall: "connect", Err: syscall.ENOENT},
+					})
+					Expect(err).To(MatchError("Cannot get status from galera"))
+
+					var unreachable healthcheck.UnreachableError
+					Expect(errors.As(err, &unreachable)).To(BeTrue())
+					Expect(unreachable.StatusCode()).To(Equal(http.StatusBadGateway))
+				})
+
+				It("reports a query that hit its deadline as unreachable", func() {
+					err := check(context.DeadlineExceeded)
+					Expect(err).To(MatchError("timed out after 2s waiting for database"))
+
+					var unreachable healthcheck.UnreachableError
+					Expect(errors.As(err, &unreachable)).To(BeTrue())
+					Expect(unreachable.StatusCode()).To(Equal(http.StatusBadGateway))
+				})
 			})
 		})
 
@@ -252,39 +866,400 @@ var _ = Describe("GaleraHealthChecker", func() {
 			})
 		})
 	})
+
+	Describe("WsrepVariable", func() {
+		var healthchecker *healthcheck.HealthChecker
+
+		BeforeEach(func() {
+			db, _ := sql.Open("testdb", "")
+			healthchecker = healthcheck.New(db, config.Config{}, lagertest.NewTestLogger("healthcheck test"))
+		})
+
+		AfterEach(func() {
+			testdb.Reset()
+		})
+
+		It("returns the value of an allowed variable", func() {
+			testdb.StubQuery(
+				"SHOW STATUS WHERE Variable_name IN ('wsrep_cluster_status')",
+				testdb.RowsFromCSVString([]string{"Variable_name", "Value"}, "wsrep_cluster_status,Primary"),
+			)
+
+			Expect(healthchecker.WsrepVariable("wsrep_cluster_status")).To(Equal("Primary"))
+		})
+
+		It("rejects names that are not allowed without querying the database", func() {
+			var queried bool
+			testdb.SetQueryFunc(func(query string) (driver.Rows, error) {
+				queried = true
+				return nil, errors.New("unexpected query")
+			})
+
+			_, err := healthchecker.WsrepVariable("wsrep_ready') OR ('1'='1")
+			Expect(err).To(BeAssignableToTypeOf(healthcheck.InvalidVariableError{}))
+			Expect(queried).To(BeFalse())
+		})
+
+		It("returns an error when the variable is not reported", func() {
+			testdb.StubQuery(
+				"SHOW STATUS WHERE Variable_name IN ('wsrep_gcomm_uuid')",
+				testdb.RowsFromCSVString([]string{"Variable_name", "Value"}, ""),
+			)
+
+			_, err := healthchecker.WsrepVariable("wsrep_gcomm_uuid")
+			Expect(err).To(MatchError(`wsrep variable "wsrep_gcomm_uuid" not found`))
+			Expect(err).To(BeAssignableToTypeOf(healthcheck.VariableNotFoundError{}))
+		})
+	})
+
+	Describe("WsrepStatus", func() {
+		var healthchecker *healthcheck.HealthChecker
+
+		BeforeEach(func() {
+			db, _ := sql.Open("testdb", "")
+			healthchecker = healthcheck.New(db, config.Config{}, lagertest.NewTestLogger("healthcheck test"))
+		})
+
+		It("returns the key wsrep variables from a single SHOW STATUS query", func() {
+			testdb.StubQuery(
+				"SHOW STATUS WHERE Variable_name IN ('wsrep_cluster_status', 'wsrep_connected', 'wsrep_ready', 'wsrep_local_state_comment', 'wsrep_cluster_size')",
+				testdb.RowsFromCSVString([]string{"Variable_name", "Value"}, `wsrep_cluster_status,Primary
+wsrep_connected,ON
+wsrep_ready,ON
+wsrep_local_state_comment,Synced
+wsrep_cluster_size,3`),
+			)
+
+			status, err := healthchecker.WsrepStatus()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(status).To(Equal(map[string]string{
+				"cluster_status":      "Primary",
+				"connected":           "ON",
+				"ready":               "ON",
+				"local_state_comment": "Synced",
+				"cluster_size":        "3",
+			}))
+		})
+
+		It("returns an error when the query fails", func() {
+			testdb.StubQueryError(
+				"SHOW STATUS WHERE Variable_name IN ('wsrep_cluster_status', 'wsrep_connected', 'wsrep_ready', 'wsrep_local_state_comment', 'wsrep_cluster_size')",
+				errors.New("status error"),
+			)
+
+			_, err := healthchecker.WsrepStatus()
+			Expect(err).To(MatchError("status error"))
+		})
+	})
+
+	Describe("StatusLike", func() {
+		var (
+			healthchecker *healthcheck.HealthChecker
+			queries       []string
+		)
+
+		BeforeEach(func() {
+			db, _ := sql.Open("testdb", "")
+			healthchecker = healthcheck.New(db, config.Config{}, lagertest.NewTestLogger("healthcheck test"))
+
+			queries = nil
+			testdb.SetQueryFunc(func(query string) (driver.Rows, error) {
+				queries = append(queries, query)
+				if query == "SHOW STATUS LIKE 'wsrep_cert%'" {
+					return testdb.RowsFromCSVString([]string{"Variable_name", "Value"}, `wsrep_cert_deps_distance,1.5
+wsrep_cert_index_size,42`), nil
+				}
+				return testdb.RowsFromCSVString([]string{"Variable_name", "Value"}, ""), nil
+			})
+		})
+
+		AfterEach(func() {
+			testdb.Reset()
+		})
+
+		It("returns the variables matching the pattern", func() {
+			Expect(healthchecker.StatusLike("wsrep_cert%")).To(Equal(map[string]string{
+				"wsrep_cert_deps_distance": "1.5",
+				"wsrep_cert_index_size":    "42",
+			}))
+			Expect(queries).To(Equal([]string{"SHOW STATUS LIKE 'wsrep_cert%'"}))
+		})
+
+		It("returns an empty result when nothing matches", func() {
+			Expect(healthchecker.StatusLike("wsrep_nonexistent%")).To(BeEmpty())
+		})
+
+		DescribeTable("rejects unsafe patterns without querying",
+			func(pattern string) {
+				_, err := healthchecker.StatusLike(pattern)
+				Expect(err).To(Equal(healthcheck.InvalidPatternError{Pattern: pattern}))
+				Expect(queries).To(BeEmpty())
+			},
+			Entry("a quote", "wsrep%'; DROP TABLE users; --"),
+			Entry("a space", "wsrep cert"),
+			Entry("a leading wildcard", "%cert%"),
+			Entry("a backslash", `wsrep\_cert`),
+			Entry("an empty pattern", ""),
+		)
+	})
+
+	Describe("CheckStatus", func() {
+		var healthchecker *healthcheck.HealthChecker
+
+		BeforeEach(func() {
+			db, _ := sql.Open("testdb", "")
+			healthchecker = healthcheck.New(db, config.Config{
+				AvailableWhenDonor:    false,
+				AvailableWhenReadOnly: true,
+			}, lagertest.NewTestLogger("healthcheck test"))
+		})
+
+		AfterEach(func() {
+			testdb.Reset()
+		})
+
+		It("populates the status from SHOW STATUS", func() {
+			testdb.StubQuery(checkStatusQuery(), testdb.RowsFromCSVString([]string{"Variable_name", "Value"}, `wsrep_local_state,4
+wsrep_local_state_comment,Synced
+wsrep_cluster_status,Primary
+wsrep_cluster_size,3
+wsrep_flow_control_paused,0.125`))
+
+			Expect(healthchecker.CheckStatus()).To(Equal(healthcheck.CheckStatus{
+				State:             "synced",
+				Ready:             true,
+				LocalState:        4,
+				LocalStateComment: "Synced",
+				ClusterStatus:     "Primary",
+				ClusterSize:       3,
+				FlowControlPaused: 0.125,
+			}))
+		})
+
+		It("reports why the node is not ready", func() {
+			testdb.StubQuery(checkStatusQuery(), testdb.RowsFromCSVString([]string{"Variable_name", "Value"}, `wsrep_local_state,2
+wsrep_local_state_comment,Donor/Desynced
+wsrep_cluster_status,Primary
+wsrep_cluster_size,3
+wsrep_flow_control_paused,0`))
+
+			status, err := healthchecker.CheckStatus()
+			Expect(err).To(MatchError("not synced"))
+			Expect(status.Ready).To(BeFalse())
+			Expect(status.Reason).To(Equal("not synced"))
+			Expect(status.LocalState).To(Equal(2))
+			Expect(status.LocalStateComment).To(Equal("Donor/Desynced"))
+		})
+
+		It("returns an error when the status cannot be read", func() {
+			testdb.StubQueryError(checkStatusQuery(), errors.New("status error"))
+
+			status, err := healthchecker.CheckStatus()
+			Expect(err).To(MatchError("status error"))
+			Expect(status.Ready).To(BeFalse())
+			Expect(status.Reason).To(Equal("status error"))
+		})
+	})
+
+	Describe("WsrepStatus with ReportNodeIdentity", func() {
+		var healthchecker *healthcheck.HealthChecker
+
+		BeforeEach(func() {
+			db, _ := sql.Open("testdb", "")
+			healthchecker = healthcheck.New(db, config.Config{ReportNodeIdentity: true}, lagertest.NewTestLogger("healthcheck test"))
+
+			testdb.StubQuery(
+				"SHOW STATUS WHERE Variable_name IN ('wsrep_cluster_status', 'wsrep_connected', 'wsrep_ready', 'wsrep_local_state_comment', 'wsrep_cluster_size', 'wsrep_gcomm_uuid')",
+				testdb.RowsFromCSVString([]string{"Variable_name", "Value"}, `wsrep_cluster_status,Primary
+wsrep_connected,ON
+wsrep_ready,ON
+wsrep_local_state_comment,Synced
+wsrep_cluster_size,3
+wsrep_gcomm_uuid,0b4e6b0e-2b4f-11eb-a0a5-9b7a4f1d8f3c`),
+			)
+		})
+
+		It("includes the node's name, address and gcomm UUID", func() {
+			testdb.StubQuery(
+				"SHOW GLOBAL VARIABLES WHERE Variable_name IN ('wsrep_node_name', 'wsrep_node_address')",
+				testdb.RowsFromCSVString([]string{"Variable_name", "Value"}, `wsrep_node_name,mysql/0
+wsrep_node_address,10.0.0.10`),
+			)
+
+			status, err := healthchecker.WsrepStatus()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(status).To(HaveKeyWithValue("gcomm_uuid", "0b4e6b0e-2b4f-11eb-a0a5-9b7a4f1d8f3c"))
+			Expect(status).To(HaveKeyWithValue("node_name", "mysql/0"))
+			Expect(status).To(HaveKeyWithValue("node_address", "10.0.0.10"))
+			Expect(status).To(HaveKeyWithValue("cluster_status", "Primary"))
+		})
+
+		It("returns an error when the variables query fails", func() {
+			testdb.StubQueryError(
+				"SHOW GLOBAL VARIABLES WHERE Variable_name IN ('wsrep_node_name', 'wsrep_node_address')",
+				errors.New("variables error"),
+			)
+
+			_, err := healthchecker.WsrepStatus()
+			Expect(err).To(MatchError("variables error"))
+		})
+	})
+
+	Describe("Ping", func() {
+		It("succeeds while the database connection is up", func() {
+			db, _ := sql.Open("testdb", "")
+			healthchecker := healthcheck.New(db, config.Config{}, lagertest.NewTestLogger("healthcheck test"))
+
+			Expect(healthchecker.Ping(context.Background())).To(Succeed())
+		})
+	})
+
+	Describe("PingDB", func() {
+		AfterEach(func() {
+			testdb.Reset()
+		})
+
+		It("succeeds when the database accepts connections", func() {
+			db, _ := sql.Open("testdb", "")
+			healthchecker := healthcheck.New(db, config.Config{}, lagertest.NewTestLogger("healthcheck test"))
+
+			Expect(healthchecker.PingDB()).To(Succeed())
+		})
+
+		It("fails when the database cannot be reached", func() {
+			testdb.SetOpenFunc(func(dsn string) (driver.Conn, error) {
+				return nil, errors.New("connection refused")
+			})
+
+			db, _ := sql.Open("testdb", "")
+			healthchecker := healthcheck.New(db, config.Config{}, lagertest.NewTestLogger("healthcheck test"))
+
+			Expect(healthchecker.PingDB()).To(MatchError("connection refused"))
+		})
+	})
+
+	Describe("ClusterSize", func() {
+		const query = "SHOW STATUS WHERE Variable_name IN ('wsrep_cluster_status', 'wsrep_cluster_size')"
+
+		var healthchecker *healthcheck.HealthChecker
+
+		BeforeEach(func() {
+			db, _ := sql.Open("testdb", "")
+			healthchecker = healthcheck.New(db, config.Config{}, lagertest.NewTestLogger("healthcheck test"))
+		})
+
+		It("returns the size of a primary cluster", func() {
+			testdb.StubQuery(query, testdb.RowsFromCSVString([]string{"Variable_name", "Value"}, `wsrep_cluster_status,Primary
+wsrep_cluster_size,3`))
+
+			Expect(healthchecker.ClusterSize()).To(Equal(3))
+		})
+
+		It("returns an error when the node is partitioned from the primary component", func() {
+			testdb.StubQuery(query, testdb.RowsFromCSVString([]string{"Variable_name", "Value"}, `wsrep_cluster_status,non-Primary
+wsrep_cluster_size,1`))
+
+			_, err := healthchecker.ClusterSize()
+			Expect(err).To(MatchError(`node is not part of the primary component (wsrep_cluster_status: "non-Primary")`))
+
+			var unhealthy healthcheck.UnhealthyError
+			Expect(errors.As(err, &unhealthy)).To(BeTrue())
+		})
+	})
+
+	DescribeTable("ClassifyRole",
+		func(state domain.WsrepLocalState, role string) {
+			Expect(healthcheck.ClassifyRole(state)).To(Equal(role))
+		},
+		Entry("donor", domain.DonorDesynced, healthcheck.RoleDonor),
+		Entry("joining", domain.Joining, healthcheck.RoleJoiner),
+		Entry("joined", domain.Joined, healthcheck.RoleJoiner),
+		Entry("synced", domain.Synced, healthcheck.RoleMember),
+	)
+
+	Describe("WsrepMetrics", func() {
+		It("returns the numeric wsrep status variables", func() {
+			quoted := make([]string, len(healthcheck.WsrepMetricVariables))
+			for i, name := range healthcheck.WsrepMetricVariables {
+				quoted[i] = "'" + name + "'"
+			}
+			query := fmt.Sprintf("SHOW STATUS WHERE Variable_name IN (%s)", strings.Join(quoted, ", "))
+			testdb.StubQuery(query, testdb.RowsFromCSVString([]string{"Variable_name", "Value"}, `wsrep_cluster_size,3
+wsrep_local_recv_queue_avg,0.25
+wsrep_local_state,not-a-number`))
+
+			db, _ := sql.Open("testdb", "")
+			healthchecker := healthcheck.New(db, config.Config{}, lagertest.NewTestLogger("healthcheck test"))
+
+			Expect(healthchecker.WsrepMetrics()).To(Equal(map[string]float64{
+				"wsrep_cluster_size":         3,
+				"wsrep_local_recv_queue_avg": 0.25,
+			}))
+		})
+	})
+
+	Describe("ClientConnections", func() {
+		const query = "SHOW STATUS WHERE Variable_name IN ('Threads_connected')"
+
+		var healthchecker *healthcheck.HealthChecker
+
+		BeforeEach(func() {
+			db, _ := sql.Open("testdb", "")
+			healthchecker = healthcheck.New(db, config.Config{}, lagertest.NewTestLogger("healthcheck test"))
+		})
+
+		It("excludes the health checker's own connection", func() {
+			testdb.StubQuery(query, testdb.RowsFromCSVString([]string{"Variable_name", "Value"}, "Threads_connected,5"))
+
+			Expect(healthchecker.ClientConnections()).To(Equal(4))
+		})
+
+		It("returns an error when the variable is missing", func() {
+			testdb.StubQuery(query, testdb.RowsFromCSVString([]string{"Variable_name", "Value"}, "Uptime,5"))
+
+			_, err := healthchecker.ClientConnections()
+			Expect(err).To(MatchError("Threads_connected variable not set"))
+		})
+	})
 })
 
 type healthcheckTestHelperConfig struct {
-	wsrepStatus           int
-	readOnly              bool
-	availableWhenDonor    bool
-	availableWhenReadOnly bool
-	monit                 config.MonitConfig
+	wsrepStatus             int
+	readOnly                bool
+	availableWhenDonor      bool
+	availableWhenReadOnly   bool
+	allowedStates           []string
+	monit                   config.MonitConfig
+	clusterStatus           string
+	availableWhenNonPrimary bool
 }
 
 func healthcheckTestHelper(testConfig healthcheckTestHelperConfig) (string, error) {
 	db, _ := sql.Open("testdb", "")
 
-	sql := "SHOW STATUS LIKE 'wsrep_local_state'"
-	columns := []string{"Variable_name", "Value"}
-	result := fmt.Sprintf("wsrep_local_state,%d", testConfig.wsrepStatus)
-	testdb.StubQuery(sql, testdb.RowsFromCSVString(columns, result))
+	clusterStatus := testConfig.clusterStatus
+	if clusterStatus == "" {
+		clusterStatus = "Primary"
+	}
+	stubCheckStatus(testConfig.wsrepStatus, clusterStatus)
 
-	sql = "SHOW GLOBAL VARIABLES LIKE 'read_only'"
-	columns = []string{"Variable_name", "Value"}
+	sql := "SHOW GLOBAL VARIABLES LIKE 'read_only'"
+	columns := []string{"Variable_name", "Value"}
 	var readOnlyText string
 	if testConfig.readOnly {
 		readOnlyText = "ON"
 	} else {
 		readOnlyText = "OFF"
 	}
-	result = fmt.Sprintf("read_only,%s", readOnlyText)
+	result := fmt.Sprintf("read_only,%s", readOnlyText)
 	testdb.StubQuery(sql, testdb.RowsFromCSVString(columns, result))
 
 	config := config.Config{
-		AvailableWhenDonor:    testConfig.availableWhenDonor,
-		AvailableWhenReadOnly: testConfig.availableWhenReadOnly,
-		Monit:                 testConfig.monit,
+		AvailableWhenDonor:      testConfig.availableWhenDonor,
+		AvailableWhenReadOnly:   testConfig.availableWhenReadOnly,
+		AvailableWhenNonPrimary: testConfig.availableWhenNonPrimary,
+		AllowedStates:           testConfig.allowedStates,
+		Monit:                   testConfig.monit,
 	}
 
 	logger := lagertest.NewTestLogger("healthcheck test")
@@ -292,3 +1267,32 @@ func healthcheckTestHelper(testConfig healthcheckTestHelperConfig) (string, erro
 
 	return healthchecker.Check()
 }
+
+// checkStatusQuery is the SHOW STATUS query run by a check that also reads
+// the given variables for its optional checks.
+func checkStatusQuery(extras ...string) string {
+	names := []string{"wsrep_local_state", "wsrep_local_state_comment", "wsrep_cluster_status", "wsrep_cluster_size", "wsrep_flow_control_paused"}
+	names = append(names, extras...)
+
+	quoted := make([]string, len(names))
+	for i, name := range names {
+		quoted[i] = "'" + name + "'"
+	}
+	return fmt.Sprintf("SHOW STATUS WHERE Variable_name IN (%s)", strings.Join(quoted, ", "))
+}
+
+// stubCheckStatus stubs the check's SHOW STATUS query with the given local
+// state and cluster status, followed by any extra "name,value" rows.
+func stubCheckStatus(localState int, clusterStatus string, extras ...string) {
+	var names []string
+	rows := []string{
+		fmt.Sprintf("wsrep_local_state,%d", localState),
+		"wsrep_cluster_status," + clusterStatus,
+	}
+	for _, extra := range extras {
+		names = append(names, strings.SplitN(extra, ",", 2)[0])
+		rows = append(rows, extra)
+	}
+
+	testdb.StubQuery(checkStatusQuery(names...), testdb.RowsFromCSVString([]string{"Variable_name", "Value"}, strings.Join(rows, "\n")))
+}
@@ -1,11 +1,15 @@
 package main
 
 import (
+	"context"
+	"crypto/tls"
 	"database/sql"
 	"fmt"
 	"net"
 	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
 	"code.cloudfoundry.org/lager"
@@ -14,10 +18,19 @@ import (
 	"github.com/cloudfoundry-incubator/galera-healthcheck/api"
 	"github.com/cloudfoundry-incubator/galera-healthcheck/config"
 	"github.com/cloudfoundry-incubator/galera-healthcheck/healthcheck"
+	"github.com/cloudfoundry-incubator/galera-healthcheck/metrics"
 	"github.com/cloudfoundry-incubator/galera-healthcheck/monit_client"
 	"github.com/cloudfoundry-incubator/galera-healthcheck/mysqld_cmd"
 	"github.com/cloudfoundry-incubator/galera-healthcheck/node_manager"
 	"github.com/cloudfoundry-incubator/galera-healthcheck/sequence_number"
+	"github.com/cloudfoundry-incubator/galera-healthcheck/webhook"
+)
+
+// Populated at build time via -ldflags "-X main.version=... -X main.sha=... -X main.builtAt=..."
+var (
+	version string
+	sha     string
+	builtAt string
 )
 
 func main() {
@@ -25,6 +38,10 @@ func main() {
 
 	logger := rootConfig.Logger
 
+	if err != nil {
+		logger.Fatal("Failed to load config", err)
+	}
+
 	err = rootConfig.Validate()
 	if err != nil {
 		logger.Fatal("Failed to validate config", err)
@@ -48,55 +65,187 @@ func main() {
 		})
 	}
 
-	mysqldCmd := mysqld_cmd.NewMysqldCmd(logger, *rootConfig)
-	serviceManager := &node_manager.NodeManager{
-		ServiceName:   rootConfig.Monit.ServiceName,
-		StateFilePath: rootConfig.Monit.MysqlStateFilePath,
-		MonitClient: monit_client.NewClient(
+	rootConfig.DB.ApplyPoolLimits(db)
+
+	mysqldCmd := mysqld_cmd.NewMysqldCmd(logger, *rootConfig, mysqld_cmd.ExecCommandRunner{})
+
+	monitRetry := monit_client.RetryConfig{
+		MaxAttempts:     rootConfig.Monit.RetryMaxAttempts,
+		InitialInterval: rootConfig.Monit.RetryInitialInterval,
+		MaxInterval:     rootConfig.Monit.RetryMaxInterval,
+	}
+
+	var monitClient *monit_client.MonitClient
+	if rootConfig.Monit.UnixSocket != "" {
+		monitClient = monit_client.NewUnixSocketClient(
+			rootConfig.Monit.UnixSocket,
+			rootConfig.Monit.User,
+			rootConfig.Monit.Password,
+			2*time.Minute,
+			monitRetry,
+		)
+	} else {
+		monitClient = monit_client.NewClient(
 			net.JoinHostPort(rootConfig.Monit.Host, rootConfig.Monit.Port),
 			rootConfig.Monit.User,
 			rootConfig.Monit.Password,
 			2*time.Minute,
-		),
-		GaleraInitAddress: rootConfig.Monit.GaleraInitStatusServerAddress,
-		Logger:            logger,
+			monitRetry,
+		)
+	}
+
+	monitClient.RequestTimeout = rootConfig.Monit.RequestTimeout
+	monitClient.Binary = rootConfig.Monit.BinaryPath
+
+	serviceManager := &node_manager.NodeManager{
+		ServiceName:           rootConfig.Monit.ServiceName,
+		StateFilePath:         rootConfig.Monit.MysqlStateFilePath,
+		MonitClient:           monitClient,
+		GaleraInitAddress:     rootConfig.Monit.GaleraInitStatusServerAddress,
+		GaleraInitScheme:      rootConfig.Monit.GaleraInitStatusServerScheme,
+		GaleraInitPath:        rootConfig.Monit.GaleraInitStatusServerPath,
+		Logger:                logger,
+		StartupTimeout:        rootConfig.Monit.StartupTimeout,
+		StartupPollInterval:   rootConfig.Monit.StartupPollInterval,
+		StartupPollJitter:     rootConfig.Monit.StartupPollJitter,
+		StartupGracePolls:     rootConfig.Monit.StartupGracePolls,
+		GaleraInitRetryBudget: rootConfig.Monit.GaleraInitRetryBudget,
+		ManagedServices:       rootConfig.Monit.ManagedServices,
+		DryRun:                rootConfig.DryRun,
+		GrastatePath:          rootConfig.GrastatePath,
+	}
+
+	var metricsRegistry *metrics.Registry
+	if rootConfig.EnableMetrics {
+		metricsRegistry = metrics.NewRegistry()
 	}
 
 	healthchecker := healthcheck.New(db, *rootConfig, logger)
-	sequenceNumberchecker := sequence_number.New(db, mysqldCmd, *rootConfig, logger)
+	healthchecker.Metrics = metricsRegistry
+	if rootConfig.StateWebhook.Enabled() {
+		node := rootConfig.StateWebhook.Node
+		if node == "" {
+			node, _ = os.Hostname()
+		}
+		healthchecker.Webhook = webhook.NewNotifier(
+			rootConfig.StateWebhook.URL,
+			node,
+			rootConfig.StateWebhook.MinInterval,
+			rootConfig.StateWebhook.Timeout,
+			logger,
+		)
+	}
+	sequenceNumberchecker := sequence_number.New(db, mysqldCmd, monitClient, *rootConfig, logger)
 	stateSnapshotter := &healthcheck.DBStateSnapshotter{
 		DB:     db,
 		Logger: logger,
 	}
 
-	router, err := api.NewRouter(
-		logger,
-		rootConfig,
-		serviceManager,
-		sequenceNumberchecker,
-		healthchecker,
-		healthchecker,
-		stateSnapshotter,
-	)
-	if err != nil {
-		logger.Fatal("Failed to create router", err)
+	buildInfo := api.BuildInfo{
+		SHA:     sha,
+		Version: version,
+		BuiltAt: builtAt,
+	}
+
+	type server struct {
+		name    string
+		address string
+		handler http.Handler
+	}
+	var servers []server
+
+	if rootConfig.SplitAdmin() {
+		public, admin, err := api.NewSplitRouters(
+			logger,
+			rootConfig,
+			serviceManager,
+			sequenceNumberchecker,
+			healthchecker,
+			healthchecker,
+			stateSnapshotter,
+			buildInfo,
+			metricsRegistry,
+		)
+		if err != nil {
+			logger.Fatal("Failed to create router", err)
+		}
+		servers = []server{
+			{name: "healthcheck", address: rootConfig.BindAddress(), handler: public},
+			{name: "admin", address: rootConfig.AdminBindAddress(), handler: admin},
+		}
+	} else {
+		router, err := api.NewRouter(
+			logger,
+			rootConfig,
+			serviceManager,
+			sequenceNumberchecker,
+			healthchecker,
+			healthchecker,
+			stateSnapshotter,
+			buildInfo,
+			metricsRegistry,
+		)
+		if err != nil {
+			logger.Fatal("Failed to create router", err)
+		}
+		servers = []server{
+			{name: "healthcheck", address: rootConfig.BindAddress(), handler: router},
+		}
 	}
 
-	address := fmt.Sprintf("%s:%d", rootConfig.Host, rootConfig.Port)
-	l, err := net.Listen("tcp", address)
+	tlsConfig, err := api.NewTLSConfig(rootConfig.TLS)
 	if err != nil {
-		logger.Fatal("tcp-listen", err, lager.Data{
-			"address": address,
+		logger.Fatal("tls-config", err)
+	}
+
+	scheme := "http"
+	if tlsConfig != nil {
+		scheme = "https"
+	}
+
+	listeners := make([]net.Listener, len(servers))
+	for i, s := range servers {
+		l, err := net.Listen("tcp", s.address)
+		if err != nil {
+			logger.Fatal("tcp-listen", err, lager.Data{
+				"address": s.address,
+			})
+		}
+
+		if tlsConfig != nil {
+			l = tls.NewListener(l, tlsConfig)
+		}
+		listeners[i] = l
+
+		url := fmt.Sprintf("%s://%s/", scheme, s.address)
+		logger.Info(fmt.Sprintf("Serving %s endpoint", s.name), lager.Data{
+			"url": url,
 		})
 	}
 
-	url := fmt.Sprintf("http://%s/", address)
-	logger.Info("Serving healthcheck endpoint", lager.Data{
-		"url": url,
-	})
+	ctx, cancel := context.WithCancel(context.Background())
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGTERM, syscall.SIGINT)
+	go func() {
+		sig := <-signals
+		logger.Info("shutting-down", lager.Data{
+			"signal":  sig.String(),
+			"timeout": rootConfig.ShutdownTimeout.String(),
+		})
+		cancel()
+	}()
+
+	serveErrs := make(chan error, len(servers))
+	for i, s := range servers {
+		go func(l net.Listener, handler http.Handler) {
+			serveErrs <- api.Serve(ctx, l, handler, rootConfig.ShutdownTimeout)
+		}(listeners[i], s.handler)
+	}
 
-	if err := http.Serve(l, router); err != nil {
-		logger.Fatal("http-server", err)
+	for range servers {
+		if err := <-serveErrs; err != nil {
+			logger.Fatal("http-server", err)
+		}
 	}
 	logger.Info("graceful-exit")
 }
@@ -1,6 +1,11 @@
 package main_test
 
 import (
+	"fmt"
+	"os"
+	"os/exec"
+
+	"github.com/onsi/gomega/gbytes"
 	"github.com/onsi/gomega/gexec"
 
 	. "github.com/onsi/ginkgo"
@@ -14,7 +19,48 @@ var _ = Describe("Healthcheck Integration", func() {
 		Expect(binaryPath).To(BeAnExistingFile())
 	})
 
+	It("refuses to start when an environment override is invalid", func() {
+		binaryPath, err := gexec.Build("github.com/cloudfoundry-incubator/galera-healthcheck")
+		Expect(err).ToNot(HaveOccurred())
+
+		rawConfig := `{
+			"Host": "localhost",
+			"Port": 8080,
+			"DB": {
+				"User": "vcap",
+				"Password": "password"
+			},
+			"Monit": {
+				"Host": "localhost",
+				"User": "vcap",
+				"Port": 2822,
+				"Password": "random-password",
+				"MysqlStateFilePath": "/var/vcap/store/mysql/state.txt",
+				"ServiceName": "mariadb_ctrl",
+				"GaleraInitStatusServerAddress": "127.0.0.1:8114"
+			},
+			"MysqldPath": "/var/vcap/packages/mariadb/bin/mysqld",
+			"MyCnfPath": "/path/to/my.cnf",
+			"SidecarEndpoint": {
+				"Username": "username",
+				"Password": "password"
+			}
+		}`
+
+		cmd := exec.Command(binaryPath, fmt.Sprintf("-config=%s", rawConfig))
+		cmd.Env = append(os.Environ(), "GALERA_HC_PORT=http")
+
+		session, err := gexec.Start(cmd, GinkgoWriter, GinkgoWriter)
+		Expect(err).ToNot(HaveOccurred())
+
+		Eventually(session, 10).Should(gexec.Exit())
+		Expect(session.ExitCode()).NotTo(Equal(0))
+		Expect(session.Out).To(gbytes.Say("Failed to load config"))
+		Expect(session.Out).To(gbytes.Say(`GALERA_HC_PORT : \\"http\\" is not an integer`))
+	})
+
 	AfterEach(func() {
+		gexec.KillAndWait()
 		gexec.CleanupBuildArtifacts()
 	})
 })
@@ -0,0 +1,178 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// CheckDurationBuckets are the upper bounds, in seconds, of the health check
+// latency histogram.
+var CheckDurationBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// Registry collects the sidecar's metrics and renders them in the Prometheus
+// text exposition format. All methods are safe to call on a nil *Registry, in
+// which case nothing is recorded.
+type Registry struct {
+	mu sync.Mutex
+
+	requests        map[string]uint64
+	checkBuckets    []uint64
+	checkCount      uint64
+	checkSum        float64
+	wsrepLocalState int
+}
+
+func NewRegistry() *Registry {
+	return &Registry{
+		requests:     map[string]uint64{},
+		checkBuckets: make([]uint64, len(CheckDurationBuckets)),
+	}
+}
+
+func (r *Registry) IncRequests(endpoint string) {
+	if r == nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.requests[endpoint]++
+}
+
+func (r *Registry) ObserveCheckDuration(d time.Duration) {
+	if r == nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	seconds := d.Seconds()
+	for i, bound := range CheckDurationBuckets {
+		if seconds <= bound {
+			r.checkBuckets[i]++
+		}
+	}
+	r.checkCount++
+	r.checkSum += seconds
+}
+
+func (r *Registry) SetWsrepLocalState(state int) {
+	if r == nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.wsrepLocalState = state
+}
+
+func (r *Registry) Requests(endpoint string) uint64 {
+	if r == nil {
+		return 0
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.requests[endpoint]
+}
+
+func (r *Registry) CheckCount() uint64 {
+	if r == nil {
+		return 0
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.checkCount
+}
+
+func (r *Registry) WsrepLocalState() int {
+	if r == nil {
+		return 0
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.wsrepLocalState
+}
+
+func (r *Registry) WriteTo(w io.Writer) (int64, error) {
+	if r == nil {
+		return 0, nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	cw := &countingWriter{w: w}
+
+	fmt.Fprintln(cw, "# HELP galera_healthcheck_requests_total Number of requests served per API endpoint.")
+	fmt.Fprintln(cw, "# TYPE galera_healthcheck_requests_total counter")
+	endpoints := make([]string, 0, len(r.requests))
+	for endpoint := range r.requests {
+		endpoints = append(endpoints, endpoint)
+	}
+	sort.Strings(endpoints)
+	for _, endpoint := range endpoints {
+		fmt.Fprintf(cw, "galera_healthcheck_requests_total{endpoint=%q} %d\n", endpoint, r.requests[endpoint])
+	}
+
+	fmt.Fprintln(cw, "# HELP galera_healthcheck_check_duration_seconds Latency of galera health checks.")
+	fmt.Fprintln(cw, "# TYPE galera_healthcheck_check_duration_seconds histogram")
+	for i, bound := range CheckDurationBuckets {
+		fmt.Fprintf(cw, "galera_healthcheck_check_duration_seconds_bucket{le=%q} %d\n", strconv.FormatFloat(bound, 'g', -1, 64), r.checkBuckets[i])
+	}
+	fmt.Fprintf(cw, "galera_healthcheck_check_duration_seconds_bucket{le=\"+Inf\"} %d\n", r.checkCount)
+	fmt.Fprintf(cw, "galera_healthcheck_check_duration_seconds_sum %s\n", strconv.FormatFloat(r.checkSum, 'g', -1, 64))
+	fmt.Fprintf(cw, "galera_healthcheck_check_duration_seconds_count %d\n", r.checkCount)
+
+	fmt.Fprintln(cw, "# HELP galera_healthcheck_wsrep_local_state Last observed wsrep_local_state (0 when unknown).")
+	fmt.Fprintln(cw, "# TYPE galera_healthcheck_wsrep_local_state gauge")
+	fmt.Fprintf(cw, "galera_healthcheck_wsrep_local_state %d\n", r.wsrepLocalState)
+
+	return cw.n, cw.err
+}
+
+// WriteWsrepMetrics renders wsrep status variables, keyed by variable name,
+// in the Prometheus text exposition format. Each variable becomes an untyped
+// metric named after it with a galera_ prefix, for example
+// galera_wsrep_cluster_size.
+func WriteWsrepMetrics(w io.Writer, values map[string]float64) (int64, error) {
+	cw := &countingWriter{w: w}
+
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		metric := "galera_" + name
+		fmt.Fprintf(cw, "# TYPE %s untyped\n", metric)
+		fmt.Fprintf(cw, "%s %s\n", metric, strconv.FormatFloat(values[name], 'g', -1, 64))
+	}
+
+	return cw.n, cw.err
+}
+
+type countingWriter struct {
+	w   io.Writer
+	n   int64
+	err error
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	if c.err != nil {
+		return 0, c.err
+	}
+
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	c.err = err
+	return n, err
+}
@@ -0,0 +1,76 @@
+package metrics_test
+
+import (
+	"bytes"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/cloudfoundry-incubator/galera-healthcheck/metrics"
+)
+
+var _ = Describe("Registry", func() {
+	var registry *metrics.Registry
+
+	BeforeEach(func() {
+		registry = metrics.NewRegistry()
+	})
+
+	It("counts requests per endpoint", func() {
+		registry.IncRequests("root")
+		registry.IncRequests("root")
+		registry.IncRequests("mysql_status")
+
+		Expect(registry.Requests("root")).To(Equal(uint64(2)))
+		Expect(registry.Requests("mysql_status")).To(Equal(uint64(1)))
+		Expect(registry.Requests("stop_mysql")).To(Equal(uint64(0)))
+	})
+
+	It("renders metrics in the prometheus text format", func() {
+		registry.IncRequests("root")
+		registry.ObserveCheckDuration(20 * time.Millisecond)
+		registry.ObserveCheckDuration(3 * time.Second)
+		registry.SetWsrepLocalState(4)
+
+		var buf bytes.Buffer
+		_, err := registry.WriteTo(&buf)
+		Expect(err).NotTo(HaveOccurred())
+
+		output := buf.String()
+		Expect(output).To(ContainSubstring("# TYPE galera_healthcheck_requests_total counter\n"))
+		Expect(output).To(ContainSubstring(`galera_healthcheck_requests_total{endpoint="root"} 1` + "\n"))
+		Expect(output).To(ContainSubstring(`galera_healthcheck_check_duration_seconds_bucket{le="0.01"} 0` + "\n"))
+		Expect(output).To(ContainSubstring(`galera_healthcheck_check_duration_seconds_bucket{le="0.025"} 1` + "\n"))
+		Expect(output).To(ContainSubstring(`galera_healthcheck_check_duration_seconds_bucket{le="5"} 2` + "\n"))
+		Expect(output).To(ContainSubstring(`galera_healthcheck_check_duration_seconds_bucket{le="+Inf"} 2` + "\n"))
+		Expect(output).To(ContainSubstring("galera_healthcheck_check_duration_seconds_count 2\n"))
+		Expect(output).To(ContainSubstring("galera_healthcheck_wsrep_local_state 4\n"))
+	})
+
+	It("renders wsrep status variables as untyped metrics", func() {
+		var buf bytes.Buffer
+		_, err := metrics.WriteWsrepMetrics(&buf, map[string]float64{
+			"wsrep_local_recv_queue_avg": 0.25,
+			"wsrep_cluster_size":         3,
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(buf.String()).To(Equal(`# TYPE galera_wsrep_cluster_size untyped
+galera_wsrep_cluster_size 3
+# TYPE galera_wsrep_local_recv_queue_avg untyped
+galera_wsrep_local_recv_queue_avg 0.25
+`))
+	})
+
+	It("ignores observations on a nil registry", func() {
+		var nilRegistry *metrics.Registry
+
+		nilRegistry.IncRequests("root")
+		nilRegistry.ObserveCheckDuration(time.Second)
+		nilRegistry.SetWsrepLocalState(4)
+
+		Expect(nilRegistry.Requests("root")).To(BeZero())
+		Expect(nilRegistry.CheckCount()).To(BeZero())
+	})
+})
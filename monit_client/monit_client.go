@@ -1,9 +1,14 @@
 package monit_client
 
 import (
+	"context"
+	"fmt"
 	"io"
+	"io/ioutil"
+	"net"
 	"net/http"
 	"net/url"
+	"os/exec"
 	"strings"
 	"time"
 
@@ -15,9 +20,66 @@ type MonitClient struct {
 	User     string
 	Password string
 	Timeout  time.Duration
+	Retry    RetryConfig
+
+	// HTTPClient is used to talk to monit and is shared by every request so
+	// that connections are reused. Defaults to http.DefaultClient.
+	HTTPClient *http.Client
+	// RequestTimeout bounds each request to monit, including reading the
+	// response. Defaults to five seconds.
+	RequestTimeout time.Duration
+	// Binary is the monit executable that Reload runs, since monit's HTTP
+	// interface cannot reload its control file. Defaults to "monit".
+	Binary string
+	// Runner runs Binary. Defaults to running it with os/exec.
+	Runner CommandRunner
+}
+
+// CommandRunner runs an external command and returns its combined output.
+type CommandRunner interface {
+	Run(name string, args ...string) ([]byte, error)
+}
+
+type execCommandRunner struct{}
+
+func (execCommandRunner) Run(name string, args ...string) ([]byte, error) {
+	return exec.Command(name, args...).CombinedOutput()
+}
+
+// ErrRequestTimeout is the cause of errors returned when monit does not
+// answer within RequestTimeout.
+var ErrRequestTimeout = errors.New("timed out waiting for monit")
+
+// RetryConfig controls how requests to monit are retried when monit is
+// temporarily unavailable. Requests are attempted at most MaxAttempts times,
+// backing off exponentially from InitialInterval up to MaxInterval. A
+// MaxAttempts of zero or one disables retries.
+type RetryConfig struct {
+	MaxAttempts     int
+	InitialInterval time.Duration
+	MaxInterval     time.Duration
+}
+
+const (
+	defaultRetryInitialInterval = 100 * time.Millisecond
+	defaultRequestTimeout       = 5 * time.Second
+
+	maxIdleConns    = 4
+	idleConnTimeout = 90 * time.Second
+)
+
+// newTransport returns a transport that keeps a few connections to monit
+// alive between requests, since the status is polled frequently.
+func newTransport() *http.Transport {
+	return &http.Transport{
+		Proxy:               http.ProxyFromEnvironment,
+		MaxIdleConns:        maxIdleConns,
+		MaxIdleConnsPerHost: maxIdleConns,
+		IdleConnTimeout:     idleConnTimeout,
+	}
 }
 
-func NewClient(address, user, password string, timeout time.Duration) *MonitClient {
+func NewClient(address, user, password string, timeout time.Duration, retry RetryConfig) *MonitClient {
 	return &MonitClient{
 		URL: &url.URL{
 			Scheme: "http",
@@ -26,15 +88,37 @@ func NewClient(address, user, password string, timeout time.Duration) *MonitClie
 		User:     user,
 		Password: password,
 		Timeout:  timeout,
+		Retry:    retry,
+		HTTPClient: &http.Client{
+			Transport: newTransport(),
+		},
 	}
 }
 
+// NewUnixSocketClient returns a client for a monit daemon listening on a unix
+// domain socket rather than a TCP port.
+func NewUnixSocketClient(socketPath, user, password string, timeout time.Duration, retry RetryConfig) *MonitClient {
+	client := NewClient("monit", user, password, timeout, retry)
+
+	dialer := &net.Dialer{}
+	transport := newTransport()
+	transport.Proxy = nil
+	transport.DialContext = func(ctx context.Context, _, _ string) (net.Conn, error) {
+		return dialer.DialContext(ctx, "unix", socketPath)
+	}
+	client.HTTPClient = &http.Client{Transport: transport}
+
+	return client
+}
+
 func (c *MonitClient) Start(processName string) error {
-	if _, err := c.do(http.MethodPost, "/"+processName, "action=start"); err != nil {
+	body, err := c.do(http.MethodPost, "/"+processName, "action=start")
+	if err != nil {
 		return errors.Wrap(err, "failed to make start request for "+processName)
 	}
+	_ = body.Close()
 
-	if err := c.waitForStatus(processName, ServiceRunning); err != nil {
+	if err := c.waitFor(processName, isRunning); err != nil {
 		return errors.Wrapf(err, "timed out waiting for %s monit service to start", processName)
 	}
 
@@ -42,18 +126,85 @@ func (c *MonitClient) Start(processName string) error {
 }
 
 func (c *MonitClient) Stop(processName string) error {
-	if _, err := c.do(http.MethodPost, "/"+processName, "action=stop"); err != nil {
+	body, err := c.do(http.MethodPost, "/"+processName, "action=stop")
+	if err != nil {
 		return errors.Wrap(err, "failed to make stop request for "+processName)
 	}
+	_ = body.Close()
 
-	if err := c.waitForStatus(processName, ServiceStopped); err != nil {
+	if err := c.waitFor(processName, isUnmonitored); err != nil {
 		return errors.Wrapf(err, "timed out waiting for %s monit service to stop", processName)
 	}
 
 	return nil
 }
 
-func (c *MonitClient) waitForStatus(processName string, desiredServiceStatus ServiceStatus) error {
+// Unmonitor tells monit to stop monitoring a service, as `monit unmonitor`
+// does, and waits for monit to clear the service's monitor flag. The process
+// keeps running, but monit no longer restarts it if it dies.
+func (c *MonitClient) Unmonitor(processName string) error {
+	body, err := c.do(http.MethodPost, "/"+processName, "action=unmonitor")
+	if err != nil {
+		return errors.Wrap(err, "failed to make unmonitor request for "+processName)
+	}
+	_ = body.Close()
+
+	if err := c.waitFor(processName, isUnmonitored); err != nil {
+		return errors.Wrapf(err, "timed out waiting for monit to unmonitor %s", processName)
+	}
+
+	return nil
+}
+
+// Monitor tells monit to resume monitoring a service, as `monit monitor`
+// does. It does not wait for the service to be running.
+func (c *MonitClient) Monitor(processName string) error {
+	body, err := c.do(http.MethodPost, "/"+processName, "action=monitor")
+	if err != nil {
+		return errors.Wrap(err, "failed to make monitor request for "+processName)
+	}
+	_ = body.Close()
+
+	return nil
+}
+
+// Reload asks monit to reread its control file by running `monit reload`,
+// which signals the monit daemon, and returns the command's output. Monit's
+// HTTP interface has no equivalent action.
+func (c *MonitClient) Reload() (string, error) {
+	binary := c.Binary
+	if binary == "" {
+		binary = "monit"
+	}
+
+	runner := c.Runner
+	if runner == nil {
+		runner = execCommandRunner{}
+	}
+
+	output, err := runner.Run(binary, "reload")
+	response := strings.TrimSpace(string(output))
+	if err != nil {
+		if response != "" {
+			return "", errors.Wrapf(err, "failed to reload monit: %s", response)
+		}
+		return "", errors.Wrap(err, "failed to reload monit")
+	}
+
+	return response, nil
+}
+
+func isRunning(svc ServiceTag) bool {
+	return svc.State() == StateRunning
+}
+
+// isUnmonitored reports whether monit has finished with a stop or unmonitor
+// action, both of which leave the service unmonitored.
+func isUnmonitored(svc ServiceTag) bool {
+	return svc.PendingAction == 0 && svc.Monitor == MonitMonitorStatusStopped
+}
+
+func (c *MonitClient) waitFor(processName string, done func(ServiceTag) bool) error {
 	var (
 		lastServiceStatus = "unknown"
 	)
@@ -67,13 +218,13 @@ func (c *MonitClient) waitForStatus(processName string, desiredServiceStatus Ser
 		case <-timer.C:
 			return errors.Errorf("service status=%v", lastServiceStatus)
 		case <-ticker.C:
-			var err error
-			lastServiceStatus, err = c.Status(processName)
+			svc, err := c.service(processName)
 			if err != nil {
 				return err
 			}
+			lastServiceStatus = svc.String()
 
-			if lastServiceStatus == string(desiredServiceStatus) {
+			if done(svc) {
 				return nil
 			}
 		}
@@ -81,32 +232,121 @@ func (c *MonitClient) waitForStatus(processName string, desiredServiceStatus Ser
 }
 
 func (c *MonitClient) Status(processName string) (string, error) {
-	body, err := c.do(http.MethodGet, "/_status", "", url.Values{"format": []string{"xml"}})
+	svc, err := c.service(processName)
 	if err != nil {
 		return "", err
 	}
+
+	return svc.String(), nil
+}
+
+// ProcessStats returns the memory, CPU and uptime of a process as reported
+// by monit.
+func (c *MonitClient) ProcessStats(processName string) (ProcessStats, error) {
+	svc, err := c.service(processName)
+	if err != nil {
+		return ProcessStats{}, err
+	}
+
+	return svc.ProcessStats(), nil
+}
+
+// Services returns every service in the monit summary with its normalized
+// state, in the order monit reports them.
+func (c *MonitClient) Services() ([]ServiceSummary, error) {
+	monitStatus, err := c.status()
+	if err != nil {
+		return nil, err
+	}
+
+	services := make([]ServiceSummary, 0, len(monitStatus.Services))
+	for _, svc := range monitStatus.Services {
+		services = append(services, svc.Summary())
+	}
+	return services, nil
+}
+
+func (c *MonitClient) status() (MonitStatus, error) {
+	body, err := c.do(http.MethodGet, "/_status", "", url.Values{"format": []string{"xml"}})
+	if err != nil {
+		return MonitStatus{}, err
+	}
 	defer func() { _ = body.Close() }()
 
-	monitStatus, err := ParseXML(body)
+	return ParseXML(body)
+}
+
+func (c *MonitClient) service(processName string) (ServiceTag, error) {
+	monitStatus, err := c.status()
 	if err != nil {
-		return "", err
+		return ServiceTag{}, err
 	}
 
 	for _, svc := range monitStatus.Services {
 		if svc.Name == processName {
-			return svc.String(), nil
+			return svc, nil
 		}
 	}
 
-	return "", errors.New("service not found")
+	return ServiceTag{}, errors.New("service not found")
 }
 
 func (c *MonitClient) do(method, path, reqBody string, queryParams ...url.Values) (io.ReadCloser, error) {
+	interval := c.Retry.InitialInterval
+	if interval <= 0 {
+		interval = defaultRetryInitialInterval
+	}
+
+	for attempt := 1; ; attempt++ {
+		body, err := c.doOnce(method, path, reqBody, queryParams...)
+		if err == nil || attempt >= c.Retry.MaxAttempts || !isRetryable(err) {
+			return body, err
+		}
+
+		time.Sleep(interval)
+
+		interval *= 2
+		if c.Retry.MaxInterval > 0 && interval > c.Retry.MaxInterval {
+			interval = c.Retry.MaxInterval
+		}
+	}
+}
+
+type statusCodeError struct {
+	statusCode int
+}
+
+func (e statusCodeError) Error() string {
+	return fmt.Sprintf("status code: %d", e.statusCode)
+}
+
+// isRetryable reports whether a failed request may succeed if attempted
+// again. Server errors and transport failures are retried; client errors
+// such as a 401 indicate a problem that retrying cannot fix.
+func isRetryable(err error) bool {
+	if errors.Cause(err) == ErrRequestTimeout {
+		return true
+	}
+	if statusErr, ok := err.(statusCodeError); ok {
+		return statusErr.statusCode >= http.StatusInternalServerError
+	}
+	_, isURLErr := err.(*url.Error)
+	return isURLErr
+}
+
+func (c *MonitClient) doOnce(method, path, reqBody string, queryParams ...url.Values) (io.ReadCloser, error) {
 	body := strings.NewReader(reqBody)
 
+	timeout := c.RequestTimeout
+	if timeout <= 0 {
+		timeout = defaultRequestTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+
 	reqURL := c.URL.ResolveReference(&url.URL{Path: path})
-	req, err := http.NewRequest(method, reqURL.String(), body)
+	req, err := http.NewRequestWithContext(ctx, method, reqURL.String(), body)
 	if err != nil {
+		cancel()
 		return nil, err
 	}
 
@@ -120,15 +360,45 @@ func (c *MonitClient) do(method, path, reqBody string, queryParams ...url.Values
 		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 	}
 
-	response, err := http.DefaultClient.Do(req)
+	httpClient := c.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	response, err := httpClient.Do(req)
 	if err != nil {
+		cancel()
+		if ctx.Err() == context.DeadlineExceeded {
+			return nil, errors.Wrapf(ErrRequestTimeout, "%s %s after %s", method, path, timeout)
+		}
 		return nil, err
 	}
 
 	switch response.StatusCode {
 	case http.StatusOK:
-		return response.Body, nil
+		return cancelOnClose{ReadCloser: response.Body, cancel: cancel}, nil
 	default:
-		return nil, errors.Errorf("status code: %d", response.StatusCode)
+		drainAndClose(response.Body)
+		cancel()
+		return nil, statusCodeError{statusCode: response.StatusCode}
 	}
 }
+
+// cancelOnClose releases the request's timeout once the caller has finished
+// reading the response body.
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (c cancelOnClose) Close() error {
+	defer c.cancel()
+	return drainAndClose(c.ReadCloser)
+}
+
+// drainAndClose reads whatever the caller left of a response body before
+// closing it, so that the connection can be reused for the next request.
+func drainAndClose(body io.ReadCloser) error {
+	_, _ = io.Copy(ioutil.Discard, body)
+	return body.Close()
+}
@@ -2,12 +2,17 @@ package monit_client_test
 
 import (
 	"io/ioutil"
+	"net"
 	"net/http"
+	"net/http/httptest"
 	"net/url"
+	"os"
 	"path/filepath"
+	"sync/atomic"
 	"time"
 
 	"github.com/onsi/gomega/ghttp"
+	"github.com/pkg/errors"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -31,7 +36,7 @@ var _ = Describe("Monit", func() {
 
 	BeforeEach(func() {
 		server = ghttp.NewServer()
-		monitClient = monit_client.NewClient(server.Addr(), "monit-user", "monit-password", 2*time.Second)
+		monitClient = monit_client.NewClient(server.Addr(), "monit-user", "monit-password", 2*time.Second, monit_client.RetryConfig{})
 	})
 
 	AfterEach(func() {
@@ -162,6 +167,107 @@ var _ = Describe("Monit", func() {
 		})
 	})
 
+	Describe("reload", func() {
+		var runner *fakeRunner
+
+		BeforeEach(func() {
+			runner = &fakeRunner{output: []byte("Reinitializing monit daemon\n")}
+			monitClient.Binary = "/var/vcap/bosh/bin/monit"
+			monitClient.Runner = runner
+
+			// Monit's _runtime handler only acts on validate and stop, so
+			// the stub rejects any other action.
+			server.RouteToHandler(http.MethodPost, "/_runtime", func(w http.ResponseWriter, req *http.Request) {
+				Expect(req.ParseForm()).To(Succeed())
+				switch req.PostForm.Get("action") {
+				case "validate", "stop":
+					w.WriteHeader(http.StatusOK)
+				default:
+					http.Error(w, "Invalid action", http.StatusBadRequest)
+				}
+			})
+		})
+
+		It("runs monit reload, which signals the monit daemon", func() {
+			response, err := monitClient.Reload()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(response).To(Equal("Reinitializing monit daemon"))
+
+			Expect(runner.calls).To(Equal([][]string{{"/var/vcap/bosh/bin/monit", "reload"}}))
+			Expect(server.ReceivedRequests()).To(BeEmpty())
+		})
+
+		It("returns an error with the output when monit reload fails", func() {
+			runner.output = []byte("monit: no status file\n")
+			runner.err = errors.New("exit status 1")
+
+			_, err := monitClient.Reload()
+			Expect(err).To(MatchError("failed to reload monit: monit: no status file: exit status 1"))
+		})
+	})
+
+	Describe("unmonitor", func() {
+		It("makes an unmonitor request and waits for monit to stop monitoring the service", func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest(http.MethodPost, "/mysql"),
+					ghttp.VerifyContentType("application/x-www-form-urlencoded"),
+					ghttp.VerifyBasicAuth("monit-user", "monit-password"),
+					ghttp.VerifyBody([]byte(`action=unmonitor`)),
+					ghttp.RespondWith(http.StatusOK, nil),
+				),
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest(http.MethodGet, "/_status", "format=xml"),
+					ghttp.VerifyBasicAuth("monit-user", "monit-password"),
+					ghttp.RespondWith(http.StatusOK, Fixture("unmonitored.xml")),
+				),
+			)
+
+			Expect(monitClient.Unmonitor("mysql")).To(Succeed())
+		})
+
+		It("reports a service that is unmonitored but still running as not monitored rather than stopped", func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest(http.MethodPost, "/mysql"),
+					ghttp.VerifyBody([]byte(`action=unmonitor`)),
+					ghttp.RespondWith(http.StatusOK, nil),
+				),
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest(http.MethodGet, "/_status", "format=xml"),
+					ghttp.RespondWith(http.StatusOK, Fixture("unmonitored.xml")),
+				),
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest(http.MethodGet, "/_status", "format=xml"),
+					ghttp.RespondWith(http.StatusOK, Fixture("unmonitored.xml")),
+				),
+			)
+
+			Expect(monitClient.Unmonitor("mysql")).To(Succeed())
+
+			status, err := monitClient.Status("mysql")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(status).To(Equal("not monitored"))
+			Expect(monit_client.NormalizeStatus(status)).To(Equal(monit_client.StateNotMonitored))
+		})
+	})
+
+	Describe("monitor", func() {
+		It("makes a monitor request to the monit API", func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest(http.MethodPost, "/mysql"),
+					ghttp.VerifyContentType("application/x-www-form-urlencoded"),
+					ghttp.VerifyBasicAuth("monit-user", "monit-password"),
+					ghttp.VerifyBody([]byte(`action=monitor`)),
+					ghttp.RespondWith(http.StatusOK, nil),
+				),
+			)
+
+			Expect(monitClient.Monitor("mysql")).To(Succeed())
+		})
+	})
+
 	Describe("stop", func() {
 		It("makes a stop request to the monit API", func() {
 			server.AppendHandlers(
@@ -183,6 +289,29 @@ var _ = Describe("Monit", func() {
 			Expect(err).NotTo(HaveOccurred())
 		})
 
+		It("reports a stopped service as stopped", func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest(http.MethodPost, "/mysql"),
+					ghttp.VerifyBody([]byte(`action=stop`)),
+					ghttp.RespondWith(http.StatusOK, nil),
+				),
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest(http.MethodGet, "/_status", "format=xml"),
+					ghttp.RespondWith(http.StatusOK, Fixture("stopped.xml")),
+				),
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest(http.MethodGet, "/_status", "format=xml"),
+					ghttp.RespondWith(http.StatusOK, Fixture("stopped.xml")),
+				),
+			)
+
+			Expect(monitClient.Stop("mysql")).To(Succeed())
+
+			status, err := monitClient.Status("mysql")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(monit_client.NormalizeStatus(status)).To(Equal(monit_client.StateStopped))
+		})
 		It("returns a timeout error when the service doesn't reach the desired state", func() {
 			server.AppendHandlers(
 				ghttp.CombineHandlers(
@@ -372,4 +501,268 @@ var _ = Describe("Monit", func() {
 			})
 		})
 	})
+
+	Describe("process stats", func() {
+		It("returns the memory, cpu and uptime monit reports for the service", func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest(http.MethodGet, "/_status", "format=xml"),
+					ghttp.VerifyBasicAuth("monit-user", "monit-password"),
+					ghttp.RespondWith(http.StatusOK, Fixture("process_stats.xml")),
+				),
+			)
+
+			stats, err := monitClient.ProcessStats("mysql")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(stats).To(Equal(monit_client.ProcessStats{
+				MemoryKB:      195060,
+				CPUPercent:    0.2,
+				UptimeSeconds: 247559,
+			}))
+		})
+
+		Context("when a service does not exist", func() {
+			It("returns an error", func() {
+				server.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest(http.MethodGet, "/_status", "format=xml"),
+						ghttp.RespondWith(http.StatusOK, Fixture("missing.xml")),
+					),
+				)
+
+				_, err := monitClient.ProcessStats("mysql")
+				Expect(err).To(MatchError(`service not found`))
+			})
+		})
+	})
+
+	Describe("services", func() {
+		It("returns every service in the monit summary with its normalized state", func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest(http.MethodGet, "/_status", "format=xml"),
+					ghttp.VerifyBasicAuth("monit-user", "monit-password"),
+					ghttp.RespondWith(http.StatusOK, Fixture("multi_service.xml")),
+				),
+			)
+
+			services, err := monitClient.Services()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(services).To(Equal([]monit_client.ServiceSummary{
+				{Name: "galera-init", Status: monit_client.StateRunning},
+				{Name: "garbd", Status: monit_client.StateStopped},
+				{Name: "proxy", Status: monit_client.StateFailed},
+				{Name: "galera-agent", Status: monit_client.StatePending},
+				{Name: "system_localhost", Status: monit_client.StatePending},
+			}))
+		})
+
+		It("returns an error when monit is unavailable", func() {
+			server.AppendHandlers(ghttp.RespondWith(http.StatusUnauthorized, nil))
+
+			_, err := monitClient.Services()
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Describe("retries", func() {
+		BeforeEach(func() {
+			monitClient.Retry = monit_client.RetryConfig{
+				MaxAttempts:     3,
+				InitialInterval: 10 * time.Millisecond,
+				MaxInterval:     20 * time.Millisecond,
+			}
+		})
+
+		It("retries transient server errors until the request succeeds", func() {
+			server.AppendHandlers(
+				ghttp.RespondWith(http.StatusServiceUnavailable, nil),
+				ghttp.RespondWith(http.StatusServiceUnavailable, nil),
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest(http.MethodGet, "/_status", "format=xml"),
+					ghttp.VerifyBasicAuth("monit-user", "monit-password"),
+					ghttp.RespondWith(http.StatusOK, Fixture("started.xml")),
+				),
+			)
+
+			status, err := monitClient.Status("mysql")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(status).To(Equal("running"))
+			Expect(server.ReceivedRequests()).To(HaveLen(3))
+		})
+
+		It("resends the request body on every attempt", func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyBody([]byte(`action=stop`)),
+					ghttp.RespondWith(http.StatusServiceUnavailable, nil),
+				),
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest(http.MethodPost, "/mysql"),
+					ghttp.VerifyBody([]byte(`action=stop`)),
+					ghttp.RespondWith(http.StatusOK, nil),
+				),
+				ghttp.RespondWith(http.StatusOK, Fixture("stopped.xml")),
+			)
+
+			err := monitClient.Stop("mysql")
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("gives up after the maximum number of attempts", func() {
+			server.AppendHandlers(
+				ghttp.RespondWith(http.StatusServiceUnavailable, nil),
+				ghttp.RespondWith(http.StatusServiceUnavailable, nil),
+				ghttp.RespondWith(http.StatusServiceUnavailable, nil),
+			)
+
+			_, err := monitClient.Status("mysql")
+			Expect(err).To(MatchError("status code: 503"))
+			Expect(server.ReceivedRequests()).To(HaveLen(3))
+		})
+
+		It("does not retry client errors", func() {
+			server.AppendHandlers(
+				ghttp.RespondWith(http.StatusUnauthorized, nil),
+			)
+
+			err := monitClient.Start("mysql")
+			Expect(err).To(MatchError("failed to make start request for mysql: status code: 401"))
+			Expect(server.ReceivedRequests()).To(HaveLen(1))
+		})
+	})
+
+	Describe("request timeout", func() {
+		var (
+			hungServer *httptest.Server
+			release    chan struct{}
+		)
+
+		BeforeEach(func() {
+			release = make(chan struct{})
+			hungServer = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				<-release
+			}))
+
+			monitClient = monit_client.NewClient(hungServer.Listener.Addr().String(), "monit-user", "monit-password", 2*time.Second, monit_client.RetryConfig{})
+			monitClient.RequestTimeout = 100 * time.Millisecond
+		})
+
+		AfterEach(func() {
+			close(release)
+			hungServer.Close()
+		})
+
+		It("gives up on a monit that never responds", func() {
+			start := time.Now()
+			_, err := monitClient.Status("mysql")
+			Expect(time.Since(start)).To(BeNumerically("<", 1*time.Second))
+
+			Expect(errors.Cause(err)).To(Equal(monit_client.ErrRequestTimeout))
+			Expect(err).To(MatchError(ContainSubstring("GET /_status after 100ms")))
+		})
+
+		It("applies to start requests", func() {
+			err := monitClient.Start("mysql")
+			Expect(errors.Cause(err)).To(Equal(monit_client.ErrRequestTimeout))
+		})
+	})
+
+	Describe("connection reuse", func() {
+		var (
+			statusServer *httptest.Server
+			connections  int32
+		)
+
+		BeforeEach(func() {
+			atomic.StoreInt32(&connections, 0)
+
+			statusServer = httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write(Fixture("started.xml"))
+			}))
+			statusServer.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+				if state == http.StateNew {
+					atomic.AddInt32(&connections, 1)
+				}
+			}
+			statusServer.Start()
+
+			monitClient = monit_client.NewClient(statusServer.Listener.Addr().String(), "monit-user", "monit-password", 2*time.Second, monit_client.RetryConfig{})
+		})
+
+		AfterEach(func() {
+			statusServer.Close()
+		})
+
+		It("reuses one connection across status requests", func() {
+			for i := 0; i < 5; i++ {
+				status, err := monitClient.Status("mysql")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(status).To(Equal("running"))
+			}
+
+			Expect(atomic.LoadInt32(&connections)).To(Equal(int32(1)))
+		})
+
+		It("reuses the connection after a start request", func() {
+			Expect(monitClient.Start("mysql")).To(Succeed())
+			_, err := monitClient.Status("mysql")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(atomic.LoadInt32(&connections)).To(Equal(int32(1)))
+		})
+	})
+
+	Describe("over a unix socket", func() {
+		var (
+			socketServer *ghttp.Server
+			tempDir      string
+		)
+
+		BeforeEach(func() {
+			var err error
+			tempDir, err = ioutil.TempDir("", "monit-socket")
+			Expect(err).NotTo(HaveOccurred())
+
+			socketPath := filepath.Join(tempDir, "monit.sock")
+			listener, err := net.Listen("unix", socketPath)
+			Expect(err).NotTo(HaveOccurred())
+
+			socketServer = ghttp.NewUnstartedServer()
+			socketServer.HTTPTestServer.Listener = listener
+			socketServer.Start()
+
+			monitClient = monit_client.NewUnixSocketClient(socketPath, "monit-user", "monit-password", 2*time.Second, monit_client.RetryConfig{})
+		})
+
+		AfterEach(func() {
+			socketServer.Close()
+			os.RemoveAll(tempDir)
+		})
+
+		It("fetches the status from monit", func() {
+			socketServer.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest(http.MethodGet, "/_status", "format=xml"),
+					ghttp.VerifyBasicAuth("monit-user", "monit-password"),
+					ghttp.RespondWith(http.StatusOK, Fixture("started.xml")),
+				),
+			)
+
+			status, err := monitClient.Status("mysql")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(status).To(Equal("running"))
+		})
+	})
 })
+
+type fakeRunner struct {
+	calls  [][]string
+	output []byte
+	err    error
+}
+
+func (r *fakeRunner) Run(name string, args ...string) ([]byte, error) {
+	r.calls = append(r.calls, append([]string{name}, args...))
+	return r.output, r.err
+}
@@ -14,11 +14,53 @@ type MonitStatus struct {
 }
 
 type ServiceTag struct {
-	XMLName       xml.Name `xml:"service"`
-	Name          string   `xml:"name"`
-	Status        int      `xml:"status"`
-	Monitor       int      `xml:"monitor"`
-	PendingAction int      `xml:"pendingaction"`
+	XMLName       xml.Name  `xml:"service"`
+	Name          string    `xml:"name"`
+	Status        int       `xml:"status"`
+	Monitor       int       `xml:"monitor"`
+	PendingAction int       `xml:"pendingaction"`
+	Pid           int       `xml:"pid"`
+	Uptime        int64     `xml:"uptime"`
+	Memory        MemoryTag `xml:"memory"`
+	CPU           CPUTag    `xml:"cpu"`
+}
+
+type MemoryTag struct {
+	Kilobyte      int64 `xml:"kilobyte"`
+	KilobyteTotal int64 `xml:"kilobytetotal"`
+}
+
+type CPUTag struct {
+	Percent      float64 `xml:"percent"`
+	PercentTotal float64 `xml:"percenttotal"`
+}
+
+// ProcessStats is the resource usage of a process as last sampled by monit.
+type ProcessStats struct {
+	MemoryKB      int64   `json:"memory_kb"`
+	CPUPercent    float64 `json:"cpu_percent"`
+	UptimeSeconds int64   `json:"uptime_seconds"`
+}
+
+func (t ServiceTag) ProcessStats() ProcessStats {
+	return ProcessStats{
+		MemoryKB:      t.Memory.Kilobyte,
+		CPUPercent:    t.CPU.Percent,
+		UptimeSeconds: t.Uptime,
+	}
+}
+
+// ServiceSummary is a monit service and its normalized state.
+type ServiceSummary struct {
+	Name   string       `json:"name"`
+	Status ServiceState `json:"status"`
+}
+
+func (t ServiceTag) Summary() ServiceSummary {
+	return ServiceSummary{
+		Name:   t.Name,
+		Status: t.State(),
+	}
 }
 
 type ServiceStatus string
@@ -29,15 +71,22 @@ const (
 	MonitMonitorStatusInitializing = 2
 	ServicePending                 = "pending"
 	ServiceStopped                 = "stopped"
+	ServiceNotMonitored            = "not monitored"
 	ServiceInitializing            = "initializing"
 	ServiceRunning                 = "running"
 	ServiceFailing                 = "failing"
 )
 
+// String describes the service's status. Monit stops monitoring a service
+// both when it is stopped and when it is unmonitored; a service that monit no
+// longer monitors but that still has a process is reported as not monitored
+// rather than stopped.
 func (t ServiceTag) String() string {
 	switch {
 	case t.PendingAction != 0:
 		return ServicePending
+	case t.Monitor == MonitMonitorStatusStopped && t.Pid > 0:
+		return ServiceNotMonitored
 	case t.Monitor == MonitMonitorStatusStopped:
 		return ServiceStopped
 	case t.Monitor == MonitMonitorStatusInitializing:
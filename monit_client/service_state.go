@@ -0,0 +1,46 @@
+package monit_client
+
+import "strings"
+
+// ServiceState is a monit service status normalized across the spellings
+// used by different monit versions and interfaces, such as "running",
+// "Running" and "Running - Accessible".
+type ServiceState string
+
+const (
+	StateRunning      ServiceState = "running"
+	StateStopped      ServiceState = "stopped"
+	StateNotMonitored ServiceState = "not_monitored"
+	StatePending      ServiceState = "pending"
+	StateFailed       ServiceState = "failed"
+	StateUnknown      ServiceState = "unknown"
+)
+
+// NormalizeStatus maps a status reported by monit, or returned by Status, to
+// a ServiceState.
+func NormalizeStatus(raw string) ServiceState {
+	status := strings.ToLower(strings.TrimSpace(raw))
+	if i := strings.Index(status, " - "); i >= 0 {
+		status = strings.TrimSpace(status[:i])
+	}
+
+	switch {
+	case status == "running", status == "ok", status == "accessible", status == "online":
+		return StateRunning
+	case status == "stopped":
+		return StateStopped
+	case status == "not monitored", status == "unmonitored", status == "not_monitored":
+		return StateNotMonitored
+	case status == "initializing", strings.HasSuffix(status, "pending"):
+		return StatePending
+	case status == "failing", status == "failed", strings.Contains(status, "failed"), status == "does not exist":
+		return StateFailed
+	default:
+		return StateUnknown
+	}
+}
+
+// State returns the normalized state of the service.
+func (t ServiceTag) State() ServiceState {
+	return NormalizeStatus(t.String())
+}
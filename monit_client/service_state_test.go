@@ -0,0 +1,33 @@
+package monit_client_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+
+	"github.com/cloudfoundry-incubator/galera-healthcheck/monit_client"
+)
+
+var _ = Describe("NormalizeStatus", func() {
+	DescribeTable("maps monit status spellings to a ServiceState",
+		func(raw string, expected monit_client.ServiceState) {
+			Expect(monit_client.NormalizeStatus(raw)).To(Equal(expected))
+		},
+		Entry("running", "running", monit_client.StateRunning),
+		Entry("Running", "Running", monit_client.StateRunning),
+		Entry("running - Accessible", "running - Accessible", monit_client.StateRunning),
+		Entry("OK", "OK", monit_client.StateRunning),
+		Entry("stopped", "stopped", monit_client.StateStopped),
+		Entry("Not monitored", "Not monitored", monit_client.StateNotMonitored),
+		Entry("Not monitored - start pending", "Not monitored - start pending", monit_client.StateNotMonitored),
+		Entry("unmonitored", "unmonitored", monit_client.StateNotMonitored),
+		Entry("pending", "pending", monit_client.StatePending),
+		Entry("Start pending", "Start pending", monit_client.StatePending),
+		Entry("Initializing", "Initializing", monit_client.StatePending),
+		Entry("failing", "failing", monit_client.StateFailed),
+		Entry("Execution failed", "Execution failed", monit_client.StateFailed),
+		Entry("Execution failed | Does not exist", "Execution failed | Does not exist", monit_client.StateFailed),
+		Entry("Does not exist", "Does not exist", monit_client.StateFailed),
+		Entry("an unrecognized status", "exploded", monit_client.StateUnknown),
+	)
+})
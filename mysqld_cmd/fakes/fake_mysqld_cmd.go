@@ -15,6 +15,13 @@ type FakeMysqldCmd struct {
 		result1 string
 		result2 error
 	}
+	RecoverPositionStub        func() (mysqld_cmd.RecoveredPosition, error)
+	recoverPositionMutex       sync.RWMutex
+	recoverPositionArgsForCall []struct{}
+	recoverPositionReturns     struct {
+		result1 mysqld_cmd.RecoveredPosition
+		result2 error
+	}
 }
 
 func (fake *FakeMysqldCmd) RecoverSeqno() (string, error) {
@@ -42,4 +49,29 @@ func (fake *FakeMysqldCmd) RecoverSeqnoReturns(result1 string, result2 error) {
 	}{result1, result2}
 }
 
+func (fake *FakeMysqldCmd) RecoverPosition() (mysqld_cmd.RecoveredPosition, error) {
+	fake.recoverPositionMutex.Lock()
+	fake.recoverPositionArgsForCall = append(fake.recoverPositionArgsForCall, struct{}{})
+	fake.recoverPositionMutex.Unlock()
+	if fake.RecoverPositionStub != nil {
+		return fake.RecoverPositionStub()
+	} else {
+		return fake.recoverPositionReturns.result1, fake.recoverPositionReturns.result2
+	}
+}
+
+func (fake *FakeMysqldCmd) RecoverPositionCallCount() int {
+	fake.recoverPositionMutex.RLock()
+	defer fake.recoverPositionMutex.RUnlock()
+	return len(fake.recoverPositionArgsForCall)
+}
+
+func (fake *FakeMysqldCmd) RecoverPositionReturns(result1 mysqld_cmd.RecoveredPosition, result2 error) {
+	fake.RecoverPositionStub = nil
+	fake.recoverPositionReturns = struct {
+		result1 mysqld_cmd.RecoveredPosition
+		result2 error
+	}{result1, result2}
+}
+
 var _ mysqld_cmd.MysqldCmd = new(FakeMysqldCmd)
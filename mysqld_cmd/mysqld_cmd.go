@@ -1,13 +1,13 @@
 package mysqld_cmd
 
 import (
-	"errors"
 	"fmt"
 	"io/ioutil"
 	"os"
 	"os/exec"
-	"path"
 	"regexp"
+	"strconv"
+	"sync"
 
 	"code.cloudfoundry.org/lager"
 	"github.com/cloudfoundry-incubator/galera-healthcheck/config"
@@ -15,20 +15,59 @@ import (
 
 type MysqldCmd interface {
 	RecoverSeqno() (string, error)
+	RecoverPosition() (RecoveredPosition, error)
+}
+
+// RecoveredPosition is the cluster state UUID and seqno reported by
+// mysqld --wsrep-recover.
+type RecoveredPosition struct {
+	UUID  string `json:"uuid"`
+	Seqno int    `json:"seqno"`
+}
+
+// CommandRunner runs an external command and returns its combined output.
+type CommandRunner interface {
+	Run(name string, args ...string) ([]byte, error)
+}
+
+// ExecCommandRunner runs commands with os/exec.
+type ExecCommandRunner struct{}
+
+func (ExecCommandRunner) Run(name string, args ...string) ([]byte, error) {
+	return exec.Command(name, args...).CombinedOutput()
 }
 
 type mysqldCmd struct {
 	logger       lager.Logger
 	mysqldconfig config.Config
+	runner       CommandRunner
+
+	// recoveryMu serializes runs of mysqld --wsrep-recover, which must not
+	// run concurrently against the same datadir.
+	recoveryMu sync.Mutex
 }
 
-func NewMysqldCmd(logger lager.Logger, mysqldconfig config.Config) MysqldCmd {
+func NewMysqldCmd(logger lager.Logger, mysqldconfig config.Config, runner CommandRunner) MysqldCmd {
 	return &mysqldCmd{
 		logger:       logger,
 		mysqldconfig: mysqldconfig,
+		runner:       runner,
 	}
 }
 
+// RecoveredPositionPatterns match the line in which known Galera
+// distributions log the position recovered by mysqld --wsrep-recover. Each
+// has a uuid and a seqno group. They are tried in order unless
+// WsrepRecoverPattern is configured.
+var RecoveredPositionPatterns = []*regexp.Regexp{
+	// MariaDB and Percona XtraDB Cluster 5.7:
+	//   [Note] WSREP: Recovered position: <uuid>:<seqno>
+	regexp.MustCompile(`WSREP: Recovered position:\s*(?P<uuid>[0-9a-fA-F-]+):(?P<seqno>-?\d+)`),
+	// Percona XtraDB Cluster 8.0:
+	//   [Note] [MY-000000] [WSREP] Recovered position: <uuid>:<seqno>
+	regexp.MustCompile(`\[WSREP\] Recovered position:\s*(?P<uuid>[0-9a-fA-F-]+):(?P<seqno>-?\d+)`),
+}
+
 /*
 * Why?
 *
@@ -39,16 +78,103 @@ func NewMysqldCmd(logger lager.Logger, mysqldconfig config.Config) MysqldCmd {
 * flag
  */
 func (m *mysqldCmd) RecoverSeqno() (string, error) {
+	stderr, err := m.runRecovery()
+	if err != nil {
+		return "", err
+	}
+
+	_, seqno, err := m.parsePosition(stderr)
+	if err != nil {
+		m.logger.Error("Failed to parse seqno from logs", err)
+		return "", err
+	}
 
-	errorLogFile := path.Join(os.TempDir(), "galera-healthcheck-mysqld-log.err")
-	os.RemoveAll(errorLogFile) //ensure log is empty
+	return seqno, nil
+}
 
-	cmd := exec.Command(m.mysqldconfig.MysqldPath,
-		fmt.Sprintf("--defaults-file=%s", m.mysqldconfig.MyCnfPath),
-		"--wsrep-recover",
-		fmt.Sprintf("--log-error=%s", errorLogFile))
+func (m *mysqldCmd) RecoverPosition() (RecoveredPosition, error) {
+	stderr, err := m.runRecovery()
+	if err != nil {
+		return RecoveredPosition{}, err
+	}
+
+	uuid, rawSeqno, err := m.parsePosition(stderr)
+	if err != nil {
+		m.logger.Error("Failed to parse position from logs", err)
+		return RecoveredPosition{}, err
+	}
+
+	seqno, err := strconv.Atoi(rawSeqno)
+	if err != nil {
+		return RecoveredPosition{}, err
+	}
 
-	stdout, cmdErr := cmd.CombinedOutput()
+	return RecoveredPosition{
+		UUID:  uuid,
+		Seqno: seqno,
+	}, nil
+}
+
+// parsePosition finds the recovered position in the wsrep-recover log using
+// the configured WsrepRecoverPattern, or else the first of
+// RecoveredPositionPatterns that matches.
+func (m *mysqldCmd) parsePosition(stderr string) (uuid, seqno string, err error) {
+	patterns := RecoveredPositionPatterns
+	if m.mysqldconfig.WsrepRecoverPattern != "" {
+		pattern, err := regexp.Compile(m.mysqldconfig.WsrepRecoverPattern)
+		if err != nil {
+			return "", "", err
+		}
+		patterns = []*regexp.Regexp{pattern}
+	}
+
+	for _, pattern := range patterns {
+		match := pattern.FindStringSubmatch(stderr)
+		if match == nil {
+			continue
+		}
+
+		for i, name := range pattern.SubexpNames() {
+			switch name {
+			case "uuid":
+				uuid = match[i]
+			case "seqno":
+				seqno = match[i]
+			}
+		}
+
+		if seqno != "" {
+			return uuid, seqno, nil
+		}
+	}
+
+	return "", "", fmt.Errorf("Couldn't find regex matching a recovered position in %d patterns", len(patterns))
+}
+
+// runRecovery runs mysqld --wsrep-recover, or the configured
+// WsrepRecoverArgs, and returns what it logged to stderr.
+func (m *mysqldCmd) runRecovery() (string, error) {
+	m.recoveryMu.Lock()
+	defer m.recoveryMu.Unlock()
+
+	logFile, err := ioutil.TempFile("", "galera-healthcheck-mysqld-*.err")
+	if err != nil {
+		return "", err
+	}
+	errorLogFile := logFile.Name()
+	logFile.Close()
+	defer os.Remove(errorLogFile)
+
+	args := m.mysqldconfig.WsrepRecoverArgs
+	if len(args) == 0 {
+		args = []string{
+			fmt.Sprintf("--defaults-file=%s", m.mysqldconfig.MyCnfPath),
+			"--wsrep-recover",
+		}
+	}
+	args = append(append([]string{}, args...), fmt.Sprintf("--log-error=%s", errorLogFile))
+
+	stdout, cmdErr := m.runner.Run(m.mysqldconfig.MysqldPath, args...)
 	stderr, readingLogErr := ioutil.ReadFile(errorLogFile)
 	if readingLogErr != nil {
 		stderr = []byte("failed to read stderr")
@@ -64,17 +190,5 @@ func (m *mysqldCmd) RecoverSeqno() (string, error) {
 		m.logger.Debug(string(stdout))
 	}
 
-	seqNoRegex := `WSREP: Recovered position:.*:(-?\d+)`
-	re := regexp.MustCompile(seqNoRegex)
-	sequenceNumberLogLine := re.FindStringSubmatch(string(stderr))
-
-	if len(sequenceNumberLogLine) < 2 {
-		// First match is the whole string, second match is the seq no
-		err := errors.New(fmt.Sprintf("Couldn't find regex: %s Log Line: %s", seqNoRegex, sequenceNumberLogLine))
-		m.logger.Error("Failed to parse seqno from logs", err)
-		return "", err
-	}
-
-	sequenceNumber := sequenceNumberLogLine[1]
-	return sequenceNumber, nil
+	return string(stderr), nil
 }
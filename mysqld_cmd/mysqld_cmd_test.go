@@ -0,0 +1,211 @@
+package mysqld_cmd_test
+
+import (
+	"errors"
+	"io/ioutil"
+	"strings"
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/lager/lagertest"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/cloudfoundry-incubator/galera-healthcheck/config"
+	"github.com/cloudfoundry-incubator/galera-healthcheck/mysqld_cmd"
+)
+
+// stubRunner stands in for mysqld --wsrep-recover, which reports the
+// recovered position in the file named by --log-error.
+type stubRunner struct {
+	name     string
+	args     []string
+	errorLog string
+	err      error
+}
+
+func (s *stubRunner) Run(name string, args ...string) ([]byte, error) {
+	s.name = name
+	s.args = args
+
+	for _, arg := range args {
+		if strings.HasPrefix(arg, "--log-error=") {
+			path := strings.TrimPrefix(arg, "--log-error=")
+			Expect(ioutil.WriteFile(path, []byte(s.errorLog), 0644)).To(Succeed())
+		}
+	}
+
+	return nil, s.err
+}
+
+// slowRunner records how many recoveries overlap and which log files they
+// were given.
+type slowRunner struct {
+	errorLog string
+
+	mu        sync.Mutex
+	active    int
+	maxActive int
+	logFiles  []string
+}
+
+func (s *slowRunner) Run(name string, args ...string) ([]byte, error) {
+	path := strings.TrimPrefix(args[len(args)-1], "--log-error=")
+
+	s.mu.Lock()
+	s.active++
+	if s.active > s.maxActive {
+		s.maxActive = s.active
+	}
+	s.logFiles = append(s.logFiles, path)
+	s.mu.Unlock()
+
+	time.Sleep(20 * time.Millisecond)
+	err := ioutil.WriteFile(path, []byte(s.errorLog), 0644)
+
+	s.mu.Lock()
+	s.active--
+	s.mu.Unlock()
+
+	return nil, err
+}
+
+var _ = Describe("MysqldCmd", func() {
+	var (
+		runner *stubRunner
+		cmd    mysqld_cmd.MysqldCmd
+	)
+
+	BeforeEach(func() {
+		fixture, err := ioutil.ReadFile("fixtures/wsrep_recover.err")
+		Expect(err).NotTo(HaveOccurred())
+
+		runner = &stubRunner{errorLog: string(fixture)}
+		cmd = mysqld_cmd.NewMysqldCmd(lagertest.NewTestLogger("mysqld_cmd"), config.Config{
+			MysqldPath: "/var/vcap/packages/pxc/bin/mysqld",
+			MyCnfPath:  "/var/vcap/jobs/pxc-mysql/config/my.cnf",
+		}, runner)
+	})
+
+	Describe("RecoverSeqno", func() {
+		It("parses the seqno from the wsrep-recover log", func() {
+			Expect(cmd.RecoverSeqno()).To(Equal("1412"))
+		})
+
+		It("runs mysqld with --wsrep-recover", func() {
+			_, err := cmd.RecoverSeqno()
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(runner.name).To(Equal("/var/vcap/packages/pxc/bin/mysqld"))
+			Expect(runner.args).To(HaveLen(3))
+			Expect(runner.args[:2]).To(Equal([]string{
+				"--defaults-file=/var/vcap/jobs/pxc-mysql/config/my.cnf",
+				"--wsrep-recover",
+			}))
+			Expect(runner.args[2]).To(HavePrefix("--log-error="))
+		})
+
+		It("returns an error when the log has no recovered position", func() {
+			runner.errorLog = "[Note] Binlog end\n"
+
+			_, err := cmd.RecoverSeqno()
+			Expect(err).To(MatchError(ContainSubstring("Couldn't find regex")))
+		})
+
+		It("returns an error when mysqld fails", func() {
+			runner.err = errors.New("exit status 1")
+
+			_, err := cmd.RecoverSeqno()
+			Expect(err).To(MatchError("exit status 1"))
+		})
+	})
+
+	Describe("RecoverPosition", func() {
+		It("parses the cluster state UUID and seqno from the wsrep-recover log", func() {
+			Expect(cmd.RecoverPosition()).To(Equal(mysqld_cmd.RecoveredPosition{
+				UUID:  "7d36e7d8-ac0a-11ea-8d8a-1a5c34b0ab6e",
+				Seqno: 1412,
+			}))
+		})
+
+		It("parses the MariaDB wsrep-recover log format", func() {
+			fixture, err := ioutil.ReadFile("fixtures/wsrep_recover_mariadb.err")
+			Expect(err).NotTo(HaveOccurred())
+			runner.errorLog = string(fixture)
+
+			Expect(cmd.RecoverPosition()).To(Equal(mysqld_cmd.RecoveredPosition{
+				UUID:  "9e9a6e2a-7b4f-11eb-9b3c-3a7f1a0b2c4d",
+				Seqno: 318,
+			}))
+		})
+
+		It("parses the Percona XtraDB Cluster 8.0 wsrep-recover log format", func() {
+			fixture, err := ioutil.ReadFile("fixtures/wsrep_recover_pxc8.err")
+			Expect(err).NotTo(HaveOccurred())
+			runner.errorLog = string(fixture)
+
+			Expect(cmd.RecoverPosition()).To(Equal(mysqld_cmd.RecoveredPosition{
+				UUID:  "3c2e7a54-1f9b-11ed-a26e-0242ac120002",
+				Seqno: 2057,
+			}))
+		})
+
+		Context("when WsrepRecoverPattern is configured", func() {
+			BeforeEach(func() {
+				runner.errorLog = "[Note] custom-galera: restored state 5b1d2c3e-0000-11ee-8000-0242ac120002 at 77\n"
+				cmd = mysqld_cmd.NewMysqldCmd(lagertest.NewTestLogger("mysqld_cmd"), config.Config{
+					MysqldPath:          "/var/vcap/packages/pxc/bin/mysqld",
+					MyCnfPath:           "/var/vcap/jobs/pxc-mysql/config/my.cnf",
+					WsrepRecoverPattern: `restored state (?P<uuid>\S+) at (?P<seqno>-?\d+)`,
+				}, runner)
+			})
+
+			It("parses the position with the configured pattern", func() {
+				Expect(cmd.RecoverPosition()).To(Equal(mysqld_cmd.RecoveredPosition{
+					UUID:  "5b1d2c3e-0000-11ee-8000-0242ac120002",
+					Seqno: 77,
+				}))
+			})
+
+			It("does not fall back to the built-in patterns", func() {
+				fixture, err := ioutil.ReadFile("fixtures/wsrep_recover.err")
+				Expect(err).NotTo(HaveOccurred())
+				runner.errorLog = string(fixture)
+
+				_, err = cmd.RecoverSeqno()
+				Expect(err).To(MatchError(ContainSubstring("Couldn't find regex")))
+			})
+		})
+	})
+
+	Describe("concurrent recoveries", func() {
+		It("runs one recovery at a time, each with its own log file", func() {
+			fixture, err := ioutil.ReadFile("fixtures/wsrep_recover.err")
+			Expect(err).NotTo(HaveOccurred())
+
+			slow := &slowRunner{errorLog: string(fixture)}
+			cmd = mysqld_cmd.NewMysqldCmd(lagertest.NewTestLogger("mysqld_cmd"), config.Config{
+				MysqldPath: "/var/vcap/packages/pxc/bin/mysqld",
+				MyCnfPath:  "/var/vcap/jobs/pxc-mysql/config/my.cnf",
+			}, slow)
+
+			var wg sync.WaitGroup
+			for i := 0; i < 3; i++ {
+				wg.Add(1)
+				go func() {
+					defer GinkgoRecover()
+					defer wg.Done()
+					Expect(cmd.RecoverSeqno()).To(Equal("1412"))
+				}()
+			}
+			wg.Wait()
+
+			Expect(slow.maxActive).To(Equal(1))
+			Expect(slow.logFiles).To(HaveLen(3))
+			Expect(slow.logFiles[0]).NotTo(Equal(slow.logFiles[1]))
+			for _, logFile := range slow.logFiles {
+				Expect(logFile).NotTo(BeAnExistingFile())
+			}
+		})
+	})
+})
@@ -1,14 +1,24 @@
 package node_manager
 
 import (
+	"context"
+	"fmt"
 	"io/ioutil"
+	"math/rand"
+	"net"
 	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
 	"time"
 
 	"code.cloudfoundry.org/lager"
 	"github.com/pkg/errors"
 
 	"github.com/cloudfoundry-incubator/galera-healthcheck/monit_client"
+	"github.com/cloudfoundry-incubator/galera-healthcheck/requestid"
+	"github.com/cloudfoundry-incubator/galera-healthcheck/sequence_number"
 )
 
 //go:generate go run github.com/maxbrunsfeld/counterfeiter/v6 . MonitClient
@@ -16,115 +26,601 @@ type MonitClient interface {
 	Start(serviceName string) error
 	Stop(serviceName string) error
 	Status(serviceName string) (string, error)
+	ProcessStats(serviceName string) (monit_client.ProcessStats, error)
+	Services() ([]monit_client.ServiceSummary, error)
+	Reload() (string, error)
+	Unmonitor(serviceName string) error
+	Monitor(serviceName string) error
 }
 
+const arbitratorServiceName = "garbd"
+
+// The state file is read back after every write to catch filesystems that
+// briefly serve stale contents, which galera-init would otherwise act on.
+const (
+	stateFileVerifyAttempts = 5
+	stateFileVerifyInterval = 50 * time.Millisecond
+)
+
 type NodeManager struct {
 	ServiceName       string
 	StateFilePath     string
 	MonitClient       MonitClient
 	GaleraInitAddress string
-	Logger            lager.Logger
+	// GaleraInitScheme and GaleraInitPath locate galera-init's readiness
+	// endpoint at GaleraInitAddress. The scheme defaults to http.
+	GaleraInitScheme string
+	GaleraInitPath   string
+	Logger           lager.Logger
+
+	// StartupTimeout bounds how long a start operation waits for galera-init
+	// to report healthy. Zero means wait indefinitely.
+	StartupTimeout time.Duration
+	// StartupPollInterval is how often galera-init is polled during startup.
+	// Defaults to one second.
+	StartupPollInterval time.Duration
+	// StartupPollJitter varies each poll interval by up to this fraction of
+	// StartupPollInterval, so that nodes started together do not poll in
+	// lockstep. Zero disables jitter.
+	StartupPollJitter float64
+	// StartupGracePolls is how many polls may see the monit job not yet
+	// running before startup is declared failed. A job that monit reports
+	// as failed aborts startup regardless.
+	StartupGracePolls int
+	// GaleraInitRetryBudget is how many non-OK responses from galera-init are
+	// tolerated before startup is declared failed. galera-init briefly
+	// answers 503 while mysqld starts.
+	GaleraInitRetryBudget int
+	// ManagedServices lists further monit services that requests may target
+	// with the service query parameter, for example the arbitrator when it
+	// runs alongside mysql. ServiceName may always be targeted.
+	ManagedServices []string
+	// DryRun makes start and stop operations report what they would do
+	// without writing the state file or calling monit.
+	DryRun bool
+	// GrastatePath is the node's grastate.dat. Bootstrapping is refused
+	// unless it records safe_to_bootstrap: 1 or the request sets force=true.
+	GrastatePath string
+	// Progress, when set, receives a StartupProgress after each galera-init
+	// poll. Sends never block, so a slow reader misses updates rather than
+	// delaying startup.
+	Progress chan<- StartupProgress
+	// ReadStateFile reads the state file back after it is written. Defaults
+	// to ioutil.ReadFile.
+	ReadStateFile func(path string) ([]byte, error)
+	// After waits for the duration to elapse between polls. Defaults to
+	// time.After.
+	After func(d time.Duration) <-chan time.Time
+
+	mu        sync.Mutex
+	operating bool
+}
+
+// StartupProgress reports how long a start operation has been waiting for
+// galera-init.
+type StartupProgress struct {
+	Attempt int
+	Elapsed time.Duration
+}
+
+// UnsafeBootstrapError is returned when grastate.dat shows that another node
+// may hold more recent transactions than this one.
+type UnsafeBootstrapError struct {
+	GrastatePath string
+}
+
+func (e UnsafeBootstrapError) Error() string {
+	return fmt.Sprintf("refusing to bootstrap: %s does not have safe_to_bootstrap: 1 (use force=true to override)", e.GrastatePath)
+}
+
+func (e UnsafeBootstrapError) StatusCode() int {
+	return http.StatusConflict
 }
 
-func (m *NodeManager) StartServiceBootstrap(_ *http.Request) (string, error) {
-	if m.ServiceName == "garbd" {
+// OperationInProgressError is returned when a start or stop operation is
+// requested while another one is still running on this node.
+type OperationInProgressError struct{}
+
+func (e OperationInProgressError) Error() string {
+	return "operation already in progress"
+}
+
+func (e OperationInProgressError) StatusCode() int {
+	return http.StatusConflict
+}
+
+// beginOperation claims the node for a start or stop operation. Only one such
+// operation may run at a time; the returned function releases the claim and
+// must be called once the operation finishes, whether or not it succeeded.
+func (m *NodeManager) beginOperation() (func(), error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.operating {
+		return nil, OperationInProgressError{}
+	}
+	m.operating = true
+
+	return func() {
+		m.mu.Lock()
+		m.operating = false
+		m.mu.Unlock()
+	}, nil
+}
+
+func (m *NodeManager) StartServiceBootstrap(req *http.Request) (string, error) {
+	service, err := m.service(req)
+	if err != nil {
+		return "", err
+	}
+
+	done, err := m.beginOperation()
+	if err != nil {
+		return "", err
+	}
+	defer done()
+
+	if isArbitrator(service) {
 		return "", errors.New("bootstrapping arbitrator not allowed")
 	}
 
-	if err := ioutil.WriteFile(m.StateFilePath, []byte("NEEDS_BOOTSTRAP"), 0777); err != nil {
+	if err := m.checkSafeToBootstrap(req); err != nil {
+		return "", err
+	}
+
+	if m.DryRun {
+		return "dry-run: would bootstrap", nil
+	}
+
+	m.requestLogger(req).Info("start", lager.Data{"service": service, "mode": "bootstrap"})
+
+	if err := m.initStateFile(service, "NEEDS_BOOTSTRAP"); err != nil {
 		return "", errors.Wrap(err, "failed to initialize state file")
 	}
 
-	if err := m.MonitClient.Start(m.ServiceName); err != nil {
+	if err := m.MonitClient.Start(service); err != nil {
 		return "", err
 	}
 
-	if err := m.waitForGaleraInit(); err != nil {
+	if err := m.waitForGaleraInit(requestContext(req), service); err != nil {
 		return "", err
 	}
 
 	return "cluster bootstrap successful", nil
 }
 
-func (m *NodeManager) StartServiceJoin(_ *http.Request) (string, error) {
-	if err := ioutil.WriteFile(m.StateFilePath, []byte("CLUSTERED"), 0777); err != nil {
+func (m *NodeManager) StartServiceJoin(req *http.Request) (string, error) {
+	service, err := m.service(req)
+	if err != nil {
+		return "", err
+	}
+
+	done, err := m.beginOperation()
+	if err != nil {
+		return "", err
+	}
+	defer done()
+
+	if m.DryRun {
+		return "dry-run: would join cluster", nil
+	}
+
+	m.requestLogger(req).Info("start", lager.Data{"service": service, "mode": "join"})
+
+	if err := m.initStateFile(service, "CLUSTERED"); err != nil {
 		return "", errors.Wrap(err, "failed to initialize state file")
 	}
 
-	if err := m.MonitClient.Start(m.ServiceName); err != nil {
+	if err := m.MonitClient.Start(service); err != nil {
 		return "", err
 	}
 
-	if err := m.waitForGaleraInit(); err != nil {
+	if err := m.waitForGaleraInit(requestContext(req), service); err != nil {
 		return "", err
 	}
 
+	if isArbitrator(service) {
+		return "arbitrator join successful", nil
+	}
 	return "join cluster successful", nil
 }
 
-func (m *NodeManager) StartServiceSingleNode(_ *http.Request) (string, error) {
-	if err := ioutil.WriteFile(m.StateFilePath, []byte("SINGLE_NODE"), 0777); err != nil {
+func (m *NodeManager) StartServiceSingleNode(req *http.Request) (string, error) {
+	service, err := m.service(req)
+	if err != nil {
+		return "", err
+	}
+
+	done, err := m.beginOperation()
+	if err != nil {
+		return "", err
+	}
+	defer done()
+
+	if m.DryRun {
+		return "dry-run: would start single node", nil
+	}
+
+	m.requestLogger(req).Info("start", lager.Data{"service": service, "mode": "single_node"})
+
+	if err := m.initStateFile(service, "SINGLE_NODE"); err != nil {
 		return "", errors.Wrap(err, "failed to initialize state file")
 	}
 
-	if err := m.MonitClient.Start(m.ServiceName); err != nil {
+	if err := m.MonitClient.Start(service); err != nil {
 		return "", err
 	}
 
-	if err := m.waitForGaleraInit(); err != nil {
+	if err := m.waitForGaleraInit(requestContext(req), service); err != nil {
 		return "", err
 	}
 
 	return "single node start successful", nil
 }
 
-func (m *NodeManager) StopService(_ *http.Request) (string, error) {
-	if err := m.MonitClient.Stop(m.ServiceName); err != nil {
+func (m *NodeManager) StopService(req *http.Request) (string, error) {
+	service, err := m.service(req)
+	if err != nil {
+		return "", err
+	}
+
+	done, err := m.beginOperation()
+	if err != nil {
+		return "", err
+	}
+	defer done()
+
+	if m.DryRun {
+		return "dry-run: would stop", nil
+	}
+
+	m.requestLogger(req).Info("stop", lager.Data{"service": service})
+
+	if err := m.MonitClient.Stop(service); err != nil {
 		return "", err
 	}
 
 	return "stop successful", nil
 }
 
-func (m *NodeManager) GetStatus(_ *http.Request) (string, error) {
-	return m.MonitClient.Status(m.ServiceName)
+// UnmonitorService stops monit from restarting the service, so that mysqld
+// can be operated on by hand during maintenance. It leaves the process and
+// the state file alone.
+func (m *NodeManager) UnmonitorService(req *http.Request) (string, error) {
+	service, err := m.service(req)
+	if err != nil {
+		return "", err
+	}
+
+	done, err := m.beginOperation()
+	if err != nil {
+		return "", err
+	}
+	defer done()
+
+	if m.DryRun {
+		return "dry-run: would unmonitor", nil
+	}
+
+	m.requestLogger(req).Info("unmonitor", lager.Data{"service": service})
+
+	if err := m.MonitClient.Unmonitor(service); err != nil {
+		return "", err
+	}
+
+	return "unmonitor successful", nil
+}
+
+// MonitorService has monit resume monitoring the service after
+// UnmonitorService.
+func (m *NodeManager) MonitorService(req *http.Request) (string, error) {
+	service, err := m.service(req)
+	if err != nil {
+		return "", err
+	}
+
+	done, err := m.beginOperation()
+	if err != nil {
+		return "", err
+	}
+	defer done()
+
+	if m.DryRun {
+		return "dry-run: would monitor", nil
+	}
+
+	m.requestLogger(req).Info("monitor", lager.Data{"service": service})
+
+	if err := m.MonitClient.Monitor(service); err != nil {
+		return "", err
+	}
+
+	return "monitor successful", nil
+}
+
+func (m *NodeManager) GetStatus(req *http.Request) (string, error) {
+	service, err := m.service(req)
+	if err != nil {
+		return "", err
+	}
+	return m.MonitClient.Status(service)
+}
+
+func (m *NodeManager) GetProcessStats(req *http.Request) (monit_client.ProcessStats, error) {
+	service, err := m.service(req)
+	if err != nil {
+		return monit_client.ProcessStats{}, err
+	}
+	return m.MonitClient.ProcessStats(service)
+}
+
+// GetServices returns every service monit manages on the node, not just
+// ServiceName.
+func (m *NodeManager) GetServices(_ *http.Request) ([]monit_client.ServiceSummary, error) {
+	return m.MonitClient.Services()
+}
+
+// GetState returns the trimmed contents of the state file, or nil if the file
+// does not exist.
+func (m *NodeManager) GetState(_ *http.Request) (*string, error) {
+	contents, err := ioutil.ReadFile(m.StateFilePath)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, errors.Wrap(err, "failed to read state file")
+	}
+
+	state := strings.TrimSpace(string(contents))
+	return &state, nil
+}
+
+func (m *NodeManager) ReloadMonit(_ *http.Request) (string, error) {
+	if m.DryRun {
+		return "dry-run: would reload monit", nil
+	}
+	return m.MonitClient.Reload()
+}
+
+func (m *NodeManager) checkSafeToBootstrap(req *http.Request) error {
+	if m.GrastatePath == "" {
+		return nil
+	}
+
+	if req != nil && req.URL.Query().Get("force") == "true" {
+		m.requestLogger(req).Info("skipping-bootstrap-safety-check", lager.Data{"grastate": m.GrastatePath})
+		return nil
+	}
+
+	state, err := sequence_number.ReadGrastate(m.GrastatePath)
+	if os.IsNotExist(err) {
+		// A node that has never run galera has no saved state to diverge from.
+		return nil
+	} else if err != nil {
+		return errors.Wrap(err, "failed to read grastate")
+	}
+
+	if !state.SafeToBootstrap {
+		return UnsafeBootstrapError{GrastatePath: m.GrastatePath}
+	}
+
+	return nil
+}
+
+// initStateFile writes state to the state file before service is started.
+// The state file belongs to ServiceName's galera-init, so it is left alone
+// when another managed service, such as the arbitrator, is started.
+func (m *NodeManager) initStateFile(service, state string) error {
+	if service != m.ServiceName {
+		return nil
+	}
+	return m.writeStateFile(state)
+}
+
+// writeStateFile replaces the state file atomically so that galera-init never
+// observes a partially written state. The new contents are written to a
+// temporary file in the same directory and then renamed into place.
+func (m *NodeManager) writeStateFile(state string) error {
+	tmpPath := m.StateFilePath + ".tmp"
+
+	f, err := os.OpenFile(tmpPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0777)
+	if err != nil {
+		return err
+	}
+
+	if _, err := f.WriteString(state); err != nil {
+		f.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+
+	if err := f.Sync(); err != nil {
+		f.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+
+	if err := f.Close(); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+
+	if err := os.Rename(tmpPath, m.StateFilePath); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+
+	return m.verifyStateFile(state)
+}
+
+// verifyStateFile reads the state file until it holds state, retrying briefly
+// before giving up.
+func (m *NodeManager) verifyStateFile(state string) error {
+	readFile := m.ReadStateFile
+	if readFile == nil {
+		readFile = ioutil.ReadFile
+	}
+
+	var err error
+	for attempt := 1; attempt <= stateFileVerifyAttempts; attempt++ {
+		if attempt > 1 {
+			time.Sleep(stateFileVerifyInterval)
+		}
+
+		var contents []byte
+		contents, err = readFile(m.StateFilePath)
+		if err == nil && string(contents) == state {
+			return nil
+		}
+		if err == nil {
+			err = errors.Errorf("state file contains %q, expected %q", contents, state)
+		}
+
+		m.Logger.Error("verify-state-file", err, lager.Data{"attempt": attempt})
+	}
+
+	return errors.Wrap(err, "state file did not read back as written")
+}
+
+// isArbitrator reports whether service is the galera arbitrator, which has
+// no galera-init and no SQL endpoint to wait for.
+func isArbitrator(service string) bool {
+	return service == arbitratorServiceName
+}
+
+// UnknownServiceError is returned when a request targets a monit service that
+// the node manager has not been configured to manage.
+type UnknownServiceError struct {
+	Service string
+}
+
+func (e UnknownServiceError) Error() string {
+	return fmt.Sprintf("service %q is not managed by this node", e.Service)
+}
+
+func (e UnknownServiceError) StatusCode() int {
+	return http.StatusBadRequest
 }
 
-func (m *NodeManager) waitForGaleraInit() error {
-	ticker := time.NewTicker(1 * time.Second)
-	defer ticker.Stop()
+// service returns the monit service named by the request's service query
+// parameter, defaulting to ServiceName.
+func (m *NodeManager) service(req *http.Request) (string, error) {
+	if req == nil {
+		return m.ServiceName, nil
+	}
+
+	service := req.URL.Query().Get("service")
+	if service == "" || service == m.ServiceName {
+		return m.ServiceName, nil
+	}
+
+	for _, managed := range m.ManagedServices {
+		if service == managed {
+			return service, nil
+		}
+	}
+	return "", UnknownServiceError{Service: service}
+}
+
+// requestContext returns the context of the incoming request so that long
+// running operations stop once the client goes away.
+func requestContext(req *http.Request) context.Context {
+	if req == nil {
+		return context.Background()
+	}
+	return req.Context()
+}
+
+// requestLogger returns the manager's logger tagged with the correlation ID
+// of req, if any.
+func (m *NodeManager) requestLogger(req *http.Request) lager.Logger {
+	return requestid.Logger(m.Logger, requestContext(req))
+}
+
+// waitForGaleraInit waits for monit to report the job running and then for
+// galera-init to report healthy. The arbitrator has no galera-init, so for it
+// only the monit state is checked.
+func (m *NodeManager) waitForGaleraInit(ctx context.Context, service string) error {
+	pollInterval := m.StartupPollInterval
+	if pollInterval <= 0 {
+		pollInterval = 1 * time.Second
+	}
+
+	var timeout <-chan time.Time
+	if m.StartupTimeout > 0 {
+		timer := time.NewTimer(m.StartupTimeout)
+		defer timer.Stop()
+		timeout = timer.C
+	}
 
 	httpClient := http.Client{Timeout: 1 * time.Second}
+	logger := requestid.Logger(m.Logger, ctx)
+
+	start := time.Now()
+	attempt := 0
+	notReady := 0
 
 	for {
 		select {
-		case <-ticker.C:
-			status, err := m.MonitClient.Status(m.ServiceName)
+		case <-ctx.Done():
+			return errors.Wrap(ctx.Err(), "stopped waiting for galera-init")
+		case <-timeout:
+			return errors.Errorf("timed out after %s waiting for galera-init to become healthy", m.StartupTimeout)
+		case <-m.after(m.jitter(pollInterval)):
+			attempt++
+			elapsed := time.Since(start)
+			m.reportProgress(StartupProgress{Attempt: attempt, Elapsed: elapsed})
+
+			status, err := m.MonitClient.Status(service)
 			if err != nil {
-				return errors.Errorf("error fetching status for service %q", m.ServiceName)
+				return errors.Errorf("error fetching status for service %q", service)
 			}
 
-			m.Logger.Info("check-monit-state", lager.Data{
-				"service": m.ServiceName,
+			logger.Debug("check-monit-state", lager.Data{
+				"service": service,
 				"state":   status,
+				"attempt": attempt,
+				"elapsed": elapsed.String(),
 			})
 
-			if status != monit_client.ServiceRunning {
+			switch state := monit_client.NormalizeStatus(status); {
+			case state == monit_client.StateRunning:
+			case state != monit_client.StateFailed && attempt <= m.StartupGracePolls:
+				continue
+			default:
 				return errors.New("job failed during startup")
 			}
 
-			m.Logger.Info("check-galera-init")
-			res, err := httpClient.Get("http://" + m.GaleraInitAddress)
+			if isArbitrator(service) {
+				return nil
+			}
+
+			logger.Debug("check-galera-init", lager.Data{
+				"attempt": attempt,
+				"elapsed": elapsed.String(),
+			})
+			galeraInitReq, err := http.NewRequestWithContext(ctx, http.MethodGet, m.galeraInitURL(), nil)
+			if err != nil {
+				return err
+			}
+
+			res, err := httpClient.Do(galeraInitReq)
 			if err != nil {
-				m.Logger.Error("check-galera-init", err)
+				logger.Error("check-galera-init", err, lager.Data{"attempt": attempt})
 				continue
 			}
 
-			m.Logger.Info("check-galera-init", lager.Data{
-				"status": res.Status,
+			logger.Debug("check-galera-init", lager.Data{
+				"status":  res.Status,
+				"attempt": attempt,
 			})
 
+			res.Body.Close()
+
 			if res.StatusCode != http.StatusOK {
+				notReady++
+				if notReady <= m.GaleraInitRetryBudget {
+					continue
+				}
 				return errors.Errorf("unexpected response from node: %v", res.Status)
 			}
 
@@ -132,3 +628,59 @@ func (m *NodeManager) waitForGaleraInit() error {
 		}
 	}
 }
+
+// jitter scales interval by a random factor within StartupPollJitter of one.
+func (m *NodeManager) jitter(interval time.Duration) time.Duration {
+	if m.StartupPollJitter <= 0 {
+		return interval
+	}
+
+	factor := 1 + m.StartupPollJitter*(2*rand.Float64()-1)
+	return time.Duration(float64(interval) * factor)
+}
+
+func (m *NodeManager) after(d time.Duration) <-chan time.Time {
+	if m.After != nil {
+		return m.After(d)
+	}
+	return time.After(d)
+}
+
+func (m *NodeManager) galeraInitURL() string {
+	scheme := m.GaleraInitScheme
+	if scheme == "" {
+		scheme = "http"
+	}
+
+	u := url.URL{
+		Scheme: scheme,
+		Host:   galeraInitHost(m.GaleraInitAddress),
+		Path:   m.GaleraInitPath,
+	}
+	return u.String()
+}
+
+// galeraInitHost brackets IPv6 literals so that the address can be used as
+// a URL host. IPv4 addresses and hostnames are returned unchanged.
+func galeraInitHost(address string) string {
+	if host, port, err := net.SplitHostPort(address); err == nil {
+		return net.JoinHostPort(host, port)
+	}
+
+	if ip := net.ParseIP(address); ip != nil && ip.To4() == nil {
+		return "[" + address + "]"
+	}
+
+	return address
+}
+
+func (m *NodeManager) reportProgress(progress StartupProgress) {
+	if m.Progress == nil {
+		return
+	}
+
+	select {
+	case m.Progress <- progress:
+	default:
+	}
+}
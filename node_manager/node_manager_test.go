@@ -1,20 +1,26 @@
 package node_manager_test
 
 import (
+	"context"
 	"fmt"
 	"io/ioutil"
+	"net"
 	"net/http"
 	"os"
 	"path/filepath"
+	"time"
 
 	"code.cloudfoundry.org/lager/lagertest"
 	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
 	. "github.com/onsi/gomega"
 	"github.com/onsi/gomega/ghttp"
 	"github.com/pkg/errors"
 
+	"github.com/cloudfoundry-incubator/galera-healthcheck/monit_client"
 	"github.com/cloudfoundry-incubator/galera-healthcheck/node_manager"
 	"github.com/cloudfoundry-incubator/galera-healthcheck/node_manager/node_managerfakes"
+	"github.com/cloudfoundry-incubator/galera-healthcheck/requestid"
 )
 
 var _ = Describe("NodeManager", func() {
@@ -54,7 +60,7 @@ var _ = Describe("NodeManager", func() {
 				Expect(err).
 					To(
 						MatchError(
-							fmt.Sprintf(`failed to initialize state file: open %s: no such file or directory`, mgr.StateFilePath),
+							fmt.Sprintf(`failed to initialize state file: open %s.tmp: no such file or directory`, mgr.StateFilePath),
 						),
 					)
 			})
@@ -124,6 +130,214 @@ var _ = Describe("NodeManager", func() {
 				})
 			})
 
+			Context("when galera-init never becomes healthy", func() {
+				BeforeEach(func() {
+					listener, err := net.Listen("tcp", "127.0.0.1:0")
+					Expect(err).NotTo(HaveOccurred())
+					mgr.GaleraInitAddress = listener.Addr().String()
+					listener.Close()
+
+					mgr.StartupTimeout = 100 * time.Millisecond
+					mgr.StartupPollInterval = 10 * time.Millisecond
+
+					fakeMonit.StartReturns(nil)
+					fakeMonit.StatusReturns("running", nil)
+				})
+
+				It("gives up after the startup timeout", func() {
+					start := time.Now()
+					_, err := mgr.StartServiceBootstrap(nil)
+					Expect(err).To(MatchError(`timed out after 100ms waiting for galera-init to become healthy`))
+					Expect(time.Since(start)).To(BeNumerically("<", 1*time.Second))
+					Expect(fakeMonit.StatusCallCount()).To(BeNumerically(">", 1))
+				})
+
+				It("logs each poll with an increasing attempt count and elapsed time", func() {
+					logger := lagertest.NewTestLogger("node_manager")
+					mgr.Logger = logger
+
+					mgr.StartServiceBootstrap(nil)
+
+					var attempts []float64
+					for _, log := range logger.Logs() {
+						if log.Message == "node_manager.check-monit-state" {
+							attempts = append(attempts, log.Data["attempt"].(float64))
+							Expect(log.Data).To(HaveKey("elapsed"))
+						}
+					}
+
+					Expect(len(attempts)).To(BeNumerically(">", 1))
+					for i, attempt := range attempts {
+						Expect(attempt).To(Equal(float64(i + 1)))
+					}
+				})
+
+				It("reports progress on the Progress channel without blocking", func() {
+					progress := make(chan node_manager.StartupProgress, 1)
+					mgr.Progress = progress
+
+					_, err := mgr.StartServiceBootstrap(nil)
+					Expect(err).To(HaveOccurred())
+
+					var first node_manager.StartupProgress
+					Expect(progress).To(Receive(&first))
+					Expect(first.Attempt).To(Equal(1))
+					Expect(first.Elapsed).To(BeNumerically(">", 0))
+				})
+			})
+
+			Context("when monit reports running with a different spelling", func() {
+				var server *ghttp.Server
+
+				BeforeEach(func() {
+					server = ghttp.NewServer()
+					server.RouteToHandler("GET", "/", ghttp.RespondWith(http.StatusOK, nil))
+
+					mgr.GaleraInitAddress = server.Addr()
+
+					fakeMonit.StartReturns(nil)
+					fakeMonit.StatusReturns("Running - Accessible", nil)
+				})
+
+				AfterEach(func() {
+					server.Close()
+				})
+
+				It("does not treat the job as failed", func() {
+					_, err := mgr.StartServiceBootstrap(nil)
+					Expect(err).NotTo(HaveOccurred())
+				})
+			})
+
+			Context("when the request carries a correlation ID", func() {
+				var server *ghttp.Server
+
+				BeforeEach(func() {
+					server = ghttp.NewServer()
+					server.RouteToHandler("GET", "/", ghttp.RespondWith(http.StatusOK, nil))
+
+					mgr.GaleraInitAddress = server.Addr()
+
+					fakeMonit.StartReturns(nil)
+					fakeMonit.StatusReturns("running", nil)
+				})
+
+				AfterEach(func() {
+					server.Close()
+				})
+
+				It("tags the startup logs with the request ID", func() {
+					logger := lagertest.NewTestLogger("node_manager")
+					mgr.Logger = logger
+
+					req, err := http.NewRequest(http.MethodPost, "/bootstrap", nil)
+					Expect(err).NotTo(HaveOccurred())
+					req = req.WithContext(requestid.NewContext(req.Context(), "abc123"))
+
+					_, err = mgr.StartServiceBootstrap(req)
+					Expect(err).NotTo(HaveOccurred())
+
+					var messages []string
+					for _, log := range logger.Logs() {
+						Expect(log.Data).To(HaveKeyWithValue("request_id", "abc123"))
+						messages = append(messages, log.Message)
+					}
+					Expect(messages).To(ContainElement("node_manager.start"))
+					Expect(messages).To(ContainElement("node_manager.check-monit-state"))
+					Expect(messages).To(ContainElement("node_manager.check-galera-init"))
+				})
+			})
+
+			Context("when monit has not started the job by the first poll", func() {
+				var server *ghttp.Server
+
+				BeforeEach(func() {
+					server = ghttp.NewServer()
+					server.RouteToHandler("GET", "/", ghttp.RespondWith(http.StatusOK, nil))
+
+					mgr.GaleraInitAddress = server.Addr()
+					mgr.StartupPollInterval = 10 * time.Millisecond
+					mgr.StartupGracePolls = 3
+
+					fakeMonit.StartReturns(nil)
+					fakeMonit.StatusReturnsOnCall(0, "Initializing", nil)
+					fakeMonit.StatusReturnsOnCall(1, "start pending", nil)
+				})
+
+				AfterEach(func() {
+					server.Close()
+				})
+
+				It("keeps polling until the job is running", func() {
+					fakeMonit.StatusReturns("running", nil)
+
+					_, err := mgr.StartServiceJoin(nil)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(fakeMonit.StatusCallCount()).To(Equal(3))
+				})
+
+				It("fails as soon as monit reports the job failed", func() {
+					fakeMonit.StatusReturns("Execution failed", nil)
+
+					_, err := mgr.StartServiceJoin(nil)
+					Expect(err).To(MatchError("job failed during startup"))
+					Expect(fakeMonit.StatusCallCount()).To(Equal(3))
+					Expect(server.ReceivedRequests()).To(BeEmpty())
+				})
+
+				It("fails once the grace polls are used up", func() {
+					fakeMonit.StatusReturns("Initializing", nil)
+
+					_, err := mgr.StartServiceJoin(nil)
+					Expect(err).To(MatchError("job failed during startup"))
+					Expect(fakeMonit.StatusCallCount()).To(Equal(4))
+				})
+			})
+
+			Context("when poll jitter is configured", func() {
+				var waits []time.Duration
+
+				BeforeEach(func() {
+					waits = nil
+					mgr.StartupPollInterval = 100 * time.Millisecond
+					mgr.StartupPollJitter = 0.2
+					mgr.StartupGracePolls = 20
+					mgr.After = func(d time.Duration) <-chan time.Time {
+						waits = append(waits, d)
+						fired := make(chan time.Time, 1)
+						fired <- time.Time{}
+						return fired
+					}
+
+					fakeMonit.StartReturns(nil)
+					fakeMonit.StatusReturns("Initializing", nil)
+				})
+
+				It("varies the poll interval within the jitter bound", func() {
+					_, err := mgr.StartServiceBootstrap(nil)
+					Expect(err).To(MatchError("job failed during startup"))
+
+					Expect(waits).To(HaveLen(21))
+					for _, wait := range waits {
+						Expect(wait).To(BeNumerically(">=", 80*time.Millisecond))
+						Expect(wait).To(BeNumerically("<=", 120*time.Millisecond))
+					}
+					Expect(waits).To(ContainElement(Not(Equal(waits[0]))))
+				})
+
+				It("polls at the base interval when jitter is disabled", func() {
+					mgr.StartupPollJitter = 0
+
+					_, err := mgr.StartServiceBootstrap(nil)
+					Expect(err).To(MatchError("job failed during startup"))
+
+					Expect(waits).To(HaveLen(21))
+					for _, wait := range waits {
+						Expect(wait).To(Equal(100 * time.Millisecond))
+					}
+				})
+			})
+
 			Context("when galera-init initializes successfully", func() {
 				var server *ghttp.Server
 
@@ -153,6 +367,130 @@ var _ = Describe("NodeManager", func() {
 					Expect(msg).To(Equal(`cluster bootstrap successful`))
 				})
 			})
+
+			Context("when galera-init serves readiness on a configured path", func() {
+				var server *ghttp.Server
+
+				BeforeEach(func() {
+					server = ghttp.NewServer()
+					server.AppendHandlers(ghttp.CombineHandlers(
+						ghttp.VerifyRequest("GET", "/ready"),
+						ghttp.RespondWith(http.StatusOK, nil),
+					))
+
+					mgr.GaleraInitAddress = server.Addr()
+					mgr.GaleraInitPath = "/ready"
+
+					fakeMonit.StartReturns(nil)
+					fakeMonit.StatusReturns("running", nil)
+				})
+
+				AfterEach(func() {
+					server.Close()
+				})
+
+				It("polls that path", func() {
+					_, err := mgr.StartServiceBootstrap(nil)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(server.ReceivedRequests()).To(HaveLen(1))
+				})
+			})
+
+			Context("when galera-init listens on different kinds of address", func() {
+				BeforeEach(func() {
+					fakeMonit.StartReturns(nil)
+					fakeMonit.StatusReturns("running", nil)
+				})
+
+				DescribeTable("requests the galera-init URL",
+					func(network, listenAddress, host string) {
+						listener, err := net.Listen(network, listenAddress)
+						if err != nil {
+							Skip(fmt.Sprintf("cannot listen on %s: %s", listenAddress, err))
+						}
+
+						requests := make(chan *http.Request, 1)
+						server := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+							requests <- req
+						})}
+						go server.Serve(listener)
+						defer server.Close()
+
+						_, port, err := net.SplitHostPort(listener.Addr().String())
+						Expect(err).NotTo(HaveOccurred())
+						mgr.GaleraInitAddress = host + ":" + port
+
+						_, err = mgr.StartServiceBootstrap(nil)
+						Expect(err).NotTo(HaveOccurred())
+
+						var req *http.Request
+						Eventually(requests).Should(Receive(&req))
+						Expect(req.Host).To(Equal(mgr.GaleraInitAddress))
+						Expect(req.URL.Path).To(Equal("/"))
+					},
+					Entry("an IPv4 address", "tcp4", "127.0.0.1:0", "127.0.0.1"),
+					Entry("a hostname", "tcp4", "127.0.0.1:0", "localhost"),
+					Entry("a bracketed IPv6 address", "tcp6", "[::1]:0", "[::1]"),
+				)
+			})
+		})
+	})
+
+	Context("bootstrap safety check", func() {
+		var writeGrastate = func(safeToBootstrap string) {
+			contents := "# GALERA saved state\nversion: 2.1\nseqno:   -1\nsafe_to_bootstrap: " + safeToBootstrap + "\n"
+			Expect(ioutil.WriteFile(mgr.GrastatePath, []byte(contents), 0600)).To(Succeed())
+		}
+
+		var bootstrapRequest = func(query string) *http.Request {
+			req, err := http.NewRequest(http.MethodPost, "/start_mysql_bootstrap"+query, nil)
+			Expect(err).NotTo(HaveOccurred())
+			return req
+		}
+
+		BeforeEach(func() {
+			mgr.GrastatePath = filepath.Join(tempDir, "grastate.dat")
+			fakeMonit.StartReturns(errors.New("monit start error"))
+		})
+
+		It("bootstraps when grastate.dat records safe_to_bootstrap: 1", func() {
+			writeGrastate("1")
+
+			_, err := mgr.StartServiceBootstrap(bootstrapRequest(""))
+			Expect(err).To(MatchError("monit start error"))
+			Expect(fakeMonit.StartCallCount()).To(Equal(1))
+		})
+
+		It("bootstraps a node that has no grastate.dat yet", func() {
+			_, err := mgr.StartServiceBootstrap(bootstrapRequest(""))
+			Expect(err).To(MatchError("monit start error"))
+			Expect(fakeMonit.StartCallCount()).To(Equal(1))
+		})
+
+		Context("when grastate.dat records safe_to_bootstrap: 0", func() {
+			BeforeEach(func() {
+				writeGrastate("0")
+			})
+
+			It("refuses to bootstrap without writing the state file or calling monit", func() {
+				_, err := mgr.StartServiceBootstrap(bootstrapRequest(""))
+				Expect(err).To(BeAssignableToTypeOf(node_manager.UnsafeBootstrapError{}))
+				Expect(err.(node_manager.UnsafeBootstrapError).StatusCode()).To(Equal(http.StatusConflict))
+				Expect(fakeMonit.StartCallCount()).To(Equal(0))
+				Expect(mgr.StateFilePath).NotTo(BeAnExistingFile())
+			})
+
+			It("bootstraps anyway when force=true is set", func() {
+				_, err := mgr.StartServiceBootstrap(bootstrapRequest("?force=true"))
+				Expect(err).To(MatchError("monit start error"))
+				Expect(fakeMonit.StartCallCount()).To(Equal(1))
+			})
+
+			It("does not affect joining the cluster", func() {
+				_, err := mgr.StartServiceJoin(bootstrapRequest(""))
+				Expect(err).To(MatchError("monit start error"))
+				Expect(fakeMonit.StartCallCount()).To(Equal(1))
+			})
 		})
 	})
 
@@ -167,7 +505,7 @@ var _ = Describe("NodeManager", func() {
 				Expect(err).
 					To(
 						MatchError(
-							fmt.Sprintf(`failed to initialize state file: open %s: no such file or directory`, mgr.StateFilePath),
+							fmt.Sprintf(`failed to initialize state file: open %s.tmp: no such file or directory`, mgr.StateFilePath),
 						),
 					)
 			})
@@ -237,6 +575,55 @@ var _ = Describe("NodeManager", func() {
 				})
 			})
 
+			Context("when galera-init is not ready at first", func() {
+				var server *ghttp.Server
+
+				BeforeEach(func() {
+					server = ghttp.NewServer()
+
+					mgr.GaleraInitAddress = server.Addr()
+					mgr.StartupPollInterval = 10 * time.Millisecond
+					mgr.GaleraInitRetryBudget = 2
+
+					fakeMonit.StartReturns(nil)
+					fakeMonit.StatusReturns("running", nil)
+				})
+
+				AfterEach(func() {
+					server.Close()
+				})
+
+				It("keeps polling until galera-init answers 200", func() {
+					server.AppendHandlers(
+						ghttp.RespondWith(http.StatusServiceUnavailable, nil),
+						ghttp.RespondWith(http.StatusServiceUnavailable, nil),
+						ghttp.RespondWith(http.StatusOK, nil),
+					)
+
+					msg, err := mgr.StartServiceJoin(nil)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(msg).To(Equal("join cluster successful"))
+					Expect(server.ReceivedRequests()).To(HaveLen(3))
+				})
+
+				It("fails once the retry budget is used up", func() {
+					server.RouteToHandler("GET", "/", ghttp.RespondWith(http.StatusServiceUnavailable, nil))
+
+					_, err := mgr.StartServiceJoin(nil)
+					Expect(err).To(MatchError("unexpected response from node: 503 Service Unavailable"))
+					Expect(server.ReceivedRequests()).To(HaveLen(3))
+				})
+
+				It("still fails as soon as monit reports the job failed", func() {
+					server.RouteToHandler("GET", "/", ghttp.RespondWith(http.StatusServiceUnavailable, nil))
+					fakeMonit.StatusReturnsOnCall(1, "failing", nil)
+
+					_, err := mgr.StartServiceJoin(nil)
+					Expect(err).To(MatchError("job failed during startup"))
+					Expect(server.ReceivedRequests()).To(HaveLen(1))
+				})
+			})
+
 			Context("when galera-init initializes successfully", func() {
 				var server *ghttp.Server
 
@@ -267,6 +654,237 @@ var _ = Describe("NodeManager", func() {
 				})
 			})
 		})
+
+		Context("when the service is the arbitrator", func() {
+			var server *ghttp.Server
+
+			BeforeEach(func() {
+				server = ghttp.NewServer()
+				server.AllowUnhandledRequests = true
+
+				mgr.ServiceName = "garbd"
+				mgr.GaleraInitAddress = server.Addr()
+
+				fakeMonit.StartReturns(nil)
+			})
+
+			AfterEach(func() {
+				server.Close()
+			})
+
+			It("starts garbd without waiting on galera-init", func() {
+				fakeMonit.StatusReturns("running", nil)
+
+				msg, err := mgr.StartServiceJoin(nil)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(msg).To(Equal("arbitrator join successful"))
+				Expect(ioutil.ReadFile(mgr.StateFilePath)).To(Equal([]byte("CLUSTERED")))
+
+				Expect(fakeMonit.StartCallCount()).To(Equal(1))
+				Expect(fakeMonit.StartArgsForCall(0)).To(Equal("garbd"))
+				Expect(server.ReceivedRequests()).To(BeEmpty())
+			})
+
+			It("still fails when garbd fails to start", func() {
+				fakeMonit.StatusReturns("failing", nil)
+
+				_, err := mgr.StartServiceJoin(nil)
+				Expect(err).To(MatchError("job failed during startup"))
+				Expect(server.ReceivedRequests()).To(BeEmpty())
+			})
+		})
+	})
+
+	Context("when a start or stop operation is already running", func() {
+		var (
+			server  *ghttp.Server
+			release chan struct{}
+			started chan struct{}
+		)
+
+		BeforeEach(func() {
+			server = ghttp.NewServer()
+			server.RouteToHandler("GET", "/", ghttp.RespondWith(http.StatusOK, nil))
+			mgr.GaleraInitAddress = server.Addr()
+			mgr.StartupPollInterval = 10 * time.Millisecond
+
+			release = make(chan struct{})
+			started = make(chan struct{}, 2)
+			fakeMonit.StartStub = func(string) error {
+				started <- struct{}{}
+				<-release
+				return nil
+			}
+			fakeMonit.StatusReturns("running", nil)
+		})
+
+		AfterEach(func() {
+			server.Close()
+		})
+
+		It("rejects concurrent operations with a conflict", func() {
+			firstErr := make(chan error, 1)
+			go func() {
+				_, err := mgr.StartServiceBootstrap(nil)
+				firstErr <- err
+			}()
+			Eventually(started).Should(Receive())
+
+			_, err := mgr.StartServiceBootstrap(nil)
+			Expect(err).To(MatchError("operation already in progress"))
+			Expect(err.(node_manager.OperationInProgressError).StatusCode()).To(Equal(http.StatusConflict))
+
+			_, err = mgr.StopService(nil)
+			Expect(err).To(Equal(node_manager.OperationInProgressError{}))
+
+			close(release)
+			Eventually(firstErr).Should(Receive(BeNil()))
+			Expect(fakeMonit.StartCallCount()).To(Equal(1))
+			Expect(fakeMonit.StopCallCount()).To(Equal(0))
+		})
+
+		It("releases the guard when the operation fails", func() {
+			close(release)
+			fakeMonit.StatusReturns("failed", nil)
+
+			_, err := mgr.StartServiceBootstrap(nil)
+			Expect(err).To(MatchError("job failed during startup"))
+
+			fakeMonit.StatusReturns("running", nil)
+			_, err = mgr.StartServiceBootstrap(nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(fakeMonit.StartCallCount()).To(Equal(2))
+		})
+	})
+
+	Context("writing the state file", func() {
+		BeforeEach(func() {
+			fakeMonit.StartReturns(errors.New("monit start error"))
+			Expect(ioutil.WriteFile(mgr.StateFilePath, []byte("CLUSTERED"), 0777)).To(Succeed())
+		})
+
+		It("never exposes a partially written state file", func() {
+			done := make(chan struct{})
+			partialContents := make(chan []string, 1)
+
+			go func() {
+				defer GinkgoRecover()
+				var partial []string
+				for {
+					select {
+					case <-done:
+						partialContents <- partial
+						return
+					default:
+					}
+
+					contents, err := ioutil.ReadFile(mgr.StateFilePath)
+					Expect(err).NotTo(HaveOccurred())
+
+					switch string(contents) {
+					case "NEEDS_BOOTSTRAP", "CLUSTERED", "SINGLE_NODE":
+					default:
+						partial = append(partial, string(contents))
+					}
+				}
+			}()
+
+			for i := 0; i < 100; i++ {
+				mgr.StartServiceBootstrap(nil)
+				mgr.StartServiceJoin(nil)
+				mgr.StartServiceSingleNode(nil)
+			}
+			close(done)
+
+			Eventually(partialContents).Should(Receive(BeEmpty()))
+		})
+
+		It("writes the expected state for each start mode and leaves no temporary file behind", func() {
+			mgr.StartServiceBootstrap(nil)
+			Expect(ioutil.ReadFile(mgr.StateFilePath)).To(Equal([]byte("NEEDS_BOOTSTRAP")))
+
+			mgr.StartServiceJoin(nil)
+			Expect(ioutil.ReadFile(mgr.StateFilePath)).To(Equal([]byte("CLUSTERED")))
+
+			mgr.StartServiceSingleNode(nil)
+			Expect(ioutil.ReadFile(mgr.StateFilePath)).To(Equal([]byte("SINGLE_NODE")))
+
+			Expect(mgr.StateFilePath + ".tmp").NotTo(BeAnExistingFile())
+		})
+
+		It("retries reading the state file back when the first read is stale", func() {
+			var reads int
+			mgr.ReadStateFile = func(path string) ([]byte, error) {
+				reads++
+				if reads == 1 {
+					return []byte("CLUSTERED"), nil
+				}
+				return ioutil.ReadFile(path)
+			}
+
+			_, err := mgr.StartServiceBootstrap(nil)
+			Expect(err).To(MatchError("monit start error"))
+
+			Expect(reads).To(Equal(2))
+			Expect(fakeMonit.StartCallCount()).To(Equal(1))
+		})
+
+		It("does not start the service when the state file never reads back as written", func() {
+			mgr.ReadStateFile = func(string) ([]byte, error) {
+				return []byte("CLUSTERED"), nil
+			}
+
+			_, err := mgr.StartServiceBootstrap(nil)
+			Expect(err).To(MatchError(`failed to initialize state file: state file did not read back as written: state file contains "CLUSTERED", expected "NEEDS_BOOTSTRAP"`))
+			Expect(fakeMonit.StartCallCount()).To(Equal(0))
+		})
+	})
+
+	Context("when the request context is cancelled while waiting for galera-init", func() {
+		var (
+			req    *http.Request
+			cancel context.CancelFunc
+		)
+
+		BeforeEach(func() {
+			listener, err := net.Listen("tcp", "127.0.0.1:0")
+			Expect(err).NotTo(HaveOccurred())
+			mgr.GaleraInitAddress = listener.Addr().String()
+			listener.Close()
+
+			mgr.StartupPollInterval = 10 * time.Millisecond
+
+			fakeMonit.StartReturns(nil)
+			fakeMonit.StatusReturns("running", nil)
+
+			var ctx context.Context
+			ctx, cancel = context.WithCancel(context.Background())
+			req, err = http.NewRequestWithContext(ctx, http.MethodPost, "/start_mysql_join", nil)
+			Expect(err).NotTo(HaveOccurred())
+
+			time.AfterFunc(50*time.Millisecond, cancel)
+		})
+
+		AfterEach(func() {
+			cancel()
+		})
+
+		It("stops polling and returns a context cancelled error", func() {
+			start := time.Now()
+			_, err := mgr.StartServiceJoin(req)
+			Expect(errors.Cause(err)).To(Equal(context.Canceled))
+			Expect(err).To(MatchError(ContainSubstring("stopped waiting for galera-init")))
+			Expect(time.Since(start)).To(BeNumerically("<", 1*time.Second))
+
+			statusCalls := fakeMonit.StatusCallCount()
+			Consistently(fakeMonit.StatusCallCount, 50*time.Millisecond).Should(Equal(statusCalls))
+		})
+
+		It("leaves the state file in place", func() {
+			_, err := mgr.StartServiceJoin(req)
+			Expect(err).To(HaveOccurred())
+			Expect(ioutil.ReadFile(mgr.StateFilePath)).To(Equal([]byte("CLUSTERED")))
+		})
 	})
 
 	Context("StartServiceSingleNode", func() {
@@ -280,7 +898,7 @@ var _ = Describe("NodeManager", func() {
 				Expect(err).
 					To(
 						MatchError(
-							fmt.Sprintf(`failed to initialize state file: open %s: no such file or directory`, mgr.StateFilePath),
+							fmt.Sprintf(`failed to initialize state file: open %s.tmp: no such file or directory`, mgr.StateFilePath),
 						),
 					)
 			})
@@ -382,6 +1000,74 @@ var _ = Describe("NodeManager", func() {
 		})
 	})
 
+	Context("when a request names a service", func() {
+		var newRequest = func(query string) *http.Request {
+			req, err := http.NewRequest(http.MethodPost, "/stop_mysql"+query, nil)
+			Expect(err).NotTo(HaveOccurred())
+			return req
+		}
+
+		BeforeEach(func() {
+			mgr.ManagedServices = []string{"garbd"}
+		})
+
+		It("targets ServiceName by default", func() {
+			_, err := mgr.StopService(newRequest(""))
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(fakeMonit.StopCallCount()).To(Equal(1))
+			Expect(fakeMonit.StopArgsForCall(0)).To(Equal("galera-init"))
+		})
+
+		It("targets a managed service", func() {
+			_, err := mgr.StopService(newRequest("?service=garbd"))
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(fakeMonit.StopCallCount()).To(Equal(1))
+			Expect(fakeMonit.StopArgsForCall(0)).To(Equal("garbd"))
+
+			fakeMonit.StatusReturns("running", nil)
+			_, err = mgr.GetStatus(newRequest("?service=garbd"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(fakeMonit.StatusArgsForCall(0)).To(Equal("garbd"))
+		})
+
+		It("rejects a service that is not managed", func() {
+			_, err := mgr.StopService(newRequest("?service=sshd"))
+			Expect(err).To(MatchError(`service "sshd" is not managed by this node`))
+			Expect(err.(node_manager.UnknownServiceError).StatusCode()).To(Equal(http.StatusBadRequest))
+
+			_, err = mgr.GetStatus(newRequest("?service=sshd"))
+			Expect(err).To(Equal(node_manager.UnknownServiceError{Service: "sshd"}))
+
+			Expect(fakeMonit.StopCallCount()).To(Equal(0))
+			Expect(fakeMonit.StatusCallCount()).To(Equal(0))
+		})
+
+		It("starts a managed arbitrator without waiting for galera-init", func() {
+			mgr.StartupPollInterval = 10 * time.Millisecond
+			fakeMonit.StatusReturns("running", nil)
+
+			msg, err := mgr.StartServiceJoin(newRequest("?service=garbd"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(msg).To(Equal("arbitrator join successful"))
+			Expect(fakeMonit.StartArgsForCall(0)).To(Equal("garbd"))
+		})
+
+		It("leaves ServiceName's state file alone when starting another service", func() {
+			mgr.StartupPollInterval = 10 * time.Millisecond
+			fakeMonit.StatusReturns("running", nil)
+			Expect(ioutil.WriteFile(mgr.StateFilePath, []byte("SINGLE_NODE"), 0777)).To(Succeed())
+
+			_, err := mgr.StartServiceJoin(newRequest("?service=garbd"))
+			Expect(err).NotTo(HaveOccurred())
+			_, err = mgr.StartServiceSingleNode(newRequest("?service=garbd"))
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(ioutil.ReadFile(mgr.StateFilePath)).To(Equal([]byte("SINGLE_NODE")))
+		})
+	})
+
 	Context("StopService", func() {
 		Context("when monit fails to stop a service", func() {
 			BeforeEach(func() {
@@ -400,6 +1086,50 @@ var _ = Describe("NodeManager", func() {
 				Expect(err).NotTo(HaveOccurred())
 				Expect(msg).To(Equal(`stop successful`))
 			})
+
+			It("tags the stop log with the request ID", func() {
+				logger := lagertest.NewTestLogger("node_manager")
+				mgr.Logger = logger
+
+				req, err := http.NewRequest(http.MethodPost, "/stop_mysql", nil)
+				Expect(err).NotTo(HaveOccurred())
+				req = req.WithContext(requestid.NewContext(req.Context(), "abc123"))
+
+				_, err = mgr.StopService(req)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(logger.Logs()).To(HaveLen(1))
+				Expect(logger.Logs()[0].Message).To(Equal("node_manager.stop"))
+				Expect(logger.Logs()[0].Data).To(HaveKeyWithValue("request_id", "abc123"))
+			})
+		})
+	})
+
+	Context("when DryRun is set", func() {
+		BeforeEach(func() {
+			mgr.DryRun = true
+		})
+
+		It("does not call monit or write the state file", func() {
+			Expect(mgr.StartServiceBootstrap(nil)).To(Equal("dry-run: would bootstrap"))
+			Expect(mgr.StartServiceJoin(nil)).To(Equal("dry-run: would join cluster"))
+			Expect(mgr.StartServiceSingleNode(nil)).To(Equal("dry-run: would start single node"))
+			Expect(mgr.StopService(nil)).To(Equal("dry-run: would stop"))
+
+			Expect(fakeMonit.StartCallCount()).To(Equal(0))
+			Expect(fakeMonit.StopCallCount()).To(Equal(0))
+			Expect(fakeMonit.StatusCallCount()).To(Equal(0))
+
+			files, err := ioutil.ReadDir(tempDir)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(files).To(BeEmpty())
+		})
+
+		It("still refuses to bootstrap an arbitrator", func() {
+			mgr.ServiceName = "garbd"
+
+			_, err := mgr.StartServiceBootstrap(nil)
+			Expect(err).To(MatchError("bootstrapping arbitrator not allowed"))
 		})
 	})
 
@@ -427,4 +1157,91 @@ var _ = Describe("NodeManager", func() {
 			})
 		})
 	})
+
+	Context("GetProcessStats", func() {
+		It("returns the process stats monit reports for the service", func() {
+			fakeMonit.ProcessStatsReturns(monit_client.ProcessStats{MemoryKB: 1024, CPUPercent: 1.5, UptimeSeconds: 60}, nil)
+
+			stats, err := mgr.GetProcessStats(nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(stats).To(Equal(monit_client.ProcessStats{MemoryKB: 1024, CPUPercent: 1.5, UptimeSeconds: 60}))
+			Expect(fakeMonit.ProcessStatsArgsForCall(0)).To(Equal("galera-init"))
+		})
+	})
+
+	Context("GetState", func() {
+		DescribeTable("returns the trimmed state file contents",
+			func(contents, expected string) {
+				Expect(ioutil.WriteFile(mgr.StateFilePath, []byte(contents), 0644)).To(Succeed())
+
+				state, err := mgr.GetState(nil)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(state).NotTo(BeNil())
+				Expect(*state).To(Equal(expected))
+			},
+			Entry("NEEDS_BOOTSTRAP", "NEEDS_BOOTSTRAP", "NEEDS_BOOTSTRAP"),
+			Entry("CLUSTERED", "CLUSTERED\n", "CLUSTERED"),
+			Entry("SINGLE_NODE", "SINGLE_NODE", "SINGLE_NODE"),
+		)
+
+		It("returns nil when there is no state file", func() {
+			state, err := mgr.GetState(nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(state).To(BeNil())
+		})
+	})
+
+	Context("UnmonitorService", func() {
+		It("unmonitors the service without stopping it", func() {
+			Expect(mgr.UnmonitorService(nil)).To(Equal("unmonitor successful"))
+			Expect(fakeMonit.UnmonitorCallCount()).To(Equal(1))
+			Expect(fakeMonit.UnmonitorArgsForCall(0)).To(Equal("galera-init"))
+			Expect(fakeMonit.StopCallCount()).To(BeZero())
+		})
+
+		It("returns the monit error", func() {
+			fakeMonit.UnmonitorReturns(errors.New("monit unmonitor error"))
+
+			_, err := mgr.UnmonitorService(nil)
+			Expect(err).To(MatchError("monit unmonitor error"))
+		})
+
+		It("does not call monit in dry-run mode", func() {
+			mgr.DryRun = true
+
+			Expect(mgr.UnmonitorService(nil)).To(Equal("dry-run: would unmonitor"))
+			Expect(fakeMonit.UnmonitorCallCount()).To(BeZero())
+		})
+	})
+
+	Context("MonitorService", func() {
+		It("monitors the service", func() {
+			Expect(mgr.MonitorService(nil)).To(Equal("monitor successful"))
+			Expect(fakeMonit.MonitorCallCount()).To(Equal(1))
+			Expect(fakeMonit.MonitorArgsForCall(0)).To(Equal("galera-init"))
+		})
+
+		It("does not call monit in dry-run mode", func() {
+			mgr.DryRun = true
+
+			Expect(mgr.MonitorService(nil)).To(Equal("dry-run: would monitor"))
+			Expect(fakeMonit.MonitorCallCount()).To(BeZero())
+		})
+	})
+
+	Context("ReloadMonit", func() {
+		It("reloads monit and returns its response", func() {
+			fakeMonit.ReloadReturns("Monit daemon reinitialized", nil)
+
+			Expect(mgr.ReloadMonit(nil)).To(Equal("Monit daemon reinitialized"))
+			Expect(fakeMonit.ReloadCallCount()).To(Equal(1))
+		})
+
+		It("does not reload monit in dry-run mode", func() {
+			mgr.DryRun = true
+
+			Expect(mgr.ReloadMonit(nil)).To(Equal("dry-run: would reload monit"))
+			Expect(fakeMonit.ReloadCallCount()).To(BeZero())
+		})
+	})
 })
@@ -4,10 +4,59 @@ package node_managerfakes
 import (
 	"sync"
 
+	"github.com/cloudfoundry-incubator/galera-healthcheck/monit_client"
 	"github.com/cloudfoundry-incubator/galera-healthcheck/node_manager"
 )
 
 type FakeMonitClient struct {
+	MonitorStub        func(string) error
+	monitorMutex       sync.RWMutex
+	monitorArgsForCall []struct {
+		arg1 string
+	}
+	monitorReturns struct {
+		result1 error
+	}
+	monitorReturnsOnCall map[int]struct {
+		result1 error
+	}
+	ProcessStatsStub        func(string) (monit_client.ProcessStats, error)
+	processStatsMutex       sync.RWMutex
+	processStatsArgsForCall []struct {
+		arg1 string
+	}
+	processStatsReturns struct {
+		result1 monit_client.ProcessStats
+		result2 error
+	}
+	processStatsReturnsOnCall map[int]struct {
+		result1 monit_client.ProcessStats
+		result2 error
+	}
+	ReloadStub        func() (string, error)
+	reloadMutex       sync.RWMutex
+	reloadArgsForCall []struct {
+	}
+	reloadReturns struct {
+		result1 string
+		result2 error
+	}
+	reloadReturnsOnCall map[int]struct {
+		result1 string
+		result2 error
+	}
+	ServicesStub        func() ([]monit_client.ServiceSummary, error)
+	servicesMutex       sync.RWMutex
+	servicesArgsForCall []struct {
+	}
+	servicesReturns struct {
+		result1 []monit_client.ServiceSummary
+		result2 error
+	}
+	servicesReturnsOnCall map[int]struct {
+		result1 []monit_client.ServiceSummary
+		result2 error
+	}
 	StartStub        func(string) error
 	startMutex       sync.RWMutex
 	startArgsForCall []struct {
@@ -43,10 +92,254 @@ type FakeMonitClient struct {
 	stopReturnsOnCall map[int]struct {
 		result1 error
 	}
+	UnmonitorStub        func(string) error
+	unmonitorMutex       sync.RWMutex
+	unmonitorArgsForCall []struct {
+		arg1 string
+	}
+	unmonitorReturns struct {
+		result1 error
+	}
+	unmonitorReturnsOnCall map[int]struct {
+		result1 error
+	}
 	invocations      map[string][][]interface{}
 	invocationsMutex sync.RWMutex
 }
 
+func (fake *FakeMonitClient) Monitor(arg1 string) error {
+	fake.monitorMutex.Lock()
+	ret, specificReturn := fake.monitorReturnsOnCall[len(fake.monitorArgsForCall)]
+	fake.monitorArgsForCall = append(fake.monitorArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	fake.recordInvocation("Monitor", []interface{}{arg1})
+	fake.monitorMutex.Unlock()
+	if fake.MonitorStub != nil {
+		return fake.MonitorStub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	fakeReturns := fake.monitorReturns
+	return fakeReturns.result1
+}
+
+func (fake *FakeMonitClient) MonitorCallCount() int {
+	fake.monitorMutex.RLock()
+	defer fake.monitorMutex.RUnlock()
+	return len(fake.monitorArgsForCall)
+}
+
+func (fake *FakeMonitClient) MonitorCalls(stub func(string) error) {
+	fake.monitorMutex.Lock()
+	defer fake.monitorMutex.Unlock()
+	fake.MonitorStub = stub
+}
+
+func (fake *FakeMonitClient) MonitorArgsForCall(i int) string {
+	fake.monitorMutex.RLock()
+	defer fake.monitorMutex.RUnlock()
+	argsForCall := fake.monitorArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeMonitClient) MonitorReturns(result1 error) {
+	fake.monitorMutex.Lock()
+	defer fake.monitorMutex.Unlock()
+	fake.MonitorStub = nil
+	fake.monitorReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeMonitClient) MonitorReturnsOnCall(i int, result1 error) {
+	fake.monitorMutex.Lock()
+	defer fake.monitorMutex.Unlock()
+	fake.MonitorStub = nil
+	if fake.monitorReturnsOnCall == nil {
+		fake.monitorReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.monitorReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeMonitClient) ProcessStats(arg1 string) (monit_client.ProcessStats, error) {
+	fake.processStatsMutex.Lock()
+	ret, specificReturn := fake.processStatsReturnsOnCall[len(fake.processStatsArgsForCall)]
+	fake.processStatsArgsForCall = append(fake.processStatsArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	fake.recordInvocation("ProcessStats", []interface{}{arg1})
+	fake.processStatsMutex.Unlock()
+	if fake.ProcessStatsStub != nil {
+		return fake.ProcessStatsStub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.processStatsReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeMonitClient) ProcessStatsCallCount() int {
+	fake.processStatsMutex.RLock()
+	defer fake.processStatsMutex.RUnlock()
+	return len(fake.processStatsArgsForCall)
+}
+
+func (fake *FakeMonitClient) ProcessStatsCalls(stub func(string) (monit_client.ProcessStats, error)) {
+	fake.processStatsMutex.Lock()
+	defer fake.processStatsMutex.Unlock()
+	fake.ProcessStatsStub = stub
+}
+
+func (fake *FakeMonitClient) ProcessStatsArgsForCall(i int) string {
+	fake.processStatsMutex.RLock()
+	defer fake.processStatsMutex.RUnlock()
+	argsForCall := fake.processStatsArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeMonitClient) ProcessStatsReturns(result1 monit_client.ProcessStats, result2 error) {
+	fake.processStatsMutex.Lock()
+	defer fake.processStatsMutex.Unlock()
+	fake.ProcessStatsStub = nil
+	fake.processStatsReturns = struct {
+		result1 monit_client.ProcessStats
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeMonitClient) ProcessStatsReturnsOnCall(i int, result1 monit_client.ProcessStats, result2 error) {
+	fake.processStatsMutex.Lock()
+	defer fake.processStatsMutex.Unlock()
+	fake.ProcessStatsStub = nil
+	if fake.processStatsReturnsOnCall == nil {
+		fake.processStatsReturnsOnCall = make(map[int]struct {
+			result1 monit_client.ProcessStats
+			result2 error
+		})
+	}
+	fake.processStatsReturnsOnCall[i] = struct {
+		result1 monit_client.ProcessStats
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeMonitClient) Reload() (string, error) {
+	fake.reloadMutex.Lock()
+	ret, specificReturn := fake.reloadReturnsOnCall[len(fake.reloadArgsForCall)]
+	fake.reloadArgsForCall = append(fake.reloadArgsForCall, struct {
+	}{})
+	fake.recordInvocation("Reload", []interface{}{})
+	fake.reloadMutex.Unlock()
+	if fake.ReloadStub != nil {
+		return fake.ReloadStub()
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.reloadReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeMonitClient) ReloadCallCount() int {
+	fake.reloadMutex.RLock()
+	defer fake.reloadMutex.RUnlock()
+	return len(fake.reloadArgsForCall)
+}
+
+func (fake *FakeMonitClient) ReloadCalls(stub func() (string, error)) {
+	fake.reloadMutex.Lock()
+	defer fake.reloadMutex.Unlock()
+	fake.ReloadStub = stub
+}
+
+func (fake *FakeMonitClient) ReloadReturns(result1 string, result2 error) {
+	fake.reloadMutex.Lock()
+	defer fake.reloadMutex.Unlock()
+	fake.ReloadStub = nil
+	fake.reloadReturns = struct {
+		result1 string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeMonitClient) ReloadReturnsOnCall(i int, result1 string, result2 error) {
+	fake.reloadMutex.Lock()
+	defer fake.reloadMutex.Unlock()
+	fake.ReloadStub = nil
+	if fake.reloadReturnsOnCall == nil {
+		fake.reloadReturnsOnCall = make(map[int]struct {
+			result1 string
+			result2 error
+		})
+	}
+	fake.reloadReturnsOnCall[i] = struct {
+		result1 string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeMonitClient) Services() ([]monit_client.ServiceSummary, error) {
+	fake.servicesMutex.Lock()
+	ret, specificReturn := fake.servicesReturnsOnCall[len(fake.servicesArgsForCall)]
+	fake.servicesArgsForCall = append(fake.servicesArgsForCall, struct {
+	}{})
+	fake.recordInvocation("Services", []interface{}{})
+	fake.servicesMutex.Unlock()
+	if fake.ServicesStub != nil {
+		return fake.ServicesStub()
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.servicesReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeMonitClient) ServicesCallCount() int {
+	fake.servicesMutex.RLock()
+	defer fake.servicesMutex.RUnlock()
+	return len(fake.servicesArgsForCall)
+}
+
+func (fake *FakeMonitClient) ServicesCalls(stub func() ([]monit_client.ServiceSummary, error)) {
+	fake.servicesMutex.Lock()
+	defer fake.servicesMutex.Unlock()
+	fake.ServicesStub = stub
+}
+
+func (fake *FakeMonitClient) ServicesReturns(result1 []monit_client.ServiceSummary, result2 error) {
+	fake.servicesMutex.Lock()
+	defer fake.servicesMutex.Unlock()
+	fake.ServicesStub = nil
+	fake.servicesReturns = struct {
+		result1 []monit_client.ServiceSummary
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeMonitClient) ServicesReturnsOnCall(i int, result1 []monit_client.ServiceSummary, result2 error) {
+	fake.servicesMutex.Lock()
+	defer fake.servicesMutex.Unlock()
+	fake.ServicesStub = nil
+	if fake.servicesReturnsOnCall == nil {
+		fake.servicesReturnsOnCall = make(map[int]struct {
+			result1 []monit_client.ServiceSummary
+			result2 error
+		})
+	}
+	fake.servicesReturnsOnCall[i] = struct {
+		result1 []monit_client.ServiceSummary
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *FakeMonitClient) Start(arg1 string) error {
 	fake.startMutex.Lock()
 	ret, specificReturn := fake.startReturnsOnCall[len(fake.startArgsForCall)]
@@ -230,15 +523,85 @@ func (fake *FakeMonitClient) StopReturnsOnCall(i int, result1 error) {
 	}{result1}
 }
 
+func (fake *FakeMonitClient) Unmonitor(arg1 string) error {
+	fake.unmonitorMutex.Lock()
+	ret, specificReturn := fake.unmonitorReturnsOnCall[len(fake.unmonitorArgsForCall)]
+	fake.unmonitorArgsForCall = append(fake.unmonitorArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	fake.recordInvocation("Unmonitor", []interface{}{arg1})
+	fake.unmonitorMutex.Unlock()
+	if fake.UnmonitorStub != nil {
+		return fake.UnmonitorStub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	fakeReturns := fake.unmonitorReturns
+	return fakeReturns.result1
+}
+
+func (fake *FakeMonitClient) UnmonitorCallCount() int {
+	fake.unmonitorMutex.RLock()
+	defer fake.unmonitorMutex.RUnlock()
+	return len(fake.unmonitorArgsForCall)
+}
+
+func (fake *FakeMonitClient) UnmonitorCalls(stub func(string) error) {
+	fake.unmonitorMutex.Lock()
+	defer fake.unmonitorMutex.Unlock()
+	fake.UnmonitorStub = stub
+}
+
+func (fake *FakeMonitClient) UnmonitorArgsForCall(i int) string {
+	fake.unmonitorMutex.RLock()
+	defer fake.unmonitorMutex.RUnlock()
+	argsForCall := fake.unmonitorArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeMonitClient) UnmonitorReturns(result1 error) {
+	fake.unmonitorMutex.Lock()
+	defer fake.unmonitorMutex.Unlock()
+	fake.UnmonitorStub = nil
+	fake.unmonitorReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeMonitClient) UnmonitorReturnsOnCall(i int, result1 error) {
+	fake.unmonitorMutex.Lock()
+	defer fake.unmonitorMutex.Unlock()
+	fake.UnmonitorStub = nil
+	if fake.unmonitorReturnsOnCall == nil {
+		fake.unmonitorReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.unmonitorReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
 func (fake *FakeMonitClient) Invocations() map[string][][]interface{} {
 	fake.invocationsMutex.RLock()
 	defer fake.invocationsMutex.RUnlock()
+	fake.monitorMutex.RLock()
+	defer fake.monitorMutex.RUnlock()
+	fake.processStatsMutex.RLock()
+	defer fake.processStatsMutex.RUnlock()
+	fake.reloadMutex.RLock()
+	defer fake.reloadMutex.RUnlock()
+	fake.servicesMutex.RLock()
+	defer fake.servicesMutex.RUnlock()
 	fake.startMutex.RLock()
 	defer fake.startMutex.RUnlock()
 	fake.statusMutex.RLock()
 	defer fake.statusMutex.RUnlock()
 	fake.stopMutex.RLock()
 	defer fake.stopMutex.RUnlock()
+	fake.unmonitorMutex.RLock()
+	defer fake.unmonitorMutex.RUnlock()
 	copiedInvocations := map[string][][]interface{}{}
 	for key, value := range fake.invocations {
 		copiedInvocations[key] = value
@@ -0,0 +1,48 @@
+package requestid
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+
+	"code.cloudfoundry.org/lager"
+)
+
+// Header is the HTTP header used to carry a request's correlation ID.
+const Header = "X-Request-Id"
+
+type contextKey struct{}
+
+// New returns a random correlation ID.
+func New() string {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(id)
+}
+
+// NewContext returns a copy of ctx carrying the given correlation ID.
+func NewContext(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, contextKey{}, id)
+}
+
+// FromContext returns the correlation ID stored in ctx, if any.
+func FromContext(ctx context.Context) (string, bool) {
+	if ctx == nil {
+		return "", false
+	}
+	id, ok := ctx.Value(contextKey{}).(string)
+	return id, ok && id != ""
+}
+
+// Logger tags logger with the correlation ID stored in ctx so that every
+// line logged while serving a request can be traced back to it. The logger
+// is returned unchanged when ctx carries no ID.
+func Logger(logger lager.Logger, ctx context.Context) lager.Logger {
+	id, ok := FromContext(ctx)
+	if !ok {
+		return logger
+	}
+	return logger.WithData(lager.Data{"request_id": id})
+}
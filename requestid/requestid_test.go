@@ -0,0 +1,51 @@
+package requestid_test
+
+import (
+	"context"
+
+	"code.cloudfoundry.org/lager/lagertest"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/cloudfoundry-incubator/galera-healthcheck/requestid"
+)
+
+var _ = Describe("requestid", func() {
+	It("round-trips an ID through a context", func() {
+		ctx := requestid.NewContext(context.Background(), "abc123")
+
+		id, ok := requestid.FromContext(ctx)
+		Expect(ok).To(BeTrue())
+		Expect(id).To(Equal("abc123"))
+	})
+
+	It("reports no ID for a context without one", func() {
+		_, ok := requestid.FromContext(context.Background())
+		Expect(ok).To(BeFalse())
+	})
+
+	It("generates distinct IDs", func() {
+		Expect(requestid.New()).To(HaveLen(32))
+		Expect(requestid.New()).NotTo(Equal(requestid.New()))
+	})
+
+	Describe("Logger", func() {
+		It("tags log lines with the request ID", func() {
+			logger := lagertest.NewTestLogger("requestid")
+			ctx := requestid.NewContext(context.Background(), "abc123")
+
+			requestid.Logger(logger, ctx).Info("something")
+
+			Expect(logger.Logs()).To(HaveLen(1))
+			Expect(logger.Logs()[0].Data).To(HaveKeyWithValue("request_id", "abc123"))
+		})
+
+		It("leaves the logger untouched without a request ID", func() {
+			logger := lagertest.NewTestLogger("requestid")
+
+			requestid.Logger(logger, context.Background()).Info("something")
+
+			Expect(logger.Logs()[0].Data).NotTo(HaveKey("request_id"))
+		})
+	})
+})
@@ -0,0 +1,65 @@
+package sequence_number
+
+import (
+	"errors"
+	"sort"
+)
+
+// ErrNoCandidates is returned by Compare when no node reported a seqno.
+var ErrNoCandidates = errors.New("no node reported a sequence number")
+
+// NodeSeqno is one node's reported sequence number. Seqno is nil for an
+// arbitrator, which holds no data and can never win.
+type NodeSeqno struct {
+	Node  string `json:"node"`
+	Seqno *int   `json:"seqno"`
+}
+
+// Comparison is the outcome of comparing the seqnos reported by a cluster.
+// Tied lists every node sharing the highest seqno when there is more than
+// one; Winner is then the first of them by name so that every node that
+// runs the comparison agrees on the same one.
+type Comparison struct {
+	Winner       string   `json:"winner"`
+	HighestSeqno int      `json:"highest_seqno"`
+	Tied         []string `json:"tied,omitempty"`
+}
+
+// Compare picks the node with the highest seqno, which is the one that must
+// bootstrap the cluster when recovering from a full outage.
+func Compare(nodes []NodeSeqno) (Comparison, error) {
+	var (
+		highest int
+		leaders []string
+	)
+
+	for _, n := range nodes {
+		if n.Seqno == nil {
+			continue
+		}
+
+		switch {
+		case leaders == nil || *n.Seqno > highest:
+			highest = *n.Seqno
+			leaders = []string{n.Node}
+		case *n.Seqno == highest:
+			leaders = append(leaders, n.Node)
+		}
+	}
+
+	if len(leaders) == 0 {
+		return Comparison{}, ErrNoCandidates
+	}
+
+	sort.Strings(leaders)
+
+	comparison := Comparison{
+		Winner:       leaders[0],
+		HighestSeqno: highest,
+	}
+	if len(leaders) > 1 {
+		comparison.Tied = leaders
+	}
+
+	return comparison, nil
+}
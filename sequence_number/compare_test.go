@@ -0,0 +1,56 @@
+package sequence_number_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/cloudfoundry-incubator/galera-healthcheck/sequence_number"
+)
+
+var _ = Describe("Compare", func() {
+	seqno := func(n int) *int { return &n }
+
+	It("picks the node with the highest seqno", func() {
+		comparison, err := sequence_number.Compare([]sequence_number.NodeSeqno{
+			{Node: "mysql/0", Seqno: seqno(10)},
+			{Node: "mysql/1", Seqno: seqno(12)},
+			{Node: "mysql/2", Seqno: seqno(11)},
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(comparison).To(Equal(sequence_number.Comparison{
+			Winner:       "mysql/1",
+			HighestSeqno: 12,
+		}))
+	})
+
+	It("breaks a tie by node name and lists the tied nodes", func() {
+		comparison, err := sequence_number.Compare([]sequence_number.NodeSeqno{
+			{Node: "mysql/2", Seqno: seqno(12)},
+			{Node: "mysql/0", Seqno: seqno(10)},
+			{Node: "mysql/1", Seqno: seqno(12)},
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(comparison).To(Equal(sequence_number.Comparison{
+			Winner:       "mysql/1",
+			HighestSeqno: 12,
+			Tied:         []string{"mysql/1", "mysql/2"},
+		}))
+	})
+
+	It("ignores arbitrators", func() {
+		comparison, err := sequence_number.Compare([]sequence_number.NodeSeqno{
+			{Node: "arbitrator/0"},
+			{Node: "mysql/0", Seqno: seqno(0)},
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(comparison.Winner).To(Equal("mysql/0"))
+		Expect(comparison.HighestSeqno).To(Equal(0))
+	})
+
+	It("fails when only arbitrators reported", func() {
+		_, err := sequence_number.Compare([]sequence_number.NodeSeqno{
+			{Node: "arbitrator/0"},
+		})
+		Expect(err).To(MatchError(sequence_number.ErrNoCandidates))
+	})
+})
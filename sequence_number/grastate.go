@@ -0,0 +1,68 @@
+package sequence_number
+
+import (
+	"bufio"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Grastate is the saved galera state that mysqld writes to grastate.dat on
+// shutdown. Seqno is -1 when the node did not shut down cleanly.
+type Grastate struct {
+	UUID            string
+	Seqno           int
+	SafeToBootstrap bool
+}
+
+func ReadGrastate(path string) (Grastate, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return Grastate{}, err
+	}
+	defer f.Close()
+
+	var (
+		state     Grastate
+		seqnoSeen bool
+	)
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.SplitN(line, ":", 2)
+		if len(fields) != 2 {
+			continue
+		}
+
+		key, value := strings.TrimSpace(fields[0]), strings.TrimSpace(fields[1])
+		switch key {
+		case "uuid":
+			state.UUID = value
+		case "seqno":
+			state.Seqno, err = strconv.Atoi(value)
+			if err != nil {
+				return Grastate{}, errors.Wrapf(err, "invalid seqno in %s", path)
+			}
+			seqnoSeen = true
+		case "safe_to_bootstrap":
+			state.SafeToBootstrap = value == "1"
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return Grastate{}, err
+	}
+
+	if !seqnoSeen {
+		return Grastate{}, errors.Errorf("no seqno found in %s", path)
+	}
+
+	return state, nil
+}
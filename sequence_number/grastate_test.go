@@ -0,0 +1,66 @@
+package sequence_number_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/cloudfoundry-incubator/galera-healthcheck/sequence_number"
+)
+
+var _ = Describe("ReadGrastate", func() {
+	var (
+		tempDir string
+		path    string
+	)
+
+	BeforeEach(func() {
+		var err error
+		tempDir, err = ioutil.TempDir("", "grastate")
+		Expect(err).NotTo(HaveOccurred())
+		path = filepath.Join(tempDir, "grastate.dat")
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(tempDir)
+	})
+
+	It("parses the uuid, seqno and safe_to_bootstrap fields", func() {
+		Expect(ioutil.WriteFile(path, []byte(`# GALERA saved state
+version: 2.1
+uuid:    6d8c3c23-0c8b-11eb-a2b7-6f0b4e5a5f2b
+seqno:   1234
+safe_to_bootstrap: 1
+`), 0600)).To(Succeed())
+
+		state, err := sequence_number.ReadGrastate(path)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(state).To(Equal(sequence_number.Grastate{
+			UUID:            "6d8c3c23-0c8b-11eb-a2b7-6f0b4e5a5f2b",
+			Seqno:           1234,
+			SafeToBootstrap: true,
+		}))
+	})
+
+	It("returns an error when the seqno is missing", func() {
+		Expect(ioutil.WriteFile(path, []byte("version: 2.1\n"), 0600)).To(Succeed())
+
+		_, err := sequence_number.ReadGrastate(path)
+		Expect(err).To(MatchError(ContainSubstring("no seqno found")))
+	})
+
+	It("returns an error when the seqno is not a number", func() {
+		Expect(ioutil.WriteFile(path, []byte("seqno: abc\n"), 0600)).To(Succeed())
+
+		_, err := sequence_number.ReadGrastate(path)
+		Expect(err).To(MatchError(ContainSubstring("invalid seqno")))
+	})
+
+	It("returns an error when the file does not exist", func() {
+		_, err := sequence_number.ReadGrastate(path)
+		Expect(os.IsNotExist(err)).To(BeTrue())
+	})
+})
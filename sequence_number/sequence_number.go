@@ -11,18 +11,28 @@ import (
 
 	"code.cloudfoundry.org/lager"
 	"github.com/cloudfoundry-incubator/galera-healthcheck/config"
+	"github.com/cloudfoundry-incubator/galera-healthcheck/monit_client"
 	"github.com/cloudfoundry-incubator/galera-healthcheck/mysqld_cmd"
 )
 
+//go:generate go run github.com/maxbrunsfeld/counterfeiter/v6 . MonitClient
+
+// MonitClient reports the status of the monit service that runs mysqld.
+type MonitClient interface {
+	Status(processName string) (string, error)
+}
+
 type SequenceNumberChecker struct {
 	db        *sql.DB
 	config    config.Config
 	logger    lager.Logger
 	mysqldCmd mysqld_cmd.MysqldCmd
+	monit     MonitClient
 }
 
 func New(db *sql.DB,
 	mysqldCmd mysqld_cmd.MysqldCmd,
+	monit MonitClient,
 	config config.Config,
 	logger lager.Logger,
 ) *SequenceNumberChecker {
@@ -31,16 +41,68 @@ func New(db *sql.DB,
 		config:    config,
 		logger:    logger,
 		mysqldCmd: mysqldCmd,
+		monit:     monit,
+	}
+}
+
+// MysqldRunningError is returned when recovery is requested while mysqld is
+// running; running mysqld --wsrep-recover against live data files is unsafe.
+type MysqldRunningError struct{}
+
+func (MysqldRunningError) Error() string {
+	return "refusing to run wsrep recovery while mysqld is running"
+}
+
+func (MysqldRunningError) StatusCode() int {
+	return http.StatusConflict
+}
+
+// RecoverPosition runs wsrep recovery and returns the recovered cluster state
+// UUID and seqno. It refuses to run while the database is reachable or monit
+// reports mysqld as anything but stopped.
+func (s *SequenceNumberChecker) RecoverPosition(req *http.Request) (mysqld_cmd.RecoveredPosition, error) {
+	s.logger.Info("Recovering wsrep position of database node...")
+
+	if s.dbReachable() {
+		return mysqld_cmd.RecoveredPosition{}, MysqldRunningError{}
+	}
+
+	if err := s.ensureMysqldStopped(); err != nil {
+		return mysqld_cmd.RecoveredPosition{}, err
+	}
+
+	return s.mysqldCmd.RecoverPosition()
+}
+
+// ensureMysqldStopped returns MysqldRunningError unless monit reports the
+// mysqld service as stopped. A mysqld that is starting, running an SST, has
+// lost its socket or is merely unmonitored is not reachable but must not be
+// recovered.
+func (s *SequenceNumberChecker) ensureMysqldStopped() error {
+	service := s.config.Monit.ServiceName
+	status, err := s.monit.Status(service)
+	if err != nil {
+		return fmt.Errorf("failed to get monit status of %s: %s", service, err)
+	}
+
+	if monit_client.NormalizeStatus(status) != monit_client.StateStopped {
+		s.logger.Info("Refusing wsrep recovery", lager.Data{"service": service, "status": status})
+		return MysqldRunningError{}
 	}
+	return nil
 }
 
 func (s *SequenceNumberChecker) Check(req *http.Request) (string, error) {
 	s.logger.Info("Checking sequence number of database node...")
 
-	if s.config.Monit.ServiceName == "garbd" {
+	if s.config.IsArbitrator() {
 		return "no sequence number - running on arbitrator node", nil
 	} else if s.dbReachable() {
-		return "", errors.New("can't determine sequence number when database is running")
+		return s.readSeqNoFromDB()
+	} else if seqno, ok := s.readSeqNoFromGrastate(); ok {
+		return seqno, nil
+	} else if err := s.ensureMysqldStopped(); err != nil {
+		return "", err
 	} else {
 		returnedSeqNo, err := s.readSeqNoFromRecoverCmd()
 		if err != nil {
@@ -60,6 +122,55 @@ func (s *SequenceNumberChecker) Check(req *http.Request) (string, error) {
 	}
 }
 
+// readSeqNoFromGrastate returns the seqno recorded in grastate.dat. It reports
+// false when the file is not configured, cannot be read, or records -1 after
+// an unclean shutdown, in which case the seqno must be recovered from the
+// InnoDB logs instead.
+func (s *SequenceNumberChecker) readSeqNoFromGrastate() (string, bool) {
+	if s.config.GrastatePath == "" {
+		return "", false
+	}
+
+	s.logger.Info("Reading seqno from grastate", lager.Data{"path": s.config.GrastatePath})
+	state, err := ReadGrastate(s.config.GrastatePath)
+	if err != nil {
+		s.logger.Info(fmt.Sprintf("Unable to read grastate, falling back to wsrep recovery: %s", err.Error()))
+		return "", false
+	}
+
+	s.logger.Info("Read grastate", lager.Data{
+		"uuid":              state.UUID,
+		"seqno":             state.Seqno,
+		"safe_to_bootstrap": state.SafeToBootstrap,
+	})
+
+	if state.Seqno < 0 {
+		s.logger.Info("grastate seqno is unset after an unclean shutdown, falling back to wsrep recovery")
+		return "", false
+	}
+
+	return strconv.Itoa(state.Seqno), true
+}
+
+// readSeqNoFromDB returns wsrep_last_committed from the running node.
+// Recovery cannot be used here as mysqld holds the InnoDB logs open.
+func (s *SequenceNumberChecker) readSeqNoFromDB() (string, error) {
+	s.logger.Info("Database is running, reading seqno from wsrep_last_committed")
+
+	var unused, seqno string
+	err := s.db.QueryRow("SHOW STATUS LIKE 'wsrep_last_committed'").Scan(&unused, &seqno)
+	if err != nil {
+		s.logger.Error("Failed to read wsrep_last_committed", err)
+		return "", err
+	}
+
+	if _, err := strconv.Atoi(seqno); err != nil {
+		return "", fmt.Errorf("Invalid sequence number %s", seqno)
+	}
+
+	return seqno, nil
+}
+
 func (s *SequenceNumberChecker) readSeqNoFromRecoverCmd() (string, error) {
 	s.logger.Info("Reading seqno from logs")
 	seqno, err := s.mysqldCmd.RecoverSeqno()
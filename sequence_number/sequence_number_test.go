@@ -4,6 +4,9 @@ import (
 	"database/sql"
 	"database/sql/driver"
 	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 
 	"github.com/erikstmartin/go-testdb"
 	. "github.com/onsi/ginkgo"
@@ -13,8 +16,10 @@ import (
 
 	"code.cloudfoundry.org/lager/lagertest"
 	"github.com/cloudfoundry-incubator/galera-healthcheck/config"
+	"github.com/cloudfoundry-incubator/galera-healthcheck/mysqld_cmd"
 	"github.com/cloudfoundry-incubator/galera-healthcheck/mysqld_cmd/fakes"
 	"github.com/cloudfoundry-incubator/galera-healthcheck/sequence_number"
+	"github.com/cloudfoundry-incubator/galera-healthcheck/sequence_number/sequence_numberfakes"
 )
 
 var _ = Describe("GaleraSequenceChecker", func() {
@@ -27,6 +32,7 @@ var _ = Describe("GaleraSequenceChecker", func() {
 	var (
 		sequenceChecker *sequence_number.SequenceNumberChecker
 		mysqldCmd       *fakes.FakeMysqldCmd
+		monit           *sequence_numberfakes.FakeMonitClient
 		rootConfig      config.Config
 		logger          *lagertest.TestLogger
 		db              *sql.DB
@@ -39,10 +45,14 @@ var _ = Describe("GaleraSequenceChecker", func() {
 
 		mysqldCmd = &fakes.FakeMysqldCmd{}
 		mysqldCmd.RecoverSeqnoReturns(expectedSeqNumber, nil)
+
+		rootConfig.Monit.ServiceName = "galera-init"
+		monit = &sequence_numberfakes.FakeMonitClient{}
+		monit.StatusReturns("stopped", nil)
 	})
 
 	JustBeforeEach(func() {
-		sequenceChecker = sequence_number.New(db, mysqldCmd, rootConfig, logger)
+		sequenceChecker = sequence_number.New(db, mysqldCmd, monit, rootConfig, logger)
 	})
 
 	AfterEach(func() {
@@ -58,9 +68,22 @@ var _ = Describe("GaleraSequenceChecker", func() {
 				})
 			})
 
-			It("returns an unsuccessful check", func() {
+			It("returns wsrep_last_committed without running recovery", func() {
+				testdb.StubQuery("SHOW STATUS LIKE 'wsrep_last_committed'",
+					testdb.RowsFromCSVString([]string{"Variable_name", "Value"}, "wsrep_last_committed,57"))
+
+				seq, err := sequenceChecker.Check(createReq())
+				Expect(err).ToNot(HaveOccurred())
+				Expect(seq).To(Equal("57"))
+				Expect(mysqldCmd.RecoverSeqnoCallCount()).To(Equal(0))
+			})
+
+			It("returns an error when wsrep_last_committed cannot be read", func() {
+				testdb.StubQueryError("SHOW STATUS LIKE 'wsrep_last_committed'", errors.New("query failed"))
+
 				_, err := sequenceChecker.Check(createReq())
-				Expect(err).To(MatchError("can't determine sequence number when database is running"))
+				Expect(err).To(MatchError("query failed"))
+				Expect(mysqldCmd.RecoverSeqnoCallCount()).To(Equal(0))
 			})
 		})
 
@@ -71,10 +94,70 @@ var _ = Describe("GaleraSequenceChecker", func() {
 				})
 			})
 
-			It("returns a successful sequence number", func() {
+			It("returns the sequence number from wsrep recovery", func() {
 				seq, err := sequenceChecker.Check(createReq())
 				Expect(err).ToNot(HaveOccurred())
 				Expect(seq).To(ContainSubstring(expectedSeqNumber))
+				Expect(mysqldCmd.RecoverSeqnoCallCount()).To(Equal(1))
+				Expect(monit.StatusArgsForCall(0)).To(Equal("galera-init"))
+			})
+
+			It("refuses to run recovery while monit reports mysqld starting", func() {
+				monit.StatusReturns("Not monitored - start pending", nil)
+
+				_, err := sequenceChecker.Check(createReq())
+				Expect(err).To(Equal(sequence_number.MysqldRunningError{}))
+				Expect(mysqldCmd.RecoverSeqnoCallCount()).To(Equal(0))
+			})
+
+			Context("and grastate.dat records the seqno", func() {
+				var tempDir string
+
+				BeforeEach(func() {
+					var err error
+					tempDir, err = ioutil.TempDir("", "grastate")
+					Expect(err).NotTo(HaveOccurred())
+
+					rootConfig.GrastatePath = filepath.Join(tempDir, "grastate.dat")
+				})
+
+				AfterEach(func() {
+					os.RemoveAll(tempDir)
+				})
+
+				var writeGrastate = func(seqno string) {
+					contents := "# GALERA saved state\n" +
+						"version: 2.1\n" +
+						"uuid:    6d8c3c23-0c8b-11eb-a2b7-6f0b4e5a5f2b\n" +
+						"seqno:   " + seqno + "\n" +
+						"safe_to_bootstrap: 1\n"
+					Expect(ioutil.WriteFile(rootConfig.GrastatePath, []byte(contents), 0600)).To(Succeed())
+				}
+
+				It("returns the seqno from grastate.dat without running recovery", func() {
+					writeGrastate("41")
+
+					seq, err := sequenceChecker.Check(createReq())
+					Expect(err).ToNot(HaveOccurred())
+					Expect(seq).To(Equal("41"))
+					Expect(mysqldCmd.RecoverSeqnoCallCount()).To(Equal(0))
+				})
+
+				It("falls back to wsrep recovery when grastate.dat records -1", func() {
+					writeGrastate("-1")
+
+					seq, err := sequenceChecker.Check(createReq())
+					Expect(err).ToNot(HaveOccurred())
+					Expect(seq).To(Equal(expectedSeqNumber))
+					Expect(mysqldCmd.RecoverSeqnoCallCount()).To(Equal(1))
+				})
+
+				It("falls back to wsrep recovery when grastate.dat does not exist", func() {
+					seq, err := sequenceChecker.Check(createReq())
+					Expect(err).ToNot(HaveOccurred())
+					Expect(seq).To(Equal(expectedSeqNumber))
+					Expect(mysqldCmd.RecoverSeqnoCallCount()).To(Equal(1))
+				})
 			})
 
 			Context("and recover cmd returns -1", func() {
@@ -116,6 +199,79 @@ var _ = Describe("GaleraSequenceChecker", func() {
 			})
 		})
 	})
+
+	Describe("RecoverPosition", func() {
+		BeforeEach(func() {
+			mysqldCmd.RecoverPositionReturns(mysqld_cmd.RecoveredPosition{
+				UUID:  "6d8c3c23-0c8b-11eb-a2b7-6f0b4e5a5f2b",
+				Seqno: 32,
+			}, nil)
+		})
+
+		Context("when mysqld is running", func() {
+			BeforeEach(func() {
+				testdb.SetExecFunc(func(query string) (driver.Result, error) {
+					return nil, nil
+				})
+			})
+
+			It("refuses to run recovery", func() {
+				_, err := sequenceChecker.RecoverPosition(createReq())
+				Expect(err).To(Equal(sequence_number.MysqldRunningError{}))
+				Expect(err.(sequence_number.MysqldRunningError).StatusCode()).To(Equal(http.StatusConflict))
+				Expect(mysqldCmd.RecoverPositionCallCount()).To(Equal(0))
+			})
+		})
+
+		Context("when mysqld is stopped", func() {
+			BeforeEach(func() {
+				testdb.SetExecFunc(func(query string) (driver.Result, error) {
+					return nil, errors.New("failed to connect")
+				})
+			})
+
+			It("returns the recovered position", func() {
+				position, err := sequenceChecker.RecoverPosition(createReq())
+				Expect(err).NotTo(HaveOccurred())
+				Expect(position).To(Equal(mysqld_cmd.RecoveredPosition{
+					UUID:  "6d8c3c23-0c8b-11eb-a2b7-6f0b4e5a5f2b",
+					Seqno: 32,
+				}))
+				Expect(mysqldCmd.RecoverPositionCallCount()).To(Equal(1))
+			})
+
+			It("refuses to recover while monit does not monitor mysqld, which may still be running", func() {
+				monit.StatusReturns("not monitored", nil)
+
+				_, err := sequenceChecker.RecoverPosition(createReq())
+				Expect(err).To(Equal(sequence_number.MysqldRunningError{}))
+				Expect(mysqldCmd.RecoverPositionCallCount()).To(Equal(0))
+			})
+
+			It("refuses to run recovery while monit reports mysqld running", func() {
+				monit.StatusReturns("running", nil)
+
+				_, err := sequenceChecker.RecoverPosition(createReq())
+				Expect(err).To(Equal(sequence_number.MysqldRunningError{}))
+				Expect(mysqldCmd.RecoverPositionCallCount()).To(Equal(0))
+			})
+
+			It("refuses to run recovery when monit cannot be reached", func() {
+				monit.StatusReturns("", errors.New("connection refused"))
+
+				_, err := sequenceChecker.RecoverPosition(createReq())
+				Expect(err).To(MatchError("failed to get monit status of galera-init: connection refused"))
+				Expect(mysqldCmd.RecoverPositionCallCount()).To(Equal(0))
+			})
+
+			It("returns an error when recovery fails", func() {
+				mysqldCmd.RecoverPositionReturns(mysqld_cmd.RecoveredPosition{}, errors.New("recovery failed"))
+
+				_, err := sequenceChecker.RecoverPosition(createReq())
+				Expect(err).To(MatchError("recovery failed"))
+			})
+		})
+	})
 })
 
 func createReq() *http.Request {
@@ -0,0 +1,190 @@
+package webhook
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/lager"
+)
+
+// Transition is the payload posted when a node becomes healthy or unhealthy.
+type Transition struct {
+	Node      string    `json:"node"`
+	OldState  string    `json:"old_state"`
+	NewState  string    `json:"new_state"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// Notifier posts a Transition to URL whenever the observed health of the
+// node flips. Notifications are sent at most once per MinInterval; a node
+// that flaps back within the interval sends nothing, and one that stays
+// flipped is reported when the interval ends.
+//
+// All methods are safe to call on a nil *Notifier, in which case nothing is
+// sent.
+type Notifier struct {
+	URL         string
+	Node        string
+	MinInterval time.Duration
+	Client      *http.Client
+	Logger      lager.Logger
+	// Now returns the current time. Defaults to time.Now.
+	Now func() time.Time
+
+	mu       sync.Mutex
+	current  *observation
+	reported observation
+	lastSent time.Time
+	pending  *time.Timer
+	outbox   []Transition
+	sending  bool
+}
+
+type observation struct {
+	state   string
+	healthy bool
+}
+
+func NewNotifier(url, node string, minInterval, timeout time.Duration, logger lager.Logger) *Notifier {
+	return &Notifier{
+		URL:         url,
+		Node:        node,
+		MinInterval: minInterval,
+		Client:      &http.Client{Timeout: timeout},
+		Logger:      logger,
+	}
+}
+
+// Observe records the node's latest state. The first observation only sets
+// the baseline to compare later ones against.
+func (n *Notifier) Observe(state string, healthy bool) {
+	if n == nil {
+		return
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	observed := observation{state: state, healthy: healthy}
+	if n.current == nil {
+		n.current = &observed
+		n.reported = observed
+		return
+	}
+
+	n.current = &observed
+	if observed.healthy == n.reported.healthy || n.pending != nil {
+		return
+	}
+
+	wait := n.lastSent.Add(n.MinInterval).Sub(n.now())
+	if wait <= 0 {
+		n.sendLocked()
+		return
+	}
+
+	n.pending = time.AfterFunc(wait, n.flush)
+}
+
+// Stop cancels any notification waiting out MinInterval.
+func (n *Notifier) Stop() {
+	if n == nil {
+		return
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if n.pending != nil {
+		n.pending.Stop()
+		n.pending = nil
+	}
+}
+
+func (n *Notifier) flush() {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.pending = nil
+	if n.current.healthy != n.reported.healthy {
+		n.sendLocked()
+	}
+}
+
+func (n *Notifier) sendLocked() {
+	transition := Transition{
+		Node:      n.Node,
+		OldState:  n.reported.state,
+		NewState:  n.current.state,
+		Timestamp: n.now().UTC(),
+	}
+	n.reported = *n.current
+	n.lastSent = n.now()
+
+	// Posts happen in the background so that a slow receiver does not delay
+	// health checks, one at a time so that they arrive in order.
+	n.outbox = append(n.outbox, transition)
+	if !n.sending {
+		n.sending = true
+		go n.drain()
+	}
+}
+
+func (n *Notifier) drain() {
+	for {
+		n.mu.Lock()
+		if len(n.outbox) == 0 {
+			n.sending = false
+			n.mu.Unlock()
+			return
+		}
+		transition := n.outbox[0]
+		n.outbox = n.outbox[1:]
+		n.mu.Unlock()
+
+		n.post(transition)
+	}
+}
+
+func (n *Notifier) post(transition Transition) {
+	logger := n.Logger.Session("state-webhook", lager.Data{
+		"old_state": transition.OldState,
+		"new_state": transition.NewState,
+	})
+
+	body, err := json.Marshal(transition)
+	if err != nil {
+		logger.Error("marshal-failed", err)
+		return
+	}
+
+	client := n.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	resp, err := client.Post(n.URL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		logger.Error("post-failed", err)
+		return
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		logger.Error("post-failed", fmt.Errorf("unexpected response: %s", resp.Status))
+		return
+	}
+
+	logger.Info("posted")
+}
+
+func (n *Notifier) now() time.Time {
+	if n.Now == nil {
+		return time.Now()
+	}
+	return n.Now()
+}
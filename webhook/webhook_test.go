@@ -0,0 +1,125 @@
+package webhook_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"code.cloudfoundry.org/lager/lagertest"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/cloudfoundry-incubator/galera-healthcheck/webhook"
+)
+
+var _ = Describe("Notifier", func() {
+	var (
+		receiver    *httptest.Server
+		transitions chan webhook.Transition
+		notifier    *webhook.Notifier
+	)
+
+	BeforeEach(func() {
+		transitions = make(chan webhook.Transition, 10)
+		receiver = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			defer GinkgoRecover()
+
+			Expect(req.Method).To(Equal("POST"))
+			Expect(req.Header.Get("Content-Type")).To(Equal("application/json"))
+
+			var transition webhook.Transition
+			Expect(json.NewDecoder(req.Body).Decode(&transition)).To(Succeed())
+			transitions <- transition
+		}))
+
+		notifier = webhook.NewNotifier(receiver.URL, "mysql/0", 0, time.Second, lagertest.NewTestLogger("webhook"))
+	})
+
+	AfterEach(func() {
+		notifier.Stop()
+		receiver.Close()
+	})
+
+	It("posts the old and new state when the node becomes unhealthy", func() {
+		now := time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)
+		notifier.Now = func() time.Time { return now }
+
+		notifier.Observe("synced", true)
+		notifier.Observe("not synced", false)
+
+		var transition webhook.Transition
+		Eventually(transitions).Should(Receive(&transition))
+		Expect(transition).To(Equal(webhook.Transition{
+			Node:      "mysql/0",
+			OldState:  "synced",
+			NewState:  "not synced",
+			Timestamp: now,
+		}))
+	})
+
+	It("posts again when the node recovers", func() {
+		notifier.Observe("synced", true)
+		notifier.Observe("not synced", false)
+		notifier.Observe("synced", true)
+
+		var transition webhook.Transition
+		Eventually(transitions).Should(Receive(&transition))
+		Eventually(transitions).Should(Receive(&transition))
+		Expect(transition.OldState).To(Equal("not synced"))
+		Expect(transition.NewState).To(Equal("synced"))
+	})
+
+	It("does not post while the health of the node is unchanged", func() {
+		notifier.Observe("synced", true)
+		notifier.Observe("synced", true)
+		notifier.Observe("synced", true)
+
+		Consistently(transitions, 100*time.Millisecond).ShouldNot(Receive())
+	})
+
+	It("does not post for the first observation", func() {
+		notifier.Observe("not synced", false)
+
+		Consistently(transitions, 100*time.Millisecond).ShouldNot(Receive())
+	})
+
+	Context("when a minimum interval is configured", func() {
+		BeforeEach(func() {
+			notifier.MinInterval = 200 * time.Millisecond
+		})
+
+		It("does not post when the node flaps back within the interval", func() {
+			notifier.Observe("synced", true)
+			notifier.Observe("not synced", false)
+			Eventually(transitions).Should(Receive())
+
+			notifier.Observe("synced", true)
+			notifier.Observe("not synced", false)
+
+			Consistently(transitions, 400*time.Millisecond).ShouldNot(Receive())
+		})
+
+		It("posts the latest state once the interval ends", func() {
+			notifier.Observe("synced", true)
+			notifier.Observe("not synced", false)
+			Eventually(transitions).Should(Receive())
+
+			notifier.Observe("synced", true)
+			Consistently(transitions, 100*time.Millisecond).ShouldNot(Receive())
+
+			var transition webhook.Transition
+			Eventually(transitions).Should(Receive(&transition))
+			Expect(transition.OldState).To(Equal("not synced"))
+			Expect(transition.NewState).To(Equal("synced"))
+		})
+	})
+
+	It("does nothing when nil", func() {
+		var nilNotifier *webhook.Notifier
+		Expect(func() {
+			nilNotifier.Observe("synced", true)
+			nilNotifier.Stop()
+		}).NotTo(Panic())
+	})
+})